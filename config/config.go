@@ -3,26 +3,97 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 	"github.com/joho/godotenv"
 	"log"
 )
 
 type DBConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	Driver   string
+	Host           string
+	Port           string
+	User           string
+	Password       string
+	DBName         string
+	Driver         string
+	MigrateOnStart bool // jalankan migrasi database yang belum dijalankan saat server start
 }
 
 type APIConfig struct {
 	ApiPort string
 }
 
+type MailConfig struct {
+	MailDriver   string // "smtp" atau "file"
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	MailFrom     string
+	OutboxDir    string
+}
+
+type MidtransConfig struct {
+	MidtransEnv       string // "sandbox", "production" atau "fake" (server Midtrans palsu di dalam proses)
+	MidtransServerKey string
+	MidtransSnapURL   string // override base URL Snap, misalnya untuk mock lokal
+	MidtransAPIURL    string // override base URL Core API
+
+	// Rekonsiliasi transaksi pending yang callback-nya tidak sampai
+	ReconcileInterval   time.Duration // 0 mematikan reconciler
+	ReconcileStaleAfter time.Duration // transaksi pending baru dicek setelah tidak berubah selama ini
+	ReconcileWorkers    int           // jumlah pengecekan ke Midtrans yang berjalan bersamaan
+	PendingTTL          time.Duration // transaksi pending lebih lama dari ini dianggap kedaluwarsa
+}
+
+// LLMConfig memilih provider LLM per fitur: "gemini", "deepseek", "openai" atau "ollama"
+type LLMConfig struct {
+	CoachLLMProvider   string
+	JournalLLMProvider string
+	ArticleLLMProvider string
+
+	GeminiAPIKey string
+	GeminiModel  string
+	AIAPIKey     string // API key DeepSeek/OpenAI
+	AIBaseURL    string
+	AIModel      string
+	OllamaURL    string
+	OllamaModel  string
+
+	// Ketahanan panggilan AI, berlaku untuk semua provider
+	AITimeout          time.Duration // batas satu percobaan request
+	AIMaxRetries       int
+	AIRetryBaseDelay   time.Duration
+	AIRetryMaxDelay    time.Duration
+	AIBreakerThreshold int // 0 mematikan circuit breaker
+	AIBreakerCooldown  time.Duration
+}
+
+// JournalAnalysisConfig mengatur worker antrean analisis AI jurnal
+type JournalAnalysisConfig struct {
+	JournalAnalysisWorkers      int           // 0 mematikan worker, misalnya jika antrean diproses instance lain
+	JournalAnalysisPollInterval time.Duration // jeda pengecekan antrean saat kosong
+	JournalAnalysisMaxAttempts  int
+	JournalAnalysisDrainTimeout time.Duration // waktu tunggu analisis yang sedang berjalan saat shutdown
+	TrendReportInterval         time.Duration // jeda pengecekan laporan trend otomatis, 0 mematikannya
+}
+
+// SafetyConfig mengatur deteksi krisis pada coach dan analisis jurnal
+type SafetyConfig struct {
+	SafetyKeywords    []string // ditambahkan ke daftar kata kunci bawaan
+	SafetyLLMProvider string   // provider untuk klasifikasi LLM, "off" berarti hanya kata kunci
+	SafetyHotlines    string   // format "Nama|Kontak;Nama|Kontak", kosong berarti daftar bawaan
+}
+
 type Config struct {
 	DBConfig
 	APIConfig
+	MailConfig
+	MidtransConfig
+	LLMConfig
+	JournalAnalysisConfig
+	SafetyConfig
 }
 
 func (c *Config) readConfig() error {
@@ -39,11 +110,158 @@ func (c *Config) readConfig() error {
 		DBName:   os.Getenv("DB_NAME"),
 		Driver:   os.Getenv("DB_DRIVER"),
 	}
+	if c.MigrateOnStart, err = boolEnv("DB_MIGRATE_ON_START", false); err != nil {
+		return err
+	}
 
 	c.APIConfig = APIConfig{
 		ApiPort: os.Getenv("API_PORT"),
 	}
 
+	c.MailConfig = MailConfig{
+		MailDriver:   os.Getenv("MAIL_DRIVER"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     os.Getenv("SMTP_PORT"),
+		SMTPUser:     os.Getenv("SMTP_USER"),
+		SMTPPassword: os.Getenv("SMTP_PASS"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+		OutboxDir:    os.Getenv("MAIL_OUTBOX_DIR"),
+	}
+	if c.MailDriver == "" {
+		c.MailDriver = "file"
+	}
+	if c.SMTPPort == "" {
+		c.SMTPPort = "587"
+	}
+	if c.MailFrom == "" {
+		c.MailFrom = "no-reply@pijar.app"
+	}
+	if c.OutboxDir == "" {
+		c.OutboxDir = "outbox"
+	}
+
+	c.MidtransConfig = MidtransConfig{
+		MidtransEnv:       strings.ToLower(os.Getenv("MIDTRANS_ENV")),
+		MidtransServerKey: os.Getenv("SERVER_KEY"),
+		MidtransSnapURL:   os.Getenv("MIDTRANS_SNAP_URL"),
+		MidtransAPIURL:    os.Getenv("MIDTRANS_API_URL"),
+	}
+	switch c.MidtransEnv {
+	case "":
+		c.MidtransEnv = "sandbox"
+	case "sandbox", "production", "fake":
+	default:
+		return fmt.Errorf("invalid MIDTRANS_ENV %q, expected sandbox, production or fake", c.MidtransEnv)
+	}
+
+	if c.ReconcileInterval, err = durationEnv("MIDTRANS_RECONCILE_INTERVAL", 5*time.Minute); err != nil {
+		return err
+	}
+	if c.ReconcileStaleAfter, err = durationEnv("MIDTRANS_RECONCILE_STALE_AFTER", 15*time.Minute); err != nil {
+		return err
+	}
+	if c.PendingTTL, err = durationEnv("MIDTRANS_PENDING_TTL", 24*time.Hour); err != nil {
+		return err
+	}
+	c.ReconcileWorkers = 4
+	if v := os.Getenv("MIDTRANS_RECONCILE_WORKERS"); v != "" {
+		workers, err := strconv.Atoi(v)
+		if err != nil || workers < 1 {
+			return fmt.Errorf("invalid MIDTRANS_RECONCILE_WORKERS %q, expected a positive number", v)
+		}
+		c.ReconcileWorkers = workers
+	}
+
+	c.LLMConfig = LLMConfig{
+		CoachLLMProvider:   strings.ToLower(os.Getenv("LLM_COACH_PROVIDER")),
+		JournalLLMProvider: strings.ToLower(os.Getenv("LLM_JOURNAL_PROVIDER")),
+		ArticleLLMProvider: strings.ToLower(os.Getenv("LLM_ARTICLE_PROVIDER")),
+		GeminiAPIKey:       os.Getenv("GEMINI_API"),
+		GeminiModel:        os.Getenv("GEMINI_MODEL"),
+		AIAPIKey:           os.Getenv("AI_API"),
+		AIBaseURL:          os.Getenv("AI_BASE_URL"),
+		AIModel:            os.Getenv("AI_MODEL"),
+		OllamaURL:          os.Getenv("OLLAMA_URL"),
+		OllamaModel:        os.Getenv("OLLAMA_MODEL"),
+	}
+	if c.CoachLLMProvider == "" {
+		c.CoachLLMProvider = "gemini"
+	}
+	if c.JournalLLMProvider == "" {
+		c.JournalLLMProvider = "gemini"
+	}
+	if c.ArticleLLMProvider == "" {
+		c.ArticleLLMProvider = "deepseek"
+	}
+	if c.AITimeout, err = durationEnv("AI_TIMEOUT", 60*time.Second); err != nil {
+		return err
+	}
+	if c.AIRetryBaseDelay, err = durationEnv("AI_RETRY_BASE_DELAY", 500*time.Millisecond); err != nil {
+		return err
+	}
+	if c.AIRetryMaxDelay, err = durationEnv("AI_RETRY_MAX_DELAY", 8*time.Second); err != nil {
+		return err
+	}
+	if c.AIBreakerCooldown, err = durationEnv("AI_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return err
+	}
+	if c.AIMaxRetries, err = intEnv("AI_MAX_RETRIES", 2); err != nil {
+		return err
+	}
+	if c.AIBreakerThreshold, err = intEnv("AI_BREAKER_THRESHOLD", 5); err != nil {
+		return err
+	}
+	for key, provider := range map[string]string{
+		"LLM_COACH_PROVIDER":   c.CoachLLMProvider,
+		"LLM_JOURNAL_PROVIDER": c.JournalLLMProvider,
+		"LLM_ARTICLE_PROVIDER": c.ArticleLLMProvider,
+	} {
+		switch provider {
+		case "gemini", "deepseek", "openai", "ollama":
+		default:
+			return fmt.Errorf("invalid %s %q, expected gemini, deepseek, openai or ollama", key, provider)
+		}
+	}
+
+	if c.JournalAnalysisWorkers, err = intEnv("JOURNAL_ANALYSIS_WORKERS", 2); err != nil {
+		return err
+	}
+	if c.JournalAnalysisPollInterval, err = durationEnv("JOURNAL_ANALYSIS_POLL_INTERVAL", 5*time.Second); err != nil {
+		return err
+	}
+	if c.JournalAnalysisPollInterval <= 0 {
+		return fmt.Errorf("invalid JOURNAL_ANALYSIS_POLL_INTERVAL, expected a duration longer than 0")
+	}
+	if c.JournalAnalysisMaxAttempts, err = intEnv("JOURNAL_ANALYSIS_MAX_ATTEMPTS", 5); err != nil {
+		return err
+	}
+	if c.JournalAnalysisMaxAttempts < 1 {
+		return fmt.Errorf("invalid JOURNAL_ANALYSIS_MAX_ATTEMPTS, expected at least 1")
+	}
+	if c.JournalAnalysisDrainTimeout, err = durationEnv("JOURNAL_ANALYSIS_DRAIN_TIMEOUT", 30*time.Second); err != nil {
+		return err
+	}
+	if c.TrendReportInterval, err = durationEnv("TREND_REPORT_INTERVAL", time.Hour); err != nil {
+		return err
+	}
+
+	c.SafetyConfig = SafetyConfig{
+		SafetyLLMProvider: strings.ToLower(os.Getenv("SAFETY_LLM_PROVIDER")),
+		SafetyHotlines:    os.Getenv("SAFETY_HOTLINES"),
+	}
+	for _, keyword := range strings.Split(os.Getenv("SAFETY_KEYWORDS"), ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			c.SafetyKeywords = append(c.SafetyKeywords, keyword)
+		}
+	}
+	switch c.SafetyLLMProvider {
+	case "":
+		c.SafetyLLMProvider = c.CoachLLMProvider
+	case "off", "gemini", "deepseek", "openai", "ollama":
+	default:
+		return fmt.Errorf("invalid SAFETY_LLM_PROVIDER %q, expected off, gemini, deepseek, openai or ollama", c.SafetyLLMProvider)
+	}
+
 	if c.Host == "" || c.Port == "" || c.User == "" || c.Password == "" || c.DBName == "" || c.ApiPort == "" {
 		return fmt.Errorf("required config")
 	}
@@ -58,3 +276,41 @@ func NewConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// durationEnv membaca durasi seperti "15m" atau "24h" dari env, dengan nilai default jika kosong
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration such as 15m or 24h", key, v)
+	}
+	return d, nil
+}
+
+// boolEnv membaca true/false dari env, dengan nilai default jika kosong
+func boolEnv(key string, def bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q, expected true or false", key, v)
+	}
+	return b, nil
+}
+
+// intEnv membaca angka yang tidak negatif dari env, dengan nilai default jika kosong
+func intEnv(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a number that is not negative", key, v)
+	}
+	return n, nil
+}
@@ -1,12 +1,19 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"pijar/middleware"
+	"pijar/model"
 	"pijar/model/dto"
+	"pijar/repository"
 	"pijar/usecase"
+	"pijar/utils/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,13 +22,15 @@ type SessionHandler struct {
 	usecase usecase.SessionUsecase
 	rg      gin.RouterGroup
 	aM      middleware.AuthMiddleware
+	eM      middleware.EntitlementMiddleware
 }
 
-func NewSessionHandler(uc usecase.SessionUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *SessionHandler {
+func NewSessionHandler(uc usecase.SessionUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware, eM middleware.EntitlementMiddleware) *SessionHandler {
 	return &SessionHandler{
 		usecase: uc,
 		rg:      *rg,
 		aM:      aM,
+		eM:      eM,
 	}
 }
 
@@ -30,12 +39,23 @@ func (h *SessionHandler) Route() {
 	sessionGroup := h.rg.Group("/sessions")
 	userRoutes := sessionGroup.Use(h.aM.RequireToken("USER", "ADMIN"))
 	{
-		userRoutes.POST("/start", h.HandleStartSession)
-		userRoutes.POST("/continue/:sessionId", h.HandleContinueSession)
+		userRoutes.POST("/start", h.eM.RequireEntitlement(model.EntitlementAICoach), h.HandleStartSession)
+		userRoutes.POST("/continue/:sessionId", h.eM.RequireEntitlement(model.EntitlementAICoach), h.HandleContinueSession)
+		userRoutes.GET("", h.HandleListSessions)
+		userRoutes.GET("/:sessionId", h.HandleGetSession)
+		userRoutes.PATCH("/:sessionId", h.HandleUpdateSession)
 		userRoutes.GET("/history/:sessionId", h.HandleGetSessionHistory)
 		userRoutes.DELETE("/:sessionId", h.HandleDeleteSession)
 	}
 
+	// Ekspor transkrip sesi untuk dibawa ke terapis atau disimpan sendiri, dan jawaban coach yang di-stream
+	coachGroup := h.rg.Group("/coach")
+	coachGroup.Use(h.aM.RequireToken("USER", "ADMIN"))
+	{
+		coachGroup.POST("/continue/:sessionId/stream", h.eM.RequireEntitlement(model.EntitlementAICoach), h.HandleContinueSessionStream)
+		coachGroup.GET("/:sessionId/export", h.HandleExportSession)
+	}
+
 	adminRoutes := sessionGroup.Use(h.aM.RequireToken("ADMIN"))
 	{
 		adminRoutes.GET("/user/:user_id", h.HandleGetUserSessions)
@@ -69,8 +89,18 @@ func (h *SessionHandler) HandleStartSession(c *gin.Context) {
 		return
 	}
 
-	sessionID, response, err := h.usecase.StartSession(c, userID, req.UserInput)
+	sessionID, response, err := h.usecase.StartSession(c, userID, req.PersonaID, req.UserInput)
 	if err != nil {
+		if errors.Is(err, usecase.ErrPersonaUnavailable) || errors.Is(err, repository.ErrPersonaNotFound) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Bad Request",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if respondAIError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Message: "Internal Server Error",
 			Error:   err.Error(),
@@ -125,6 +155,9 @@ func (h *SessionHandler) HandleContinueSession(c *gin.Context) {
 
 	response, err := h.usecase.ContinueSession(c, userID, sessionID, req.UserInput)
 	if err != nil {
+		if respondAIError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Message: "Internal Server Error",
 			Error:   err.Error(),
@@ -141,6 +174,105 @@ func (h *SessionHandler) HandleContinueSession(c *gin.Context) {
 	})
 }
 
+// HandleContinueSessionStream continues a session and streams the AI answer as Server-Sent Events.
+// Events: "message" for each partial answer, "done" with the full answer, "error" if the answer fails.
+// The "done" answer is the one that is saved and may replace the streamed text when the safety check rejects it.
+func (h *SessionHandler) HandleContinueSessionStream(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "session_id is required",
+		})
+		return
+	}
+
+	var req dto.ContinueSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// get user ID from jwt body
+	val, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.Response{
+			Message: "Authentication required",
+		})
+		return
+	}
+	userID, ok := val.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Message: "Invalid user identity in context",
+		})
+		return
+	}
+
+	// Header SSE baru dikirim saat potongan pertama datang, sehingga error sebelum itu
+	// (misalnya sesi tidak ditemukan) masih bisa dikembalikan sebagai JSON biasa
+	started := false
+	onChunk := func(chunk string) error {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			started = true
+		}
+		c.SSEvent("message", gin.H{"delta": chunk})
+		c.Writer.Flush()
+		return nil
+	}
+
+	response, err := h.usecase.ContinueSessionStream(c.Request.Context(), userID, sessionID, req.UserInput, onChunk)
+	if err != nil {
+		// Client sudah menutup koneksi, tidak ada yang perlu dikirim
+		if errors.Is(err, context.Canceled) || c.Request.Context().Err() != nil {
+			log.Printf("Coach stream for session %s closed by client", sessionID)
+			return
+		}
+		if !started {
+			if respondAIError(c, err) {
+				return
+			}
+			status := http.StatusInternalServerError
+			message := "Internal Server Error"
+			if strings.Contains(err.Error(), "sesi tidak ditemukan") {
+				status = http.StatusNotFound
+				message = "Not Found"
+			}
+			c.JSON(status, dto.ErrorResponse{
+				Message: message,
+				Error:   err.Error(),
+			})
+			return
+		}
+		// Header sudah terkirim, jadi error AI bertipe dikirim sebagai event dengan status yang sama
+		if status, _, kind, ok := aiErrorStatus(err); ok {
+			c.SSEvent("error", gin.H{"error": kind.Error(), "status": status})
+			c.Writer.Flush()
+			return
+		}
+		c.SSEvent("error", gin.H{"error": err.Error()})
+		c.Writer.Flush()
+		return
+	}
+
+	c.SSEvent("done", dto.StartSessionResponse{
+		SessionID: sessionID,
+		Response:  response,
+	})
+	c.Writer.Flush()
+}
+
 // HandleGetSessionHistory retrieves conversation history
 func (h *SessionHandler) HandleGetSessionHistory(c *gin.Context) {
 	sessionID := c.Param("sessionId")
@@ -207,20 +339,168 @@ func (h *SessionHandler) HandleGetSessionHistory(c *gin.Context) {
 	})
 }
 
-// HandleGetUserSessions retrieves user session list
-func (h *SessionHandler) HandleGetUserSessions(c *gin.Context) {
-	// get user ID from jwt body
-	val, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, dto.Response{
-			Message: "Authentication required",
+// HandleListSessions lists the current user's sessions, pinned first and then by last activity.
+// ?archived=true|false|all (default false), ?page and ?limit.
+func (h *SessionHandler) HandleListSessions(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	filter := model.CoachSessionFilter{UserID: userID}
+	switch c.DefaultQuery("archived", "false") {
+	case "all":
+	case "true":
+		archived := true
+		filter.Archived = &archived
+	case "false":
+		archived := false
+		filter.Archived = &archived
+	default:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid archived, expected true, false or all",
 		})
 		return
 	}
-	userID, ok := val.(int)
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid page",
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid limit",
+		})
+		return
+	}
+	filter.Page = page
+	filter.Limit = limit
+
+	sessions, err := h.usecase.ListSessions(c, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Sessions retrieved successfully",
+		Data:    sessions,
+	})
+}
+
+// HandleGetSession returns the metadata of one of the current user's sessions
+func (h *SessionHandler) HandleGetSession(c *gin.Context) {
+	userID, ok := h.userID(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, dto.Response{
-			Message: "Invalid user identity in context",
+		return
+	}
+
+	session, err := h.usecase.GetSession(c, userID, c.Param("sessionId"))
+	if err != nil {
+		h.handleSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Session retrieved successfully",
+		Data:    session,
+	})
+}
+
+// HandleUpdateSession renames, archives or pins a session
+func (h *SessionHandler) HandleUpdateSession(c *gin.Context) {
+	var req model.CoachSessionUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.usecase.UpdateSession(c, userID, c.Param("sessionId"), req)
+	if err != nil {
+		h.handleSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Session updated successfully",
+		Data:    session,
+	})
+}
+
+// HandleExportSession downloads the full transcript of a session (?format=pdf|md|json, default pdf)
+func (h *SessionHandler) HandleExportSession(c *gin.Context) {
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "md" && format != "json" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid format, expected pdf, md or json",
+		})
+		return
+	}
+
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	transcript, err := h.usecase.ExportSession(c, userID, c.Param("sessionId"))
+	if err != nil {
+		h.handleSessionError(c, err)
+		return
+	}
+
+	filename := "coach_session_" + transcript.ExportedAt.Format("20060102_150405") + "." + format
+	switch format {
+	case "md":
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(service.CoachTranscriptMarkdown(transcript)))
+	case "json":
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.IndentedJSON(http.StatusOK, transcript)
+	default:
+		pdf, err := service.GenerateCoachTranscriptPDF(transcript)
+		if err != nil {
+			log.Printf("Error generating transcript PDF for session %s: %v", transcript.SessionID, err)
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Message: "Failed to generate PDF",
+				Error:   "failed to generate PDF",
+			})
+			return
+		}
+
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		if err := pdf.Output(c.Writer); err != nil {
+			log.Printf("Error writing transcript PDF for session %s: %v", transcript.SessionID, err)
+		}
+	}
+}
+
+// HandleGetUserSessions retrieves the session list of the user in :user_id (admin only)
+func (h *SessionHandler) HandleGetUserSessions(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid user ID",
 		})
 		return
 	}
@@ -287,3 +567,41 @@ func (h *SessionHandler) HandleDeleteSession(c *gin.Context) {
 		Data:    nil,
 	})
 }
+
+func (h *SessionHandler) handleSessionError(c *gin.Context, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Message: "Not Found",
+			Error:   err.Error(),
+		})
+	case strings.Contains(err.Error(), "cannot be empty"):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+	}
+}
+
+func (h *SessionHandler) userID(c *gin.Context) (int, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.Response{
+			Message: "Authentication required",
+		})
+		return 0, false
+	}
+	userID, ok := val.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Message: "Invalid user identity in context",
+		})
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"pijar/model/dto"
+	"pijar/utils/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// aiErrorStatus memetakan error bertipe dari layanan AI ke status HTTP: 429 jika provider
+// membatasi request, 503 jika provider sedang tidak tersedia (timeout, 5xx, circuit terbuka).
+// Nilai ok false berarti err bukan error AI bertipe.
+func aiErrorStatus(err error) (status int, message string, kind error, ok bool) {
+	switch {
+	case errors.Is(err, service.ErrLLMRateLimited):
+		return http.StatusTooManyRequests, "Too Many Requests", service.ErrLLMRateLimited, true
+	case errors.Is(err, service.ErrLLMUnavailable):
+		return http.StatusServiceUnavailable, "Service Unavailable", service.ErrLLMUnavailable, true
+	default:
+		return 0, "", nil, false
+	}
+}
+
+// respondAIError menulis response 429/503 untuk error AI bertipe, lengkap dengan header Retry-After
+// jika provider memberikannya. Mengembalikan false jika err bukan error AI bertipe, sehingga caller
+// tetap menulis response-nya sendiri.
+func respondAIError(c *gin.Context, err error) bool {
+	status, message, kind, ok := aiErrorStatus(err)
+	if !ok {
+		return false
+	}
+
+	log.Printf("AI request failed: %v", err)
+	if retryAfter := service.LLMRetryAfter(err); retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	c.JSON(status, dto.ErrorResponse{
+		Message: message,
+		Error:   kind.Error(),
+	})
+	return true
+}
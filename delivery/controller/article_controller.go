@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"pijar/middleware"
+	"pijar/model"
 	"pijar/model/dto"
 	"pijar/usecase"
 	"strconv"
@@ -15,13 +16,15 @@ type ArticleControllerImpl struct {
 	articleUsecase usecase.ArticleUsecase
 	rg             *gin.RouterGroup
 	aM             middleware.AuthMiddleware
+	eM             middleware.EntitlementMiddleware
 }
 
-func NewArticleController(au usecase.ArticleUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *ArticleControllerImpl {
+func NewArticleController(au usecase.ArticleUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware, eM middleware.EntitlementMiddleware) *ArticleControllerImpl {
 	return &ArticleControllerImpl{
 		articleUsecase: au,
 		rg:             rg,
 		aM:             aM,
+		eM:             eM,
 	}
 }
 
@@ -42,7 +45,7 @@ func (ac *ArticleControllerImpl) Route() {
 	{
 		userRoutes.GET("", ac.GetAllArticles)
 		userRoutes.GET("/all", ac.GetAllArticlesWithoutPagination)
-		userRoutes.POST("/generate", ac.GenerateArticle)
+		userRoutes.POST("/generate", ac.eM.RequireEntitlement(model.EntitlementArticleGeneration), ac.GenerateArticle)
 		userRoutes.POST("/search", ac.SearchArticleByTitle)
 	}
 }
@@ -123,6 +126,9 @@ func (ac *ArticleControllerImpl) GenerateArticle(c *gin.Context) {
 	// Generate article
 	article, err := ac.articleUsecase.GenerateArticle(c.Request.Context(), input.TopicID)
 	if err != nil {
+		if respondAIError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Message: "Internal Server Error",
 			Error:   err.Error(),
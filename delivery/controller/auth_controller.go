@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"pijar/middleware"
 	"pijar/model"
 	"pijar/model/dto"
 	"pijar/usecase"
@@ -11,22 +12,28 @@ import (
 )
 
 type AuthController struct {
-	rg          *gin.RouterGroup
-	jwtService  service.JwtService
-	AuthUsecase usecase.AuthUsecase
+	rg             *gin.RouterGroup
+	jwtService     service.JwtService
+	AuthUsecase    usecase.AuthUsecase
+	authMiddleware *middleware.AuthMiddleware
 }
 
-func NewAuthController(rg *gin.RouterGroup, jwt service.JwtService, authUC usecase.AuthUsecase) *AuthController {
+func NewAuthController(rg *gin.RouterGroup, jwt service.JwtService, authUC usecase.AuthUsecase, authMiddleware *middleware.AuthMiddleware) *AuthController {
 	return &AuthController{
-		rg:          rg,
-		jwtService:  jwt,
-		AuthUsecase: authUC,
+		rg:             rg,
+		jwtService:     jwt,
+		AuthUsecase:    authUC,
+		authMiddleware: authMiddleware,
 	}
 }
 
 func (ac *AuthController) Route() {
 	ac.rg.POST("/register", ac.Register)
 	ac.rg.POST("/login", ac.Login)
+
+	authGroup := ac.rg.Group("/auth")
+	authGroup.POST("/refresh", ac.Refresh)
+	authGroup.POST("/logout", ac.authMiddleware.RequireToken("USER", "ADMIN"), ac.Logout)
 }
 
 func (a *AuthController) Register(c *gin.Context) {
@@ -90,7 +97,7 @@ func (a *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	authResp, err := a.AuthUsecase.Login(input.Email, input.Password)
+	authResp, err := a.AuthUsecase.Login(input.Email, input.Password, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
 			Message: "Invalid credentials",
@@ -101,3 +108,69 @@ func (a *AuthController) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, authResp)
 }
+
+func (a *AuthController) Refresh(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	authResp, err := a.AuthUsecase.Refresh(input.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Message: "Invalid refresh token",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, authResp)
+}
+
+func (a *AuthController) Logout(c *gin.Context) {
+	var input struct {
+		All bool `json:"all"`
+	}
+	// body bersifat opsional, tanpa body hanya sesi saat ini yang dicabut
+	_ = c.ShouldBindJSON(&input)
+
+	if input.All {
+		userID, ok := c.Get("userID")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Message: "Unauthorized",
+				Error:   "User ID not found in token",
+			})
+			return
+		}
+		if err := a.AuthUsecase.LogoutAll(userID.(int)); err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Message: "Failed to logout",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, dto.Response{
+			Message: "All sessions logged out successfully",
+		})
+		return
+	}
+
+	if err := a.AuthUsecase.Logout(c.GetString("sessionID")); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Failed to logout",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Logged out successfully",
+	})
+}
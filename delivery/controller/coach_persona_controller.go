@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"pijar/middleware"
+	"pijar/model"
+	"pijar/model/dto"
+	"pijar/repository"
+	"pijar/usecase"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CoachPersonaController mengelola persona AI coach
+type CoachPersonaController struct {
+	personaUC usecase.CoachPersonaUsecase
+	rg        *gin.RouterGroup
+	aM        middleware.AuthMiddleware
+}
+
+func NewCoachPersonaController(personaUC usecase.CoachPersonaUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *CoachPersonaController {
+	return &CoachPersonaController{
+		personaUC: personaUC,
+		rg:        rg,
+		aM:        aM,
+	}
+}
+
+func (pc *CoachPersonaController) Route() {
+	personaRoutes := pc.rg.Group("/coach-personas")
+
+	// Daftar persona yang bisa dipilih saat memulai sesi
+	personaRoutes.GET("", pc.aM.RequireToken("USER", "ADMIN"), pc.GetActivePersonas)
+
+	// Endpoint khusus admin
+	adminRoutes := personaRoutes.Group("")
+	adminRoutes.Use(pc.aM.RequireToken("ADMIN"))
+	{
+		adminRoutes.GET("/all", pc.GetAllPersonas)
+		adminRoutes.GET("/:id", pc.GetPersonaByID)
+		adminRoutes.POST("", pc.CreatePersona)
+		adminRoutes.PUT("/:id", pc.UpdatePersona)
+		adminRoutes.DELETE("/:id", pc.ArchivePersona)
+	}
+}
+
+// GetActivePersonas lists personas users can pick when starting a session
+func (pc *CoachPersonaController) GetActivePersonas(c *gin.Context) {
+	personas, err := pc.personaUC.GetActivePersonas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to fetch personas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Personas retrieved successfully",
+		Data:    personas,
+	})
+}
+
+// GetAllPersonas lists every persona including archived ones
+func (pc *CoachPersonaController) GetAllPersonas(c *gin.Context) {
+	personas, err := pc.personaUC.GetAllPersonas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to fetch personas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Personas retrieved successfully",
+		Data:    personas,
+	})
+}
+
+func (pc *CoachPersonaController) GetPersonaByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid persona ID",
+		})
+		return
+	}
+
+	persona, err := pc.personaUC.GetPersonaByID(id)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Persona retrieved successfully",
+		Data:    persona,
+	})
+}
+
+func (pc *CoachPersonaController) CreatePersona(c *gin.Context) {
+	var req model.CoachPersonaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	persona, err := pc.personaUC.CreatePersona(req)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.Response{
+		Message: "Persona created successfully",
+		Data:    persona,
+	})
+}
+
+func (pc *CoachPersonaController) UpdatePersona(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid persona ID",
+		})
+		return
+	}
+
+	var req model.CoachPersonaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	persona, err := pc.personaUC.UpdatePersona(id, req)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Persona updated successfully",
+		Data:    persona,
+	})
+}
+
+// ArchivePersona hides a persona from users; sessions that already use it keep working
+func (pc *CoachPersonaController) ArchivePersona(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid persona ID",
+		})
+		return
+	}
+
+	if err := pc.personaUC.ArchivePersona(id); err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Persona archived successfully",
+	})
+}
+
+func (pc *CoachPersonaController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrPersonaNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Message: "Not Found",
+			Error:   err.Error(),
+		})
+	case strings.Contains(err.Error(), "already exists"):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Message: "Conflict",
+			Error:   err.Error(),
+		})
+	case strings.Contains(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+	}
+}
@@ -1,14 +1,18 @@
 package controller
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"pijar/middleware"
 	"pijar/model"
 	"pijar/usecase"
+	"pijar/utils/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,17 +21,20 @@ type JournalAIController struct {
 	aiUsecase usecase.JournalAIUsecase
 	rg        *gin.RouterGroup
 	authMdw   *middleware.AuthMiddleware
+	entMdw    *middleware.EntitlementMiddleware
 }
 
 func NewJournalAIController(
 	aiUsecase usecase.JournalAIUsecase,
 	rg *gin.RouterGroup,
 	authMdw middleware.AuthMiddleware,
+	entMdw middleware.EntitlementMiddleware,
 ) *JournalAIController {
 	controller := &JournalAIController{
 		aiUsecase: aiUsecase,
 		rg:        rg,
 		authMdw:   &authMdw,
+		entMdw:    &entMdw,
 	}
 
 	return controller
@@ -39,16 +46,17 @@ func (c *JournalAIController) Route() {
 	userRoutes := journalAPI.Use(c.authMdw.RequireToken("USER", "ADMIN"))
 	{
 		// Single analysis
-		userRoutes.POST("/analyze", c.analyzeJournal)
+		userRoutes.POST("/analyze", c.entMdw.RequireEntitlement(model.EntitlementJournalAI), c.analyzeJournal)
 		userRoutes.GET("/:id/analysis", c.getJournalAnalysis)
-		userRoutes.PUT("/:id/reanalyze", c.reanalyzeJournal)
+		userRoutes.GET("/:id/status", c.getAnalysisStatus)
+		userRoutes.PUT("/:id/reanalyze", c.entMdw.RequireEntitlement(model.EntitlementJournalAI), c.reanalyzeJournal)
 
 		// Multiple analyses
 		userRoutes.GET("/analyses", c.getUserAnalyses)
 		userRoutes.GET("/analyses-with-entries", c.getAnalysisWithJournal)
 
 		// Trend analysis
-		userRoutes.POST("/trend-analysis", c.generateTrendAnalysis)
+		userRoutes.POST("/trend-analysis", c.entMdw.RequireEntitlement(model.EntitlementJournalAI), c.generateTrendAnalysis)
 		userRoutes.GET("/trends", c.getTrendHistory)
 
 		// Charts & visualization
@@ -56,13 +64,17 @@ func (c *JournalAIController) Route() {
 	}
 }
 
-// analyzeJournal handles journal analysis request
+// analyzeJournal queues a journal for analysis; the result is read from the status endpoint
 func (c *JournalAIController) analyzeJournal(ctx *gin.Context) {
 	var req model.AnalysisRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
+	if req.JournalID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "journal_id is required"})
+		return
+	}
 
 	// Get user ID from context
 	userID, exists := ctx.Get("userID")
@@ -70,15 +82,14 @@ func (c *JournalAIController) analyzeJournal(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - userID not found in context"})
 		return
 	}
-	req.UserID = userID.(int)
 
-	response, err := c.aiUsecase.AnalyzeJournal(ctx.Request.Context(), &req)
+	status, err := c.aiUsecase.RequestAnalysis(ctx.Request.Context(), req.JournalID, userID.(int))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.handleAnalysisError(ctx, "failed to queue journal analysis", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response)
+	ctx.JSON(analysisStatusCode(status), status)
 }
 
 // getJournalAnalysis retrieves analysis for a specific journal
@@ -110,15 +121,47 @@ func (c *JournalAIController) getJournalAnalysis(ctx *gin.Context) {
 	}
 
 	analysis, err := c.aiUsecase.GetJournalAnalysis(ctx.Request.Context(), journalID, userIDInt)
+	if errors.Is(err, usecase.ErrAnalysisPending) {
+		// Journal baru masuk antrean, client mengikuti progresnya lewat /status
+		status, err := c.aiUsecase.GetAnalysisStatus(ctx.Request.Context(), journalID, userIDInt)
+		if err != nil {
+			c.handleAnalysisError(ctx, "failed to get journal analysis", err)
+			return
+		}
+		ctx.JSON(http.StatusAccepted, status)
+		return
+	}
 	if err != nil {
-		log.Printf("Error getting journal analysis: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get journal analysis: " + err.Error()})
+		c.handleAnalysisError(ctx, "failed to get journal analysis", err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, analysis)
 }
 
+// getAnalysisStatus returns the queue status of a journal analysis, with the analysis once it is done
+func (c *JournalAIController) getAnalysisStatus(ctx *gin.Context) {
+	journalID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid journal ID: must be a number"})
+		return
+	}
+
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized - userID not found in context"})
+		return
+	}
+
+	status, err := c.aiUsecase.GetAnalysisStatus(ctx.Request.Context(), journalID, userID.(int))
+	if err != nil {
+		c.handleAnalysisError(ctx, "failed to get journal analysis status", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
 // reanalyzeJournal triggers reanalysis of a journal
 func (c *JournalAIController) reanalyzeJournal(ctx *gin.Context) {
 	journalID, err := strconv.Atoi(ctx.Param("id"))
@@ -147,14 +190,13 @@ func (c *JournalAIController) reanalyzeJournal(ctx *gin.Context) {
 		return
 	}
 
-	response, err := c.aiUsecase.ReanalyzeJournal(ctx.Request.Context(), journalID, userIDInt)
+	status, err := c.aiUsecase.ReanalyzeJournal(ctx.Request.Context(), journalID, userIDInt)
 	if err != nil {
-		log.Printf("Error reanalyzing journal: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reanalyze journal: " + err.Error()})
+		c.handleAnalysisError(ctx, "failed to reanalyze journal", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": response})
+	ctx.JSON(http.StatusAccepted, gin.H{"data": status})
 }
 
 // getUserAnalyses retrieves all analyses for the current user
@@ -283,27 +325,27 @@ func (c *JournalAIController) generateTrendAnalysis(ctx *gin.Context) {
 		return
 	}
 
-	var req struct {
-		PeriodType string `json:"period_type"`
-		Days       int    `json:"days"`
-	}
-
+	var req model.TrendRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
+	req.UserID = userIDInt
 
 	if req.PeriodType == "" {
-		req.PeriodType = "weekly"
-	}
-
-	if req.Days <= 0 {
-		req.Days = 30 // Default to 30 days
+		req.PeriodType = service.TrendPeriodWeekly
 	}
 
-	response, err := c.aiUsecase.GenerateTrendAnalysis(ctx.Request.Context(), userIDInt, req.PeriodType, req.Days)
+	response, err := c.aiUsecase.GenerateTrendAnalysis(ctx.Request.Context(), &req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrInvalidTrendPeriod):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNoAnalysesInPeriod):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -380,3 +422,26 @@ func (c *JournalAIController) getSentimentChart(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": chartData})
 }
+
+// handleAnalysisError memetakan error analisis jurnal ke 403, 404 atau 500
+func (c *JournalAIController) handleAnalysisError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrJournalNotOwned):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "journal not found"})
+	case strings.Contains(err.Error(), "not found"):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Error: %s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message + ": " + err.Error()})
+	}
+}
+
+// analysisStatusCode mengembalikan 200 jika analisis sudah selesai, 202 jika masih di antrean
+func analysisStatusCode(status *model.JournalAnalysisStatus) int {
+	if status.Status == model.JournalAnalysisDone {
+		return http.StatusOK
+	}
+	return http.StatusAccepted
+}
@@ -1,11 +1,13 @@
 package controller
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"pijar/model"
 	"pijar/model/dto"
 	"pijar/usecase"
+	"pijar/utils/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -56,6 +58,13 @@ func (h *midtransCallbackHandler) HandleCallback(c *gin.Context) {
 	// Proses callback menggunakan usecase
 	err := h.paymentUsecase.ProcessCallback(callback)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Message: "Invalid callback signature",
+				Error:   err.Error(),
+			})
+			return
+		}
 		log.Printf("Error processing callback: %v", err)
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Message: "Failed to process callback",
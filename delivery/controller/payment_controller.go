@@ -1,14 +1,18 @@
 package controller
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"pijar/middleware"
 	"pijar/model"
 	"pijar/model/dto"
+	"pijar/repository"
 	"pijar/usecase"
+	"pijar/utils/service"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,8 +21,13 @@ import (
 type PaymentController interface {
 	CreatePayment(c *gin.Context)
 	GetPaymentStatus(c *gin.Context)
+	GetMyTransactions(c *gin.Context)
+	GetAllTransactions(c *gin.Context)
+	DownloadReceipt(c *gin.Context)
 	RollbackPayment(c *gin.Context)
 	ForceCheckStatus(c *gin.Context)
+	RefundPayment(c *gin.Context)
+	GetRefunds(c *gin.Context)
 	Route()
 }
 
@@ -51,9 +60,20 @@ func (p *paymentController) Route() {
 	userRoutes.Use(p.aM.RequireToken("USER", "ADMIN"))
 	{
 		userRoutes.POST("/", p.CreatePayment)
+		userRoutes.GET("", p.GetMyTransactions)
 		userRoutes.GET("/:id", p.GetPaymentStatus)
+		userRoutes.GET("/:id/receipt", p.DownloadReceipt)
 		userRoutes.POST("/:id/cancel", p.RollbackPayment)
 	}
+
+	// Endpoint khusus admin
+	adminRoutes := paymentRoutes.Group("")
+	adminRoutes.Use(p.aM.RequireToken("ADMIN"))
+	{
+		adminRoutes.GET("/all", p.GetAllTransactions)
+		adminRoutes.POST("/:id/refund", p.RefundPayment)
+		adminRoutes.GET("/:id/refunds", p.GetRefunds)
+	}
 }
 
 // CreatePayment creates a new payment
@@ -84,9 +104,19 @@ func (p *paymentController) CreatePayment(c *gin.Context) {
 		return
 	}
 
+	// Pemilik transaksi selalu user yang login, bukan user dari body request
+	req.UserID = userID
+
 	// Call usecase to create payment
 	transaction, err := p.paymentUsecase.CreatePayment(req)
 	if err != nil {
+		if errors.Is(err, usecase.ErrProductUnavailable) || strings.Contains(err.Error(), "product not found") {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Bad Request",
+				Error:   err.Error(),
+			})
+			return
+		}
 		log.Printf("Error creating payment: %v", err)
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Message: "Internal Server Error",
@@ -102,7 +132,6 @@ func (p *paymentController) CreatePayment(c *gin.Context) {
 		// Continue with limited information
 	}
 
-	transaction.UserID = userID
 	// Get user information
 	user, err := p.paymentUsecase.GetUserByID(transaction.UserID)
 	if err != nil {
@@ -148,6 +177,11 @@ func (p *paymentController) GetPaymentStatus(c *gin.Context) {
 		return
 	}
 
+	// Cek kepemilikan sebelum menghubungi Midtrans, karena pengecekan status bisa mengubah transaksi
+	if _, ok := p.authorizeTransaction(c, id); !ok {
+		return
+	}
+
 	// Call usecase to get payment status
 	transaction, err := p.paymentUsecase.GetPaymentStatus(id)
 	if err != nil {
@@ -158,46 +192,6 @@ func (p *paymentController) GetPaymentStatus(c *gin.Context) {
 		return
 	}
 
-	// Check if user is only accessing their own transaction
-	// Get user ID from token
-	userID, userIDExists := c.Get("userID")
-	role, roleExists := c.Get("role")
-	if !userIDExists || !roleExists {
-		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
-			Message: "Unauthorized",
-			Error:   "Invalid token",
-		})
-		return
-	}
-
-	// Convert user ID to int
-	userIDInt, ok := userID.(int)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Message: "Internal Server Error",
-			Error:   "Failed to parse user ID from token",
-		})
-		return
-	}
-
-	roleStr, ok := role.(string)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Message: "Internal Server Error",
-			Error:   "Failed to parse role from token",
-		})
-		return
-	}
-
-	// Check if user is accessing their own transaction or if they're an admin
-	if userIDInt != transaction.UserID && roleStr != "ADMIN" {
-		c.JSON(http.StatusForbidden, dto.ErrorResponse{
-			Message: "Forbidden",
-			Error:   "Cannot access other user's transactions",
-		})
-		return
-	}
-
 	// Return status
 	c.JSON(http.StatusOK, dto.Response{
 		Message: "Transaction status retrieved successfully",
@@ -227,17 +221,12 @@ func (p *paymentController) RollbackPayment(c *gin.Context) {
 	}
 
 	// Ambil transaksi terlebih dahulu untuk pengecekan kepemilikan
-	transaction, err := p.paymentUsecase.GetPaymentStatus(id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{
-			Message: "Not Found",
-			Error:   "Transaction not found",
-		})
+	if _, ok := p.authorizeTransaction(c, id); !ok {
 		return
 	}
 
 	// Panggil usecase untuk rollback pembayaran
-	transaction, err = p.paymentUsecase.RollbackPayment(id)
+	transaction, err := p.paymentUsecase.RollbackPayment(id)
 	if err != nil {
 		// Cek apakah error karena status transaksi tidak pending
 		if strings.Contains(err.Error(), "cannot rollback non-pending") {
@@ -314,3 +303,305 @@ func (p *paymentController) ForceCheckStatus(c *gin.Context) {
 		},
 	})
 }
+
+// RefundPayment mengembalikan dana transaksi (penuh atau sebagian), khusus admin
+func (p *paymentController) RefundPayment(c *gin.Context) {
+	// Ambil ID transaksi dari parameter
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid transaction ID",
+		})
+		return
+	}
+
+	var req model.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	refund, err := p.paymentUsecase.RefundPayment(id, req.Amount, req.Reason)
+	if err != nil {
+		log.Printf("Error refunding transaction %d: %v", id, err)
+		switch {
+		case strings.Contains(err.Error(), "transaction not found"):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Not Found",
+				Error:   "Transaction not found",
+			})
+		case errors.Is(err, repository.ErrRefundExceedsAmount), strings.Contains(err.Error(), "cannot refund"):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Bad Request",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Message: "Internal Server Error",
+				Error:   "Failed to refund payment: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Payment refunded successfully",
+		Data:    refund,
+	})
+}
+
+// GetRefunds menampilkan riwayat refund sebuah transaksi, khusus admin
+func (p *paymentController) GetRefunds(c *gin.Context) {
+	// Ambil ID transaksi dari parameter
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid transaction ID",
+		})
+		return
+	}
+
+	refunds, err := p.paymentUsecase.GetRefunds(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Not Found",
+				Error:   "Transaction not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Refunds retrieved successfully",
+		Data:    refunds,
+	})
+}
+
+// GetMyTransactions menampilkan riwayat transaksi milik user yang sedang login
+func (p *paymentController) GetMyTransactions(c *gin.Context) {
+	val, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.Response{
+			Message: "Authentication required",
+		})
+		return
+	}
+	userID, ok := val.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Message: "Invalid user identity in context",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	result, err := p.paymentUsecase.ListTransactions(model.TransactionFilter{
+		UserID: userID,
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		p.handleListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Transactions retrieved successfully",
+		Data:    result,
+	})
+}
+
+// GetAllTransactions menampilkan semua transaksi untuk admin, dengan filter
+// status, user_id, dan rentang tanggal from/to (format YYYY-MM-DD, keduanya inklusif)
+func (p *paymentController) GetAllTransactions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filter := model.TransactionFilter{
+		Status: c.Query("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Bad Request",
+				Error:   "Invalid user_id",
+			})
+			return
+		}
+		filter.UserID = userID
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Bad Request",
+				Error:   "Invalid from date, expected YYYY-MM-DD",
+			})
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Bad Request",
+				Error:   "Invalid to date, expected YYYY-MM-DD",
+			})
+			return
+		}
+		// Tanggal akhir inklusif, jadi batasnya adalah awal hari berikutnya
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	result, err := p.paymentUsecase.ListTransactions(filter)
+	if err != nil {
+		p.handleListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Transactions retrieved successfully",
+		Data:    result,
+	})
+}
+
+// DownloadReceipt mengunduh bukti pembayaran dalam format PDF
+func (p *paymentController) DownloadReceipt(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid transaction ID",
+		})
+		return
+	}
+
+	if _, ok := p.authorizeTransaction(c, id); !ok {
+		return
+	}
+
+	receipt, err := p.paymentUsecase.GetReceipt(id)
+	if err != nil {
+		if errors.Is(err, usecase.ErrReceiptUnavailable) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Message: "Conflict",
+				Error:   err.Error(),
+			})
+			return
+		}
+		log.Printf("Error building receipt for transaction %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to generate receipt",
+		})
+		return
+	}
+
+	pdf, err := service.GenerateReceiptPDF(receipt)
+	if err != nil {
+		log.Printf("Error generating receipt PDF for transaction %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Failed to generate PDF",
+			Error:   "failed to generate PDF",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", "attachment; filename=receipt_"+receipt.Transaction.OrderID+".pdf")
+
+	if err := pdf.Output(c.Writer); err != nil {
+		log.Printf("Error writing receipt PDF for transaction %d: %v", id, err)
+	}
+}
+
+// authorizeTransaction memastikan transaksi ada dan milik user yang login (admin boleh
+// mengakses semua transaksi). Response error sudah ditulis jika nilai bool false.
+func (p *paymentController) authorizeTransaction(c *gin.Context, id int) (model.Transaction, bool) {
+	userID, userIDExists := c.Get("userID")
+	role, roleExists := c.Get("role")
+	if !userIDExists || !roleExists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Message: "Unauthorized",
+			Error:   "Invalid token",
+		})
+		return model.Transaction{}, false
+	}
+
+	userIDInt, ok := userID.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to parse user ID from token",
+		})
+		return model.Transaction{}, false
+	}
+
+	roleStr, ok := role.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to parse role from token",
+		})
+		return model.Transaction{}, false
+	}
+
+	transaction, err := p.paymentUsecase.GetTransaction(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Message: "Not Found",
+			Error:   "Transaction not found",
+		})
+		return model.Transaction{}, false
+	}
+
+	if userIDInt != transaction.UserID && roleStr != "ADMIN" {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{
+			Message: "Forbidden",
+			Error:   "Cannot access other user's transactions",
+		})
+		return model.Transaction{}, false
+	}
+
+	return transaction, true
+}
+
+func (p *paymentController) handleListError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "invalid") {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+	log.Printf("Error listing transactions: %v", err)
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Message: "Internal Server Error",
+		Error:   "Failed to fetch transactions",
+	})
+}
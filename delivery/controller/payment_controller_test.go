@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pijar/model"
+	"pijar/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakePaymentUsecase mencatat request pembayaran dan menyimpan transaksi di memori
+type fakePaymentUsecase struct {
+	usecase.PaymentUsecase
+	created      []model.PaymentRequest
+	transactions map[int]model.Transaction
+}
+
+func (u *fakePaymentUsecase) CreatePayment(req model.PaymentRequest) (model.Transaction, error) {
+	u.created = append(u.created, req)
+	return model.Transaction{ID: 1, UserID: req.UserID, ProductID: req.ProductID, Status: model.TransactionStatusPending}, nil
+}
+
+func (u *fakePaymentUsecase) GetTransaction(id int) (model.Transaction, error) {
+	transaction, ok := u.transactions[id]
+	if !ok {
+		return model.Transaction{}, errors.New("transaction not found")
+	}
+	return transaction, nil
+}
+
+func (u *fakePaymentUsecase) GetPaymentStatus(id int) (model.Transaction, error) {
+	return u.GetTransaction(id)
+}
+
+func (u *fakePaymentUsecase) GetReceipt(id int) (model.Receipt, error) {
+	transaction, err := u.GetTransaction(id)
+	if err != nil {
+		return model.Receipt{}, err
+	}
+	return model.Receipt{Transaction: transaction}, nil
+}
+
+func (u *fakePaymentUsecase) ListTransactions(filter model.TransactionFilter) (model.TransactionListResponse, error) {
+	var result model.TransactionListResponse
+	for _, transaction := range u.transactions {
+		if filter.UserID == 0 || transaction.UserID == filter.UserID {
+			result.Transactions = append(result.Transactions, transaction)
+		}
+	}
+	return result, nil
+}
+
+func (u *fakePaymentUsecase) GetProductByID(id int) (model.Product, error) {
+	return model.Product{ID: id, Name: "Premium", Price: 50000}, nil
+}
+
+func (u *fakePaymentUsecase) GetUserByID(id int) (model.Users, error) {
+	return model.Users{ID: id}, nil
+}
+
+// withUser meniru AuthMiddleware dengan mengisi identitas dari token
+func withUser(userID int, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Set("role", role)
+	}
+}
+
+func TestCreatePaymentIgnoresUserIDInBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	payments := &fakePaymentUsecase{}
+	controller := &paymentController{paymentUsecase: payments}
+
+	router := gin.New()
+	router.POST("/payments", withUser(7, "USER"), controller.CreatePayment)
+
+	body := strings.NewReader(`{"user_id": 99, "product_id": 3}`)
+	req := httptest.NewRequest(http.MethodPost, "/payments", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if len(payments.created) != 1 {
+		t.Fatalf("CreatePayment called %d times, want 1", len(payments.created))
+	}
+	if got := payments.created[0]; got.UserID != 7 || got.ProductID != 3 {
+		t.Errorf("CreatePayment(%+v), want user 7 from the token and product 3", got)
+	}
+}
+
+func TestTransactionOwnership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	payments := &fakePaymentUsecase{transactions: map[int]model.Transaction{
+		1: {ID: 1, UserID: 7, OrderID: "ORDER-1", Status: model.TransactionStatusSuccess, Amount: 50000},
+		2: {ID: 2, UserID: 8, OrderID: "ORDER-2", Status: model.TransactionStatusSuccess, Amount: 50000},
+	}}
+	controller := &paymentController{paymentUsecase: payments}
+
+	tests := []struct {
+		name     string
+		userID   int
+		role     string
+		path     string
+		wantCode int
+	}{
+		{name: "detail of own transaction", userID: 7, role: "USER", path: "/payments/1", wantCode: http.StatusOK},
+		{name: "detail of another user's transaction", userID: 7, role: "USER", path: "/payments/2", wantCode: http.StatusForbidden},
+		{name: "receipt of another user's transaction", userID: 7, role: "USER", path: "/payments/2/receipt", wantCode: http.StatusForbidden},
+		{name: "admin sees any transaction", userID: 1, role: "ADMIN", path: "/payments/2", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/payments/:id", withUser(tt.userID, tt.role), controller.GetPaymentStatus)
+			router.GET("/payments/:id/receipt", withUser(tt.userID, tt.role), controller.DownloadReceipt)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("GET %s status = %d, want %d: %s", tt.path, w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("history only lists own transactions", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/payments", withUser(7, "USER"), controller.GetMyTransactions)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payments?user_id=8", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var resp struct {
+			Data model.TransactionListResponse `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if len(resp.Data.Transactions) != 1 || resp.Data.Transactions[0].UserID != 7 {
+			t.Errorf("history = %+v, want only the transaction of user 7", resp.Data.Transactions)
+		}
+	})
+}
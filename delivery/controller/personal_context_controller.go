@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"net/http"
+	"pijar/middleware"
+	"pijar/model"
+	"pijar/model/dto"
+	"pijar/usecase"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalContextController mengatur opt-in personal context untuk AI coach
+type PersonalContextController struct {
+	personalContextUC usecase.PersonalContextUsecase
+	rg                *gin.RouterGroup
+	aM                middleware.AuthMiddleware
+}
+
+func NewPersonalContextController(personalContextUC usecase.PersonalContextUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *PersonalContextController {
+	return &PersonalContextController{
+		personalContextUC: personalContextUC,
+		rg:                rg,
+		aM:                aM,
+	}
+}
+
+func (pc *PersonalContextController) Route() {
+	routes := pc.rg.Group("/sessions/personal-context")
+	routes.Use(pc.aM.RequireToken("USER", "ADMIN"))
+	{
+		routes.GET("", pc.GetSettings)
+		routes.PUT("", pc.UpdateSettings)
+		routes.GET("/:sessionId", pc.GetSharedContext)
+	}
+}
+
+// GetSettings returns whether personal context is enabled and a preview of what would be shared
+func (pc *PersonalContextController) GetSettings(c *gin.Context) {
+	userID, ok := pc.userID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := pc.personalContextUC.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Personal context settings retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// UpdateSettings turns personal context on or off
+func (pc *PersonalContextController) UpdateSettings(c *gin.Context) {
+	var req model.PersonalContextRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, ok := pc.userID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := pc.personalContextUC.SetEnabled(c.Request.Context(), userID, *req.Enabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Personal context settings updated successfully",
+		Data:    settings,
+	})
+}
+
+// GetSharedContext returns the profile last sent to the AI in a session, or null if none was shared
+func (pc *PersonalContextController) GetSharedContext(c *gin.Context) {
+	userID, ok := pc.userID(c)
+	if !ok {
+		return
+	}
+
+	shared, err := pc.personalContextUC.GetSharedContext(c.Request.Context(), userID, c.Param("sessionId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "sesi tidak ditemukan") {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Not Found",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Shared personal context retrieved successfully",
+		Data:    shared,
+	})
+}
+
+func (pc *PersonalContextController) userID(c *gin.Context) (int, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.Response{
+			Message: "Authentication required",
+		})
+		return 0, false
+	}
+	userID, ok := val.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Message: "Invalid user identity in context",
+		})
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"net/http"
+	"pijar/middleware"
+	"pijar/model"
+	"pijar/model/dto"
+	"pijar/usecase"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProductController mengelola katalog produk
+type ProductController struct {
+	productUC usecase.ProductUsecase
+	rg        *gin.RouterGroup
+	aM        middleware.AuthMiddleware
+}
+
+func NewProductController(productUC usecase.ProductUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *ProductController {
+	return &ProductController{
+		productUC: productUC,
+		rg:        rg,
+		aM:        aM,
+	}
+}
+
+func (pc *ProductController) Route() {
+	productRoutes := pc.rg.Group("/products")
+
+	// Endpoint publik untuk halaman paywall
+	productRoutes.GET("", pc.GetActiveProducts)
+
+	// Endpoint khusus admin
+	adminRoutes := productRoutes.Group("")
+	adminRoutes.Use(pc.aM.RequireToken("ADMIN"))
+	{
+		adminRoutes.GET("/all", pc.GetAllProducts)
+		adminRoutes.GET("/:id", pc.GetProductByID)
+		adminRoutes.POST("", pc.CreateProduct)
+		adminRoutes.PUT("/:id", pc.UpdateProduct)
+		adminRoutes.DELETE("/:id", pc.ArchiveProduct)
+	}
+}
+
+// GetActiveProducts lists products that can currently be purchased
+func (pc *ProductController) GetActiveProducts(c *gin.Context) {
+	products, err := pc.productUC.GetActiveProducts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to fetch products",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Products retrieved successfully",
+		Data:    products,
+	})
+}
+
+// GetAllProducts lists every product including archived ones
+func (pc *ProductController) GetAllProducts(c *gin.Context) {
+	products, err := pc.productUC.GetAllProducts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to fetch products",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Products retrieved successfully",
+		Data:    products,
+	})
+}
+
+func (pc *ProductController) GetProductByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid product ID",
+		})
+		return
+	}
+
+	product, err := pc.productUC.GetProductByID(id)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Product retrieved successfully",
+		Data:    product,
+	})
+}
+
+func (pc *ProductController) CreateProduct(c *gin.Context) {
+	var req model.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := pc.productUC.CreateProduct(req)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.Response{
+		Message: "Product created successfully",
+		Data:    product,
+	})
+}
+
+func (pc *ProductController) UpdateProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid product ID",
+		})
+		return
+	}
+
+	var req model.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	product, err := pc.productUC.UpdateProduct(id, req)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Product updated successfully",
+		Data:    product,
+	})
+}
+
+// ArchiveProduct retires a product; it stays in the database for existing transactions
+func (pc *ProductController) ArchiveProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid product ID",
+		})
+		return
+	}
+
+	if err := pc.productUC.ArchiveProduct(id); err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Product archived successfully",
+	})
+}
+
+func (pc *ProductController) handleError(c *gin.Context, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Message: "Not Found",
+			Error:   err.Error(),
+		})
+	case strings.Contains(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"net/http"
+	"pijar/middleware"
+	"pijar/model"
+	"pijar/model/dto"
+	"pijar/usecase"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SafetyController menampilkan layanan bantuan krisis dan antrean review flag untuk admin
+type SafetyController struct {
+	safetyUC usecase.SafetyUsecase
+	rg       *gin.RouterGroup
+	aM       middleware.AuthMiddleware
+}
+
+func NewSafetyController(safetyUC usecase.SafetyUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *SafetyController {
+	return &SafetyController{
+		safetyUC: safetyUC,
+		rg:       rg,
+		aM:       aM,
+	}
+}
+
+func (sc *SafetyController) Route() {
+	safetyRoutes := sc.rg.Group("/safety")
+
+	// Endpoint publik agar layanan bantuan bisa ditampilkan tanpa login
+	safetyRoutes.GET("/resources", sc.GetCrisisResources)
+
+	// Endpoint khusus admin
+	adminRoutes := safetyRoutes.Group("/flags")
+	adminRoutes.Use(sc.aM.RequireToken("ADMIN"))
+	{
+		adminRoutes.GET("", sc.GetFlags)
+		adminRoutes.GET("/:id", sc.GetFlagByID)
+		adminRoutes.PUT("/:id/review", sc.ReviewFlag)
+	}
+}
+
+// GetCrisisResources returns the crisis message and hotline list
+func (sc *SafetyController) GetCrisisResources(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Crisis resources retrieved successfully",
+		Data:    sc.safetyUC.CrisisSupport(),
+	})
+}
+
+// GetFlags lists flagged sessions and journals, open flags by default (?status=all for every flag)
+func (sc *SafetyController) GetFlags(c *gin.Context) {
+	status := c.DefaultQuery("status", model.SafetyFlagStatusOpen)
+	switch status {
+	case "all":
+		status = ""
+	case model.SafetyFlagStatusOpen, model.SafetyFlagStatusReviewed, model.SafetyFlagStatusDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid status, expected open, reviewed, dismissed or all",
+		})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid page",
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid limit",
+		})
+		return
+	}
+
+	flags, err := sc.safetyUC.GetFlags(status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   "Failed to fetch safety flags",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Safety flags retrieved successfully",
+		Data:    flags,
+	})
+}
+
+func (sc *SafetyController) GetFlagByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid flag ID",
+		})
+		return
+	}
+
+	flag, err := sc.safetyUC.GetFlagByID(id)
+	if err != nil {
+		sc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Safety flag retrieved successfully",
+		Data:    flag,
+	})
+}
+
+// ReviewFlag marks a flag as reviewed or dismissed by the current admin
+func (sc *SafetyController) ReviewFlag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid flag ID",
+		})
+		return
+	}
+
+	var req model.SafetyFlagReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	reviewerID, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Message: "Unauthorized",
+			Error:   "User not authenticated",
+		})
+		return
+	}
+
+	flag, err := sc.safetyUC.ReviewFlag(id, reviewerID.(int), req)
+	if err != nil {
+		sc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Safety flag reviewed successfully",
+		Data:    flag,
+	})
+}
+
+func (sc *SafetyController) handleError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Message: "Not Found",
+			Error:   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Message: "Internal Server Error",
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"pijar/middleware"
+	"pijar/model/dto"
+	"pijar/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionController menampilkan status langganan dan entitlement user
+type SubscriptionController struct {
+	subscriptionUC usecase.SubscriptionUsecase
+	rg             *gin.RouterGroup
+	aM             middleware.AuthMiddleware
+}
+
+func NewSubscriptionController(subscriptionUC usecase.SubscriptionUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *SubscriptionController {
+	return &SubscriptionController{
+		subscriptionUC: subscriptionUC,
+		rg:             rg,
+		aM:             aM,
+	}
+}
+
+func (sc *SubscriptionController) Route() {
+	subscriptionRoutes := sc.rg.Group("/subscriptions")
+	subscriptionRoutes.Use(sc.aM.RequireToken("USER", "ADMIN"))
+	{
+		subscriptionRoutes.GET("/me", sc.GetMySubscription)
+	}
+}
+
+// GetMySubscription returns the caller's active entitlements and subscription periods
+func (sc *SubscriptionController) GetMySubscription(c *gin.Context) {
+	val, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.Response{
+			Message: "Authentication required",
+		})
+		return
+	}
+	userID, ok := val.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Message: "Invalid user identity in context",
+		})
+		return
+	}
+
+	entitlements, err := sc.subscriptionUC.GetActiveEntitlements(userID)
+	if err != nil {
+		log.Printf("Error fetching entitlements for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Failed to fetch subscription",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	periods, err := sc.subscriptionUC.GetUserSubscriptions(userID)
+	if err != nil {
+		log.Printf("Error fetching subscription periods for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Failed to fetch subscription",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Subscription retrieved successfully",
+		Data: gin.H{
+			"entitlements": entitlements,
+			"periods":      periods,
+		},
+	})
+}
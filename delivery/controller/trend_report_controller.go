@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"pijar/middleware"
+	"pijar/model"
+	"pijar/model/dto"
+	"pijar/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrendReportController mengatur pengaturan laporan trend mingguan dan bulanan otomatis
+type TrendReportController struct {
+	trendReportUC usecase.TrendReportUsecase
+	rg            *gin.RouterGroup
+	aM            middleware.AuthMiddleware
+}
+
+func NewTrendReportController(trendReportUC usecase.TrendReportUsecase, rg *gin.RouterGroup, aM middleware.AuthMiddleware) *TrendReportController {
+	return &TrendReportController{
+		trendReportUC: trendReportUC,
+		rg:            rg,
+		aM:            aM,
+	}
+}
+
+func (tc *TrendReportController) Route() {
+	routes := tc.rg.Group("/journals-ai/reports/settings")
+	routes.Use(tc.aM.RequireToken("USER", "ADMIN"))
+	{
+		routes.GET("", tc.GetSettings)
+		routes.PUT("", tc.UpdateSettings)
+	}
+}
+
+// GetSettings returns whether trend reports are emailed and the timezone used for report periods
+func (tc *TrendReportController) GetSettings(c *gin.Context) {
+	userID, ok := tc.userID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := tc.trendReportUC.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Trend report settings retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// UpdateSettings turns trend report emails on or off and sets the report timezone
+func (tc *TrendReportController) UpdateSettings(c *gin.Context) {
+	var req model.TrendReportSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, ok := tc.userID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := tc.trendReportUC.UpdateSettings(c.Request.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidTimezone) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Bad Request",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Internal Server Error",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Trend report settings updated successfully",
+		Data:    settings,
+	})
+}
+
+func (tc *TrendReportController) userID(c *gin.Context) (int, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.Response{
+			Message: "Authentication required",
+		})
+		return 0, false
+	}
+	userID, ok := val.(int)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Message: "Invalid user identity in context",
+		})
+		return 0, false
+	}
+	return userID, true
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"pijar/middleware"
@@ -49,6 +50,17 @@ func (uc *UserController) Route() {
 	userProfile.Use(uc.authMiddleware.RequireToken("USER", "ADMIN")) // Both users and admins can access
 	userProfile.GET("/", uc.GetOwnProfileController)
 	userProfile.PUT("/", uc.UpdateOwnProfileController)
+
+	// Password reset routes - public
+	password := uc.rg.Group("/password")
+	password.POST("/forgot", uc.ForgotPasswordController)
+	password.POST("/reset", uc.ResetPasswordController)
+
+	// Registration with OTP verification - public
+	register := uc.rg.Group("/register")
+	register.POST("/otp", uc.StartRegistrationController)
+	register.POST("/resend", uc.ResendOTPController)
+	register.POST("/verify", uc.VerifyOTPController)
 }
 
 func (uc *UserController) CreateUserController(c *gin.Context) {
@@ -332,4 +344,142 @@ func (uc *UserController) UpdateOwnProfileController(c *gin.Context) {
 	})
 }
 
+// ForgotPasswordController sends a password reset code to the given email
+func (uc *UserController) ForgotPasswordController(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "invalid email",
+		})
+		return
+	}
+
+	if err := uc.UserUsecase.ForgotPassword(req.Email); err != nil {
+		log.Printf("Error sending password reset code: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Failed to process password reset request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Same response whether or not the email is registered
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "If the email is registered, a password reset code has been sent",
+	})
+}
+
+// ResetPasswordController sets a new password using a valid reset code
+func (uc *UserController) ResetPasswordController(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid input",
+		})
+		return
+	}
+
+	if err := uc.UserUsecase.ResetPassword(req.Email, req.Code, req.NewPassword); err != nil {
+		if errors.Is(err, usecase.ErrInvalidResetCode) {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Message: "Password reset failed",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Password reset failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "Password has been reset successfully, please login again",
+	})
+}
+
+// StartRegistrationController stores a pending registration and emails an OTP
+func (uc *UserController) StartRegistrationController(c *gin.Context) {
+	var req dto.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid input",
+		})
+		return
+	}
+
+	if err := uc.UserUsecase.StartRegistration(req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Registration failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.Response{
+		Message: "OTP has been sent to your email",
+	})
+}
+
+// ResendOTPController sends a fresh OTP for an existing pending registration
+func (uc *UserController) ResendOTPController(c *gin.Context) {
+	var req dto.ResendOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "invalid email",
+		})
+		return
+	}
+
+	if err := uc.UserUsecase.GenerateOTP(req.Email); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Failed to resend OTP",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Message: "OTP has been sent to your email",
+	})
+}
+
+// VerifyOTPController activates a pending registration using the emailed OTP
+func (uc *UserController) VerifyOTPController(c *gin.Context) {
+	var req dto.VerifyOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Bad Request",
+			Error:   "Invalid input",
+		})
+		return
+	}
+
+	user, err := uc.UserUsecase.VerifyOTP(req.Email, req.OTP)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidOTP) {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Message: "OTP verification failed",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "OTP verification failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.Response{
+		Message: "Registration successful, please login",
+		Data:    user,
+	})
+}
+
 // Login method has been moved to AuthController
@@ -0,0 +1,73 @@
+package delivery
+
+import (
+	"context"
+	"log"
+	"pijar/model"
+	"pijar/usecase"
+	"sync"
+	"time"
+)
+
+// journalAnalysisWorkers memproses antrean analisis jurnal di tabel journal_analysis_jobs.
+// Saat shutdown worker berhenti mengambil job baru. Analisis yang sedang berjalan diberi waktu
+// drainTimeout untuk selesai; setelah itu dibatalkan dan job-nya kembali ke antrean.
+type journalAnalysisWorkers struct {
+	journalAIUC  usecase.JournalAIUsecase
+	workers      int
+	pollInterval time.Duration
+	drainTimeout time.Duration
+}
+
+func newJournalAnalysisWorkers(journalAIUC usecase.JournalAIUsecase, workers int, pollInterval, drainTimeout time.Duration) *journalAnalysisWorkers {
+	return &journalAnalysisWorkers{
+		journalAIUC:  journalAIUC,
+		workers:      workers,
+		pollInterval: pollInterval,
+		drainTimeout: drainTimeout,
+	}
+}
+
+// start menjalankan worker sampai ctx dibatalkan. wg selesai setelah job yang sedang berjalan selesai.
+func (w *journalAnalysisWorkers) start(ctx context.Context, wg *sync.WaitGroup) {
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+}
+
+func (w *journalAnalysisWorkers) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.journalAIUC.ClaimAnalysisJob(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to claim journal analysis job: %v", err)
+		}
+		if job == nil {
+			// Antrean kosong atau database bermasalah, tunggu sebelum mengecek lagi
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.pollInterval):
+			}
+			continue
+		}
+		w.process(ctx, *job)
+	}
+}
+
+func (w *journalAnalysisWorkers) process(ctx context.Context, job model.JournalAnalysisJob) {
+	// Pembatalan ctx saat shutdown baru diteruskan ke job setelah drainTimeout
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(w.drainTimeout, cancel)
+	})
+	defer stop()
+
+	if err := w.journalAIUC.ProcessAnalysisJob(jobCtx, job); err != nil {
+		log.Printf("Journal analysis job failed: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package delivery
+
+import (
+	"context"
+	"log"
+	"pijar/model"
+	"pijar/usecase"
+	"sync"
+	"time"
+)
+
+const (
+	reconcileBatchSize  = 100
+	reconcileBaseDelay  = time.Minute
+	reconcileMaxBackoff = time.Hour
+)
+
+// paymentReconciler mengecek ulang transaksi pending ke Midtrans untuk menangani callback
+// yang tidak pernah sampai. Transaksi yang pengecekannya gagal ditunda dengan backoff
+// eksponensial agar Midtrans tidak terus-menerus dipanggil.
+type paymentReconciler struct {
+	paymentUC  usecase.PaymentUsecase
+	staleAfter time.Duration
+	pendingTTL time.Duration
+	workers    int
+
+	mu      sync.Mutex
+	backoff map[int]reconcileBackoff
+}
+
+type reconcileBackoff struct {
+	failures  int
+	nextCheck time.Time
+}
+
+func newPaymentReconciler(paymentUC usecase.PaymentUsecase, staleAfter, pendingTTL time.Duration, workers int) *paymentReconciler {
+	if workers < 1 {
+		workers = 1
+	}
+	return &paymentReconciler{
+		paymentUC:  paymentUC,
+		staleAfter: staleAfter,
+		pendingTTL: pendingTTL,
+		workers:    workers,
+		backoff:    make(map[int]reconcileBackoff),
+	}
+}
+
+// run memproses satu batch transaksi pending. Transaksi yang masih menunggu backoff tidak diambil
+// dari database, sehingga tidak memenuhi batch dan menahan transaksi lain. Pengecekan yang sedang
+// berjalan diselesaikan saat ctx dibatalkan, tetapi transaksi berikutnya tidak lagi dimulai.
+func (r *paymentReconciler) run(ctx context.Context) error {
+	now := time.Now()
+	transactions, err := r.paymentUC.GetStalePendingTransactions(now.Add(-r.staleAfter), reconcileBatchSize, r.backedOff(now))
+	if err != nil {
+		return err
+	}
+
+	expireBefore := now.Add(-r.pendingTTL)
+	sem := make(chan struct{}, r.workers)
+	var wg sync.WaitGroup
+
+	for _, transaction := range transactions {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			updated, err := r.paymentUC.ReconcilePendingTransaction(transaction, expireBefore)
+			if err != nil {
+				r.fail(transaction.ID, err)
+				return
+			}
+			r.clear(transaction.ID)
+			if updated.Status != transaction.Status {
+				log.Printf("Reconciled transaction %d (order %s): %s -> %s", transaction.ID, transaction.OrderID, transaction.Status, updated.Status)
+			}
+		}()
+	}
+
+	wg.Wait()
+	r.prune(transactions, now)
+	return nil
+}
+
+// backedOff mengembalikan transaksi yang gagal dicek dan belum boleh dicek lagi
+func (r *paymentReconciler) backedOff(now time.Time) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]int, 0, len(r.backoff))
+	for id, b := range r.backoff {
+		if now.Before(b.nextCheck) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (r *paymentReconciler) fail(id int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := r.backoff[id]
+	b.failures++
+	delay := reconcileBaseDelay << (b.failures - 1)
+	if delay <= 0 || delay > reconcileMaxBackoff {
+		delay = reconcileMaxBackoff
+	}
+	b.nextCheck = time.Now().Add(delay)
+	r.backoff[id] = b
+
+	log.Printf("Failed to reconcile transaction %d (attempt %d), retrying in %s: %v", id, b.failures, delay, err)
+}
+
+func (r *paymentReconciler) clear(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.backoff, id)
+}
+
+// prune membuang data backoff milik transaksi yang sudah tidak pending lagi. Hanya backoff yang
+// sudah lewat (pada now) yang diperiksa, karena transaksi yang masih backoff tidak ikut diambil.
+func (r *paymentReconciler) prune(pending []model.Transaction, now time.Time) {
+	// Batch yang penuh belum tentu berisi semua transaksi pending
+	if len(pending) >= reconcileBatchSize {
+		return
+	}
+
+	ids := make(map[int]struct{}, len(pending))
+	for _, transaction := range pending {
+		ids[transaction.ID] = struct{}{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, b := range r.backoff {
+		if now.Before(b.nextCheck) {
+			continue
+		}
+		if _, ok := ids[id]; !ok {
+			delete(r.backoff, id)
+		}
+	}
+}
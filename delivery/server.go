@@ -11,9 +11,12 @@ import (
 	"pijar/config"
 	"pijar/delivery/controller"
 	"pijar/middleware"
+	"pijar/migrations"
 	"pijar/repository"
 	"pijar/usecase"
 	"pijar/utils/service"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -33,33 +36,64 @@ type Server struct {
 	userUsecase    usecase.UserUsecase
 	authUsecase    *usecase.AuthUsecase
 	paymentUsecase usecase.PaymentUsecase
+	subscriptionUC usecase.SubscriptionUsecase
+	productUC      usecase.ProductUsecase
+	safetyUC       usecase.SafetyUsecase
+	personaUC      usecase.CoachPersonaUsecase
+	personalCtxUC  usecase.PersonalContextUsecase
+	trendReportUC  usecase.TrendReportUsecase
 	jwtService     service.JwtService
 	authMiddleware *middleware.AuthMiddleware
+	entMiddleware  *middleware.EntitlementMiddleware
 	engine         *gin.Engine
 	host           string
 	db             *sql.DB
 	server         *http.Server
+	fakeMidtrans   *service.FakeMidtrans
+	reconciler     *paymentReconciler
+	reconcileEvery time.Duration
+	journalWorkers *journalAnalysisWorkers
+	reportInterval time.Duration
+
+	// background jobs, dihentikan saat shutdown
+	bgCancel context.CancelFunc
+	bgWG     sync.WaitGroup
 }
 
+const (
+	otpCleanupInterval     = 15 * time.Minute
+	sessionCleanupInterval = time.Hour
+)
+
 func (s *Server) initRoute() {
 	rg := s.engine.Group("/pijar")
 
 	controller.NewUserController(rg, s.userUsecase, s.jwtService, s.authMiddleware).Route()
-	controller.NewAuthController(rg, s.jwtService, *s.authUsecase).Route()
+	controller.NewAuthController(rg, s.jwtService, *s.authUsecase, s.authMiddleware).Route()
 	controller.NewPaymentController(rg, s.paymentUsecase, *s.authMiddleware).Route()
 	controller.NewMidtransCallbackHandler(rg, s.paymentUsecase).Route()
-	controller.NewSessionHandler(s.coachUC, rg, *s.authMiddleware).Route()
+	controller.NewProductController(s.productUC, rg, *s.authMiddleware).Route()
+	controller.NewSubscriptionController(s.subscriptionUC, rg, *s.authMiddleware).Route()
+	controller.NewSessionHandler(s.coachUC, rg, *s.authMiddleware, *s.entMiddleware).Route()
 	controller.NewJournalController(s.journalUC, rg, *s.authMiddleware).Route()
-	controller.NewJournalAIController(s.journalAIUC, rg, *s.authMiddleware).Route()
+	controller.NewJournalAIController(s.journalAIUC, rg, *s.authMiddleware, *s.entMiddleware).Route()
 	controller.NewTopicController(s.topicUC, rg, *s.authMiddleware).Route()
-	controller.NewArticleController(s.articleUC, rg, *s.authMiddleware).Route()
+	controller.NewArticleController(s.articleUC, rg, *s.authMiddleware, *s.entMiddleware).Route()
 	controller.NewGoalController(s.dailyGoalUC, rg, *s.authMiddleware).Route()
+	controller.NewSafetyController(s.safetyUC, rg, *s.authMiddleware).Route()
+	controller.NewCoachPersonaController(s.personaUC, rg, *s.authMiddleware).Route()
+	controller.NewPersonalContextController(s.personalCtxUC, rg, *s.authMiddleware).Route()
+	controller.NewTrendReportController(s.trendReportUC, rg, *s.authMiddleware).Route()
 }
 
 func (s *Server) Run() {
 
 	s.initRoute()
 
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	s.bgCancel = bgCancel
+	s.startBackgroundJobs(bgCtx)
+
 	s.server = &http.Server{
 		Addr:    s.host,
 		Handler: s.engine,
@@ -84,6 +118,16 @@ func (s *Server) Run() {
 		fmt.Printf("Server forced to shutdown: %v\n", err)
 	}
 
+	if s.fakeMidtrans != nil {
+		if err := s.fakeMidtrans.Close(ctx); err != nil {
+			fmt.Printf("Error stopping fake midtrans: %v\n", err)
+		}
+	}
+
+	// Hentikan background jobs sebelum koneksi database ditutup
+	s.bgCancel()
+	s.bgWG.Wait()
+
 	if err := s.db.Close(); err != nil {
 		fmt.Printf("Error closing database: %v\n", err)
 	}
@@ -92,6 +136,43 @@ func (s *Server) Run() {
 
 }
 
+// startBackgroundJobs menjalankan job periodik yang hidup selama server berjalan
+func (s *Server) startBackgroundJobs(ctx context.Context) {
+	s.runPeriodic(ctx, "otp cleanup", otpCleanupInterval, func(context.Context) error {
+		return s.userUsecase.CleanupExpiredOTPs()
+	})
+	s.runPeriodic(ctx, "auth session cleanup", sessionCleanupInterval, func(context.Context) error {
+		return s.authUsecase.CleanupExpiredSessions()
+	})
+	if s.reconcileEvery > 0 {
+		s.runPeriodic(ctx, "payment reconciliation", s.reconcileEvery, s.reconciler.run)
+	}
+	s.journalWorkers.start(ctx, &s.bgWG)
+	if s.reportInterval > 0 {
+		s.runPeriodic(ctx, "trend reports", s.reportInterval, s.trendReportUC.GenerateDueReports)
+	}
+}
+
+// runPeriodic menjalankan fn setiap interval sampai ctx dibatalkan
+func (s *Server) runPeriodic(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	s.bgWG.Add(1)
+	go func() {
+		defer s.bgWG.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					log.Printf("Background job %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
+
 func NewServer() *Server {
 	err := godotenv.Load()
 	if err != nil {
@@ -104,10 +185,27 @@ func NewServer() *Server {
 		return nil
 	}
 
+	// Migrasi bisa juga dijalankan terpisah lewat subcommand migrate sebelum deploy
+	if cfg.MigrateOnStart {
+		migrator, err := migrations.New(db)
+		if err != nil {
+			log.Fatalf("Failed to load database migrations: %v", err)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to run database migrations: %v", err)
+		}
+		log.Printf("Database migrations applied: %d", len(applied))
+	}
+
 	// Initialize database repositories
 	userRepo := repository.NewUserRepo(db)
 	productRepo := repository.NewProductRepository(db)
 	transactionRepo := repository.NewTransactionRepository(db)
+	authSessionRepo := repository.NewAuthSessionRepository(db)
+	subscriptionRepo := repository.NewSubscriptionRepository(db)
+	midtransCallbackRepo := repository.NewMidtransCallbackRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
 
 	// Initialize service dependencies
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -115,64 +213,163 @@ func NewServer() *Server {
 	jwtExpiryStr := os.Getenv("JWT_EXPIRY")
 	jwtExpiry, err := time.ParseDuration(jwtExpiryStr)
 	if err != nil {
-		jwtExpiry = 24 * time.Hour
-		fmt.Printf("Warning: Could not parse JWT_EXPIRY value '%s', using default of 24h: %v\n", jwtExpiryStr, err)
+		jwtExpiry = 15 * time.Minute
+		fmt.Printf("Warning: Could not parse JWT_EXPIRY value '%s', using default of 15m: %v\n", jwtExpiryStr, err)
+	}
+	refreshExpiryStr := os.Getenv("REFRESH_TOKEN_EXPIRY")
+	refreshExpiry, err := time.ParseDuration(refreshExpiryStr)
+	if err != nil {
+		refreshExpiry = 30 * 24 * time.Hour
+		fmt.Printf("Warning: Could not parse REFRESH_TOKEN_EXPIRY value '%s', using default of 720h: %v\n", refreshExpiryStr, err)
 	}
 	jwtService := service.NewJwtService(jwtSecret, appName, jwtExpiry)
-	restyClient := resty.New()
-	midtransService := service.NewMidtransService(restyClient)
-
-	// Initialize middleware components
-	authMiddleware := middleware.NewAuthMiddleware(jwtService)
+	// Timeout agar request Midtrans yang menggantung tidak menahan reconciler saat shutdown
+	restyClient := resty.New().SetTimeout(30 * time.Second)
+
+	// Initialize Midtrans: sandbox, production, atau server palsu untuk development tanpa jaringan
+	var fakeMidtrans *service.FakeMidtrans
+	var midtransEndpoint service.MidtransEndpoint
+	if cfg.MidtransEnv == "fake" {
+		if cfg.MidtransServerKey == "" {
+			cfg.MidtransServerKey = "fake-server-key"
+		}
+		notificationURL := fmt.Sprintf("http://127.0.0.1:%s/pijar/midtrans/callback", cfg.ApiPort)
+		fakeMidtrans = service.NewFakeMidtrans(cfg.MidtransServerKey, notificationURL)
+		if err := fakeMidtrans.Start("127.0.0.1:0"); err != nil {
+			log.Fatalf("Failed to start fake midtrans: %v", err)
+		}
+		midtransEndpoint = fakeMidtrans.Endpoint()
+	} else {
+		midtransEndpoint, err = service.MidtransEndpointFor(cfg.MidtransEnv)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.MidtransSnapURL != "" {
+		midtransEndpoint.SnapBaseURL = strings.TrimRight(cfg.MidtransSnapURL, "/")
+	}
+	if cfg.MidtransAPIURL != "" {
+		midtransEndpoint.APIBaseURL = strings.TrimRight(cfg.MidtransAPIURL, "/")
+	}
+	midtransService := service.NewMidtransService(restyClient, cfg.MidtransServerKey, midtransEndpoint)
+
+	// Initialize mailer: SMTP untuk production, file outbox untuk development lokal
+	var mailer service.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		mailer = service.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.MailFrom)
+	default:
+		mailer = service.NewFileMailer(cfg.OutboxDir, cfg.MailFrom)
+	}
+	emailRenderer := service.NewEmailRenderer(appName)
 
 	// Initialize usecase layer components
-	userUsecase := usecase.NewUserUsecase(userRepo)
-	authUsecase := usecase.NewAuthUsecase(userRepo, jwtService)
-	paymentUsecase := usecase.NewPaymentUsecase(midtransService, productRepo, transactionRepo, userRepo)
+	userUsecase := usecase.NewUserUsecase(userRepo, authSessionRepo, mailer, emailRenderer)
+	authUsecase := usecase.NewAuthUsecase(userRepo, authSessionRepo, jwtService, refreshExpiry, mailer, emailRenderer)
+	subscriptionUsecase := usecase.NewSubscriptionUsecase(subscriptionRepo)
+	productUsecase := usecase.NewProductUsecase(productRepo)
+	paymentUsecase := usecase.NewPaymentUsecase(midtransService, productRepo, transactionRepo, userRepo, subscriptionUsecase, midtransCallbackRepo, refundRepo)
+
+	// Initialize middleware components
+	authMiddleware := middleware.NewAuthMiddleware(jwtService, authSessionRepo)
+	entitlementMiddleware := middleware.NewEntitlementMiddleware(subscriptionUsecase)
 
 	// Initialize session management components
 	sessionRepo := repository.NewSession(db)
 
-	// Initialize Gemini AI client with API key from environment
-	geminiAPIKey := os.Getenv("GEMINI_API")
-	if geminiAPIKey == "" {
-		log.Fatal("GEMINI_API environment variable is not set")
+	// Initialize LLM providers, dipilih per fitur lewat LLM_*_PROVIDER
+	llmConfig := service.LLMProviderConfig{
+		GeminiAPIKey:  cfg.GeminiAPIKey,
+		GeminiModel:   cfg.GeminiModel,
+		OpenAIAPIKey:  cfg.AIAPIKey,
+		OpenAIBaseURL: cfg.AIBaseURL,
+		OpenAIModel:   cfg.AIModel,
+		OllamaURL:     cfg.OllamaURL,
+		OllamaModel:   cfg.OllamaModel,
+		// Satu transport per provider: timeout, retry dan circuit breaker dipakai bersama oleh semua fitur
+		Transports: service.NewLLMTransports(service.LLMTransportConfig{
+			Timeout:          cfg.AITimeout,
+			MaxRetries:       cfg.AIMaxRetries,
+			RetryBaseDelay:   cfg.AIRetryBaseDelay,
+			RetryMaxDelay:    cfg.AIRetryMaxDelay,
+			BreakerThreshold: cfg.AIBreakerThreshold,
+			BreakerCooldown:  cfg.AIBreakerCooldown,
+		}),
+	}
+	coachLLM, err := service.NewLLMProvider(cfg.CoachLLMProvider, llmConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize coach LLM provider: %v", err)
+	}
+	journalLLM, err := service.NewLLMProvider(cfg.JournalLLMProvider, llmConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize journal LLM provider: %v", err)
+	}
+	articleLLM, err := service.NewLLMProvider(cfg.ArticleLLMProvider, llmConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize article LLM provider: %v", err)
 	}
 
-	// Create a single Gemini client instance
-	geminiClient := service.NewGeminiClient(geminiAPIKey)
-
-	// Configure AI coach service with custom prompt and settings
-	geminiClient.SystemPrompt = "You are a professional mental health coach. Your role is to provide empathetic support and guidance. When users need help with decision-making, use the cost-benefit analysis framework to help them think through their options. Maintain a cheerful and supportive tone, but use emoticons sparingly. Keep your responses concise and focused. Avoid repeating yourself. Your goal is to help users gain clarity and make informed decisions about their mental well-being."
-	geminiClient.Temperature = 0.7
-	geminiClient.MaxTokens = 500
+	// Prompt dan parameter coach diambil dari persona di database (tabel coach_personas)
+	personaRepo := repository.NewCoachPersonaRepository(db)
+	personaUsecase := usecase.NewCoachPersonaUsecase(personaRepo)
 
-	// Initialize session management
-	coachUsecase := usecase.NewSessionUsecase(sessionRepo, geminiClient)
+	// Analisis jurnal punya pengaturan sendiri, terpisah dari persona coach
+	journalLLMOptions := service.LLMOptions{
+		SystemPrompt: "You are an empathetic journaling assistant that analyzes journal entries for a mental wellness app. Be non-judgmental and growth-focused, and answer only in the JSON format requested.",
+		Temperature:  0.3,
+		MaxTokens:    1000,
+	}
 
-	// Initialize journal management components
-	journalRepo := repository.NewJournalRepository(db)
-	journalUsecase := usecase.NewJournalUsecase(journalRepo)
+	// Initialize safety layer: kata kunci krisis ditambah klasifikasi LLM (SAFETY_LLM_PROVIDER=off untuk mematikan)
+	var safetyLLM service.LLMProvider
+	if cfg.SafetyLLMProvider != "off" {
+		safetyLLM, err = service.NewLLMProvider(cfg.SafetyLLMProvider, llmConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize safety LLM provider: %v", err)
+		}
+	}
+	hotlines, err := service.ParseHotlines(cfg.SafetyHotlines)
+	if err != nil {
+		log.Fatalf("Invalid SAFETY_HOTLINES: %v", err)
+	}
+	safetyKeywords := append(append([]string{}, service.DefaultCrisisKeywords...), cfg.SafetyKeywords...)
+	safetyRepo := repository.NewSafetyRepository(db)
+	safetyUsecase := usecase.NewSafetyUsecase(safetyRepo, service.NewSafetyClassifier(safetyKeywords, safetyLLM), hotlines)
 
 	// Initialize journal AI components
+	journalRepo := repository.NewJournalRepository(db)
 	journalAIRepo := repository.NewJournalAnalysisRepository(db)
+	journalAnalysisJobRepo := repository.NewJournalAnalysisJobRepository(db)
 	
 	// Create journal AI service
-	journalAIService := service.NewJournalAnalysisService(geminiClient, journalAIRepo)
-	journalAIUsecase := usecase.NewJournalAIUsecase(*journalAIRepo, journalRepo, journalAIService)
+	journalAIService := service.NewJournalAnalysisService(journalLLM, journalLLMOptions, journalAIRepo)
+	journalAIUsecase := usecase.NewJournalAIUsecase(journalAIRepo, journalRepo, journalAnalysisJobRepo, journalAIService, safetyUsecase, cfg.JournalAnalysisMaxAttempts)
+
+	// Initialize journal management components; jurnal baru atau yang diubah masuk antrean analisis
+	journalUsecase := usecase.NewJournalUsecase(journalRepo, journalAIUsecase, subscriptionUsecase)
+
+	// Laporan trend mingguan dan bulanan otomatis, dengan ringkasan AI dan email opsional
+	trendReportPrefRepo := repository.NewTrendReportPreferenceRepository(db)
+	trendReportUsecase := usecase.NewTrendReportUsecase(journalAIRepo, trendReportPrefRepo, userRepo, journalAIService, subscriptionUsecase, mailer, emailRenderer)
 
 	// Initialize topic management components
 	topicRepo := repository.NewTopicRepository(db)
 	topicUsecase := usecase.NewTopicUsecase(topicRepo)
 
 	// Initialize article management components
-	articleRepo := repository.NewArticleRepository(db)
-	articleUsecase := usecase.NewArticleUsecase(articleRepo)
+	articleGenerator := service.NewArticleGenerator(articleLLM, service.LLMOptions{})
+	articleRepo := repository.NewArticleRepository(db, articleGenerator)
+	articleUsecase := usecase.NewArticleUsecase(articleRepo, articleGenerator)
 
 	// Initialize daily goals management components
 	dailyGoalRepo := repository.NewDailyGoalsRepository(db)
 	dailyGoalUC := usecase.NewGoalUseCase(dailyGoalRepo)
 
+	// Initialize session management; personal context memakai data jurnal dan goal di atas
+	coachPrefRepo := repository.NewCoachPreferenceRepository(db)
+	personalContextUsecase := usecase.NewPersonalContextUsecase(coachPrefRepo, journalAIRepo, dailyGoalRepo, sessionRepo)
+	coachUsecase := usecase.NewSessionUsecase(sessionRepo, personaUsecase, personalContextUsecase, coachLLM, safetyUsecase)
+
 	engine := gin.Default()
 	host := fmt.Sprintf(":%s", cfg.ApiPort)
 
@@ -187,10 +384,22 @@ func NewServer() *Server {
 		userUsecase:    userUsecase,
 		authUsecase:    authUsecase,
 		paymentUsecase: paymentUsecase,
+		subscriptionUC: subscriptionUsecase,
+		productUC:      productUsecase,
+		safetyUC:       safetyUsecase,
+		personaUC:      personaUsecase,
+		personalCtxUC:  personalContextUsecase,
+		trendReportUC:  trendReportUsecase,
 		jwtService:     jwtService,
 		authMiddleware: authMiddleware,
+		entMiddleware:  entitlementMiddleware,
 		engine:         engine,
 		host:           host,
 		db:             db,
+		fakeMidtrans:   fakeMidtrans,
+		reconciler:     newPaymentReconciler(paymentUsecase, cfg.ReconcileStaleAfter, cfg.PendingTTL, cfg.ReconcileWorkers),
+		reconcileEvery: cfg.ReconcileInterval,
+		journalWorkers: newJournalAnalysisWorkers(journalAIUsecase, cfg.JournalAnalysisWorkers, cfg.JournalAnalysisPollInterval, cfg.JournalAnalysisDrainTimeout),
+		reportInterval: cfg.TrendReportInterval,
 	}
 }
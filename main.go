@@ -1,10 +1,19 @@
 package main
 
 import (
+	"log"
+	"os"
 	"pijar/delivery"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := delivery.RunMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	server := delivery.NewServer()
 	server.Run()
 }
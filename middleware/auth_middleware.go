@@ -3,6 +3,7 @@ package middleware
 import (
 	"log"
 	"net/http"
+	"pijar/repository"
 	"pijar/utils/service"
 	"strconv"
 	"strings"
@@ -15,13 +16,17 @@ type AuthMiddlewareInterface interface {
 }
 
 type AuthMiddleware struct {
-	jwtService service.JwtService
+	jwtService  service.JwtService
+	sessionRepo repository.AuthSessionRepository
 }
 
 var _ AuthMiddlewareInterface = &AuthMiddleware{}
 
-func NewAuthMiddleware(jwtService service.JwtService) *AuthMiddleware {
-	return &AuthMiddleware{jwtService: jwtService}
+func NewAuthMiddleware(jwtService service.JwtService, sessionRepo repository.AuthSessionRepository) *AuthMiddleware {
+	return &AuthMiddleware{
+		jwtService:  jwtService,
+		sessionRepo: sessionRepo,
+	}
 }
 
 func (a *AuthMiddleware) RequireToken(allowedRoles ...string) gin.HandlerFunc {
@@ -39,6 +44,22 @@ func (a *AuthMiddleware) RequireToken(allowedRoles ...string) gin.HandlerFunc {
 			return
 		}
 
+		// cek sesi: token dari sesi yang sudah logout / dicabut tidak boleh dipakai lagi
+		if claims.SessionID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+		active, err := a.sessionRepo.IsSessionActive(claims.SessionID)
+		if err != nil {
+			log.Printf("Error checking session %s: %v", claims.SessionID, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			return
+		}
+
 		log.Println("claims.Role:", claims.Role)
 		log.Println("allowedRoles:", allowedRoles)
 		// cek role
@@ -64,6 +85,7 @@ func (a *AuthMiddleware) RequireToken(allowedRoles ...string) gin.HandlerFunc {
 
 		c.Set("userID", intUserID) // note lowercase key and int value
 		c.Set("role", claims.Role)
+		c.Set("sessionID", claims.SessionID)
 		c.Next()
 	}
 }
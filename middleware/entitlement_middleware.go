@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"pijar/usecase"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EntitlementMiddlewareInterface interface {
+	RequireEntitlement(entitlement string) gin.HandlerFunc
+}
+
+// EntitlementMiddleware membatasi fitur premium untuk user yang punya langganan aktif.
+// Harus dipasang setelah AuthMiddleware.RequireToken karena membaca userID dan role dari context.
+type EntitlementMiddleware struct {
+	subscriptionUC usecase.SubscriptionUsecase
+}
+
+var _ EntitlementMiddlewareInterface = &EntitlementMiddleware{}
+
+func NewEntitlementMiddleware(subscriptionUC usecase.SubscriptionUsecase) *EntitlementMiddleware {
+	return &EntitlementMiddleware{
+		subscriptionUC: subscriptionUC,
+	}
+}
+
+func (e *EntitlementMiddleware) RequireEntitlement(entitlement string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// admin selalu boleh mengakses fitur premium
+		if role, ok := c.Get("role"); ok {
+			if r, ok := role.(string); ok && strings.EqualFold(r, "ADMIN") {
+				c.Next()
+				return
+			}
+		}
+
+		val, exists := c.Get("userID")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		userID, ok := val.(int)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Invalid user identity in context"})
+			return
+		}
+
+		allowed, err := e.subscriptionUC.HasEntitlement(userID, entitlement)
+		if err != nil {
+			log.Printf("Error checking entitlement %s for user %d: %v", entitlement, userID, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify subscription"})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusPaymentRequired, gin.H{
+				"error":       "Active subscription required",
+				"entitlement": entitlement,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,108 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	file := func(body string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(body)}
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "pairs are sorted by version, not by file name",
+			fsys: fstest.MapFS{
+				"sql/0010_add_index.up.sql":      file("CREATE INDEX"),
+				"sql/0010_add_index.down.sql":    file("DROP INDEX"),
+				"sql/0002_create_users.up.sql":   file("CREATE TABLE"),
+				"sql/0002_create_users.down.sql": file("DROP TABLE"),
+				"sql/9_seed.up.sql":              file("INSERT"),
+				"sql/9_seed.down.sql":            file("DELETE"),
+			},
+			want: []Migration{
+				{Version: 2, Name: "create_users", Up: "CREATE TABLE", Down: "DROP TABLE"},
+				{Version: 9, Name: "seed", Up: "INSERT", Down: "DELETE"},
+				{Version: 10, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+			},
+		},
+		{
+			name:    "invalid file name",
+			fsys:    fstest.MapFS{"sql/0001_Create-Users.up.sql": file("")},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "version zero",
+			fsys:    fstest.MapFS{"sql/0000_init.up.sql": file(""), "sql/0000_init.down.sql": file("")},
+			wantErr: "invalid migration version",
+		},
+		{
+			name:    "missing down file",
+			fsys:    fstest.MapFS{"sql/0001_init.up.sql": file("")},
+			wantErr: "migration 0001_init needs both an up and a down file",
+		},
+		{
+			name:    "missing up file",
+			fsys:    fstest.MapFS{"sql/0001_init.down.sql": file("")},
+			wantErr: "migration 0001_init needs both an up and a down file",
+		},
+		{
+			name: "same version used by two names",
+			fsys: fstest.MapFS{
+				"sql/0003_add_roles.up.sql":   file(""),
+				"sql/0003_add_roles.down.sql": file(""),
+				"sql/0003_add_tags.up.sql":    file(""),
+				"sql/0003_add_tags.down.sql":  file(""),
+			},
+			wantErr: "migration version 3 is used by both",
+		},
+		{
+			name:    "missing sql directory",
+			fsys:    fstest.MapFS{},
+			wantErr: "failed to read migrations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(tt.fsys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("load() returned %d migrations, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("migration %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestEmbeddedMigrations memastikan file di sql/ yang ikut ter-embed selalu bisa dimuat
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatalf("load(files) error = %v", err)
+	}
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			t.Errorf("migration %s is not after %s", migrations[i].label(), migrations[i-1].label())
+		}
+	}
+}
@@ -0,0 +1,16 @@
+-- Tabel users: akun user dan admin. Registrasi selalu membuat role USER;
+-- admin dibuat dengan mengubah role langsung di database.
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    birth_year INTEGER NOT NULL DEFAULT 0,
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    role VARCHAR(20) NOT NULL DEFAULT 'USER',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Login dan lupa password mencari email tanpa membedakan huruf besar dan kecil
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users(LOWER(email));
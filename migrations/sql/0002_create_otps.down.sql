@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pending_registrations;
+DROP TABLE IF EXISTS otps;
@@ -0,0 +1,31 @@
+-- Tabel otps: kode sekali pakai untuk registrasi dan reset password
+CREATE TABLE IF NOT EXISTS otps (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    code VARCHAR(255) NOT NULL,
+    purpose VARCHAR(32) NOT NULL DEFAULT 'registration',
+    expires_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Untuk database yang sudah punya tabel otps sebelum kolom purpose ditambahkan
+ALTER TABLE otps ADD COLUMN IF NOT EXISTS purpose VARCHAR(32) NOT NULL DEFAULT 'registration';
+-- Kode disimpan sebagai hash bcrypt (60 karakter)
+ALTER TABLE otps ALTER COLUMN code TYPE VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_otps_email_purpose ON otps(email, purpose);
+
+-- Tabel pending_registrations: data registrasi yang menunggu verifikasi OTP
+CREATE TABLE IF NOT EXISTS pending_registrations (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    password_hash VARCHAR(255) NOT NULL,
+    birth_year INTEGER NOT NULL DEFAULT 0,
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
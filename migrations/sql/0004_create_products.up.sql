@@ -0,0 +1,17 @@
+-- Tabel products: katalog produk yang bisa dibeli lewat Midtrans
+CREATE TABLE IF NOT EXISTS products (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price INTEGER NOT NULL CHECK (price > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    monthly_subscription INTEGER NOT NULL DEFAULT 0 CHECK (monthly_subscription >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Untuk database yang sudah punya tabel products sebelum kolom katalog ditambahkan
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS monthly_subscription INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
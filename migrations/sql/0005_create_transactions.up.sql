@@ -0,0 +1,18 @@
+-- Tabel transactions: pembelian produk lewat Midtrans. order_id dikirim ke Midtrans dan
+-- dipakai untuk mencocokkan notifikasi dengan transaksinya.
+CREATE TABLE IF NOT EXISTS transactions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    amount INTEGER NOT NULL,
+    status VARCHAR(30) NOT NULL DEFAULT 'pending',
+    order_id VARCHAR(255) NOT NULL UNIQUE,
+    payment_url TEXT NOT NULL DEFAULT '',
+    midtrans_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id, created_at);
+-- Reconciler mencari transaksi pending yang sudah lama tidak berubah
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status, updated_at);
@@ -0,0 +1,34 @@
+-- Tabel midtrans_callbacks: log setiap notifikasi Midtrans yang lolos verifikasi signature.
+-- Unik per (transaction_id, transaction_status) sehingga notifikasi ulang dikenali
+-- dan tidak diproses dua kali.
+CREATE TABLE IF NOT EXISTS midtrans_callbacks (
+    id SERIAL PRIMARY KEY,
+    transaction_id VARCHAR(255) NOT NULL,
+    order_id VARCHAR(255) NOT NULL,
+    transaction_status VARCHAR(50) NOT NULL,
+    status_code VARCHAR(10) NOT NULL DEFAULT '',
+    fraud_status VARCHAR(50) NOT NULL DEFAULT '',
+    gross_amount VARCHAR(50) NOT NULL DEFAULT '',
+    payload JSONB,
+    delivery_count INTEGER NOT NULL DEFAULT 1,
+    processed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (transaction_id, transaction_status)
+);
+
+CREATE INDEX IF NOT EXISTS idx_midtrans_callbacks_order_id ON midtrans_callbacks(order_id);
+
+-- Tabel refunds: pengembalian dana penuh atau sebagian untuk transaksi yang sudah sukses.
+-- Status transaksi menjadi partially_refunded atau refunded sesuai total refund yang berhasil.
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    refund_key VARCHAR(255) NOT NULL UNIQUE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_transaction_id ON refunds(transaction_id);
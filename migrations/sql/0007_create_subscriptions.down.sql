@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS subscription_periods;
+DROP TABLE IF EXISTS plan_entitlements;
+DROP TABLE IF EXISTS plans;
@@ -0,0 +1,38 @@
+-- Tabel plans: produk yang dijual sebagai langganan
+CREATE TABLE IF NOT EXISTS plans (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL UNIQUE REFERENCES products(id) ON DELETE CASCADE,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    duration_days INTEGER NOT NULL CHECK (duration_days > 0),
+    grace_days INTEGER NOT NULL DEFAULT 3 CHECK (grace_days >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel plan_entitlements: fitur premium yang dibuka oleh sebuah plan
+CREATE TABLE IF NOT EXISTS plan_entitlements (
+    plan_id INTEGER NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+    entitlement VARCHAR(64) NOT NULL,
+    PRIMARY KEY (plan_id, entitlement)
+);
+
+-- Tabel subscription_periods: satu periode langganan per transaksi sukses
+CREATE TABLE IF NOT EXISTS subscription_periods (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_id INTEGER NOT NULL REFERENCES plans(id),
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    grace_until TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_periods_user_id ON subscription_periods(user_id, grace_until);
+
+-- Contoh: produk id 1 sebagai langganan bulanan premium
+-- INSERT INTO plans (product_id, code, name, duration_days, grace_days) VALUES (1, 'premium_monthly', 'Premium Bulanan', 30, 3);
+-- INSERT INTO plan_entitlements (plan_id, entitlement) VALUES (1, 'ai_coach'), (1, 'journal_ai'), (1, 'article_generation');
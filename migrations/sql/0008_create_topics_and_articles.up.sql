@@ -0,0 +1,21 @@
+-- Tabel topics: topik bacaan yang dipilih user, dipakai untuk membuat artikel
+CREATE TABLE IF NOT EXISTS topics (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    preference TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_topics_user_id ON topics(user_id);
+
+-- Tabel articles: artikel yang dibuat AI dari sebuah topik
+CREATE TABLE IF NOT EXISTS articles (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    topic_id INTEGER NOT NULL REFERENCES topics(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_articles_topic_id ON articles(topic_id);
+CREATE INDEX IF NOT EXISTS idx_articles_created_at ON articles(created_at);
@@ -0,0 +1,32 @@
+-- Tabel user_goals: target membaca user. articles_to_read berisi id artikel yang harus dibaca.
+CREATE TABLE IF NOT EXISTS user_goals (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    task TEXT NOT NULL DEFAULT '',
+    articles_to_read BIGINT[] NOT NULL DEFAULT '{}',
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_goals_user_id ON user_goals(user_id, created_at);
+
+-- Tabel user_goals_progress: status baca setiap artikel dalam sebuah goal.
+-- id_article sengaja tanpa foreign key, sama seperti articles_to_read.
+CREATE TABLE IF NOT EXISTS user_goals_progress (
+    id SERIAL PRIMARY KEY,
+    id_goals INTEGER NOT NULL REFERENCES user_goals(id) ON DELETE CASCADE,
+    id_article BIGINT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    date_completed TIMESTAMP
+);
+
+-- Satu baris progress per artikel dalam goal, dipakai oleh ON CONFLICT saat progress diperbarui.
+-- Duplikat dari sebelum index ini ada dibuang.
+DELETE FROM user_goals_progress p
+USING user_goals_progress newer
+WHERE p.id_goals = newer.id_goals
+  AND p.id_article = newer.id_article
+  AND p.ctid < newer.ctid;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_goals_progress_article ON user_goals_progress(id_goals, id_article);
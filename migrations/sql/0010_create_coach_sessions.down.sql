@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS coach_messages;
+DROP TABLE IF EXISTS conversation_contexts;
+DROP TABLE IF EXISTS coach_sessions;
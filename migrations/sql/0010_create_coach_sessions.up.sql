@@ -0,0 +1,72 @@
+-- Tabel sesi AI coach. Baris coach_sessions menyimpan metadata sesi beserta
+-- pasangan pesan terakhir; seluruh percakapan disimpan per pesan di coach_messages.
+CREATE TABLE IF NOT EXISTS coach_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id VARCHAR(36) NOT NULL UNIQUE,
+    timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_input TEXT,
+    ai_response TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Kolom metadata untuk daftar sesi milik user
+ALTER TABLE coach_sessions ADD COLUMN IF NOT EXISTS title VARCHAR(120) NOT NULL DEFAULT '';
+ALTER TABLE coach_sessions ADD COLUMN IF NOT EXISTS message_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE coach_sessions ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP;
+ALTER TABLE coach_sessions ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE coach_sessions ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_coach_sessions_user_activity ON coach_sessions(user_id, archived, pinned DESC, last_activity_at DESC);
+
+-- Konteks percakapan (pesan, ringkasan dan metadata sesi) dalam bentuk JSON
+CREATE TABLE IF NOT EXISTS conversation_contexts (
+    session_id VARCHAR(36) PRIMARY KEY,
+    context JSONB NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel coach_messages: satu baris per pesan user atau jawaban AI
+CREATE TABLE IF NOT EXISTS coach_messages (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('user', 'assistant')),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coach_messages_session ON coach_messages(session_id, id);
+
+-- Pindahkan riwayat sesi lama dari JSON konteks ke coach_messages (hanya untuk sesi yang belum punya baris)
+INSERT INTO coach_messages (session_id, user_id, role, content, created_at)
+SELECT s.session_id, s.user_id, m.message->>'role', m.message->>'content', COALESCE(cc.updated_at, s.timestamp)
+FROM coach_sessions s
+JOIN conversation_contexts cc ON cc.session_id = s.session_id
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(cc.context::jsonb->'messages') = 'array' THEN cc.context::jsonb->'messages' ELSE '[]'::jsonb END
+) WITH ORDINALITY AS m(message, position)
+WHERE m.message->>'role' IN ('user', 'assistant')
+  AND NOT EXISTS (SELECT 1 FROM coach_messages cm WHERE cm.session_id = s.session_id)
+ORDER BY s.id, m.position;
+
+-- Isi metadata sesi lama
+UPDATE coach_sessions s
+SET message_count = (SELECT COUNT(*) FROM coach_messages cm WHERE cm.session_id = s.session_id)
+WHERE s.message_count = 0;
+
+UPDATE coach_sessions
+SET last_activity_at = COALESCE(updated_at, timestamp)
+WHERE last_activity_at IS NULL;
+
+-- Judul sesi lama diambil dari pesan pertama user
+UPDATE coach_sessions s
+SET title = LEFT(REGEXP_REPLACE(TRIM(first_message.content), '\s+', ' ', 'g'), 60)
+FROM (
+    SELECT DISTINCT ON (session_id) session_id, content
+    FROM coach_messages
+    WHERE role = 'user'
+    ORDER BY session_id, id
+) first_message
+WHERE first_message.session_id = s.session_id AND s.title = '';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS coach_preferences;
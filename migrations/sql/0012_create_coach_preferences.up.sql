@@ -0,0 +1,8 @@
+-- Tabel coach_preferences: pengaturan AI coach per user.
+-- personal_context_enabled bersifat opt-in: mood, emosi dominan dan goal aktif baru
+-- dikirim ke AI setelah user mengaktifkannya.
+CREATE TABLE IF NOT EXISTS coach_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    personal_context_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
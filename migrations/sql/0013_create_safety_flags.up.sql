@@ -0,0 +1,25 @@
+-- Tabel safety_flags: antrean review admin untuk pesan coach, jawaban AI, jurnal atau
+-- analisis jurnal yang terdeteksi berisiko krisis / menyakiti diri.
+-- session_id diisi untuk sumber coach, journal_id untuk sumber jurnal. Keduanya sengaja tanpa
+-- foreign key agar flag tetap tersimpan walaupun sesi atau jurnalnya dihapus.
+CREATE TABLE IF NOT EXISTS safety_flags (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source VARCHAR(30) NOT NULL,
+    session_id VARCHAR(255),
+    journal_id INTEGER,
+    risk_level VARCHAR(20) NOT NULL,
+    categories TEXT[] NOT NULL DEFAULT '{}',
+    matched_keywords TEXT[] NOT NULL DEFAULT '{}',
+    method VARCHAR(20) NOT NULL,
+    excerpt TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_safety_flags_status ON safety_flags(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_safety_flags_user_id ON safety_flags(user_id);
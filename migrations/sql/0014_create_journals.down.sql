@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS trend_analyses;
+DROP TABLE IF EXISTS journal_analyses;
+DROP TABLE IF EXISTS journals;
@@ -0,0 +1,51 @@
+-- Tabel journals
+CREATE TABLE IF NOT EXISTS journals (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    judul TEXT NOT NULL DEFAULT '',
+    isi TEXT NOT NULL DEFAULT '',
+    perasaan VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_journals_user_id ON journals(user_id);
+
+-- Tabel journal_analyses: hasil analisis AI per jurnal.
+-- emotions, keywords dan themes berisi JSON array dalam bentuk teks.
+CREATE TABLE IF NOT EXISTS journal_analyses (
+    id SERIAL PRIMARY KEY,
+    journal_id INTEGER NOT NULL REFERENCES journals(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sentiment_score REAL NOT NULL DEFAULT 0,
+    emotions TEXT NOT NULL DEFAULT '[]',
+    keywords TEXT NOT NULL DEFAULT '[]',
+    themes TEXT NOT NULL DEFAULT '[]',
+    insights TEXT NOT NULL DEFAULT '',
+    recommendations TEXT NOT NULL DEFAULT '',
+    analyzed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel trend_analyses: ringkasan trend mood per periode
+CREATE TABLE IF NOT EXISTS trend_analyses (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    period_type VARCHAR(50) NOT NULL, -- 'weekly', 'monthly' atau 'custom'
+    average_sentiment REAL NOT NULL DEFAULT 0,
+    top_emotions TEXT NOT NULL DEFAULT '[]',
+    key_themes TEXT NOT NULL DEFAULT '[]',
+    mood_trend VARCHAR(20) NOT NULL DEFAULT 'stable',
+    trend_insights TEXT NOT NULL DEFAULT '',
+    progress_notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_analyses_user_id ON journal_analyses(user_id);
+CREATE INDEX IF NOT EXISTS idx_journal_analyses_journal_id ON journal_analyses(journal_id);
+CREATE INDEX IF NOT EXISTS idx_trend_analyses_user_id ON trend_analyses(user_id);
+CREATE INDEX IF NOT EXISTS idx_journal_analyses_analyzed_at ON journal_analyses(analyzed_at);
@@ -0,0 +1,3 @@
+-- Kolom yang ditambahkan atau diganti nama tetap dipakai aplikasi, jadi tidak dibalik.
+-- Hanya kolom yang dibuang yang dikembalikan (tanpa isinya).
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS sentiment_label VARCHAR(50);
@@ -0,0 +1,44 @@
+-- Menyesuaikan database yang dibuat dari schema_journal.sql lama dengan kolom yang dipakai aplikasi.
+-- Di database baru semua perintah di bawah tidak mengubah apa pun.
+
+-- journals: kolom title/content/feeling diganti nama menjadi judul/isi/perasaan
+DO $$
+DECLARE
+    renames TEXT[][] := ARRAY[['title', 'judul'], ['content', 'isi'], ['feeling', 'perasaan']];
+    i INTEGER;
+BEGIN
+    FOR i IN 1 .. array_length(renames, 1) LOOP
+        IF EXISTS (
+            SELECT 1 FROM information_schema.columns
+            WHERE table_schema = current_schema() AND table_name = 'journals' AND column_name = renames[i][1]
+        ) AND NOT EXISTS (
+            SELECT 1 FROM information_schema.columns
+            WHERE table_schema = current_schema() AND table_name = 'journals' AND column_name = renames[i][2]
+        ) THEN
+            EXECUTE format('ALTER TABLE journals RENAME COLUMN %I TO %I', renames[i][1], renames[i][2]);
+        END IF;
+    END LOOP;
+END $$;
+
+ALTER TABLE journals ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE journals SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE journals ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+
+-- journal_analyses: kolom hasil analisis AI; sentiment_label tidak pernah diisi aplikasi
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS emotions TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS keywords TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS themes TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS insights TEXT NOT NULL DEFAULT '';
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS recommendations TEXT NOT NULL DEFAULT '';
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE journal_analyses ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE journal_analyses DROP COLUMN IF EXISTS sentiment_label;
+CREATE INDEX IF NOT EXISTS idx_journal_analyses_journal_id ON journal_analyses(journal_id);
+
+-- trend_analyses: kolom hasil trend; entry_count lama dibiarkan karena tidak mengganggu
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS top_emotions TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS key_themes TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS mood_trend VARCHAR(20) NOT NULL DEFAULT 'stable';
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS trend_insights TEXT NOT NULL DEFAULT '';
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS progress_notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,24 @@
+-- Antrean analisis AI jurnal. Satu baris per jurnal; membuat atau mengubah jurnal
+-- memasukkan baris yang sama ke antrean lagi dengan status 'queued'.
+CREATE TABLE IF NOT EXISTS journal_analysis_jobs (
+    id SERIAL PRIMARY KEY,
+    journal_id INTEGER NOT NULL UNIQUE REFERENCES journals(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    last_error TEXT,
+    crisis BOOLEAN NOT NULL DEFAULT FALSE,
+    -- rerun diisi jika jurnal berubah saat job sedang berjalan, job lalu diantrekan lagi setelah selesai
+    rerun BOOLEAN NOT NULL DEFAULT FALSE,
+    -- lease naik setiap kali job diambil worker; hasil dari worker dengan lease lama diabaikan
+    lease INTEGER NOT NULL DEFAULT 0,
+    run_after TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Worker mengambil job 'queued' yang sudah waktunya, atau job 'running' yang worker-nya berhenti
+CREATE INDEX IF NOT EXISTS idx_journal_analysis_jobs_due ON journal_analysis_jobs(status, run_after);
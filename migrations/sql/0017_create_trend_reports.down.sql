@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS trend_report_preferences;
+DROP INDEX IF EXISTS uq_trend_analyses_period;
+ALTER TABLE trend_analyses DROP COLUMN IF EXISTS emailed_at;
+ALTER TABLE trend_analyses DROP COLUMN IF EXISTS digest;
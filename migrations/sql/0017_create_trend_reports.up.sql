@@ -0,0 +1,26 @@
+-- Laporan trend mingguan dan bulanan yang dibuat otomatis di akhir periode.
+-- digest adalah ringkasan dari AI; emailed_at diisi setelah laporan dikirim lewat email.
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS digest TEXT;
+ALTER TABLE trend_analyses ADD COLUMN IF NOT EXISTS emailed_at TIMESTAMP;
+
+-- Satu trend per user dan periode; trend yang dibuat ulang menimpa baris yang sama.
+-- Duplikat dari sebelum constraint ini ada dibuang, yang terbaru disimpan.
+DELETE FROM trend_analyses t
+USING trend_analyses newer
+WHERE t.user_id = newer.user_id
+  AND t.period_type = newer.period_type
+  AND t.period_start = newer.period_start
+  AND t.period_end = newer.period_end
+  AND t.id < newer.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_trend_analyses_period
+    ON trend_analyses(user_id, period_type, period_start, period_end);
+
+-- Pengaturan laporan trend per user. Email bersifat opt-in; timezone menentukan kapan
+-- minggu dan bulan user berakhir.
+CREATE TABLE IF NOT EXISTS trend_report_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
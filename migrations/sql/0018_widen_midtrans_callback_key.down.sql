@@ -0,0 +1,13 @@
+-- Kunci lama hanya bisa dipasang lagi jika tiap (transaction_id, transaction_status) tinggal satu baris,
+-- jadi hanya notifikasi pertama yang dipertahankan.
+ALTER TABLE midtrans_callbacks DROP CONSTRAINT IF EXISTS midtrans_callbacks_notification_key;
+
+DELETE FROM midtrans_callbacks c
+USING midtrans_callbacks first
+WHERE c.transaction_id = first.transaction_id
+  AND c.transaction_status = first.transaction_status
+  AND c.id > first.id;
+
+ALTER TABLE midtrans_callbacks DROP COLUMN IF EXISTS refund_key;
+ALTER TABLE midtrans_callbacks ADD CONSTRAINT midtrans_callbacks_transaction_id_transaction_status_key
+    UNIQUE (transaction_id, transaction_status);
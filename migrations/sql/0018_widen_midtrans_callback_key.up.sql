@@ -0,0 +1,8 @@
+-- Notifikasi Midtrans dengan transaction_status yang sama belum tentu notifikasi ulang:
+-- capture bisa berubah dari fraud_status challenge ke accept, dan setiap partial_refund
+-- menambah refund baru. Kunci unik diperluas agar perubahan tersebut tetap diproses.
+ALTER TABLE midtrans_callbacks ADD COLUMN IF NOT EXISTS refund_key VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE midtrans_callbacks DROP CONSTRAINT IF EXISTS midtrans_callbacks_transaction_id_transaction_status_key;
+ALTER TABLE midtrans_callbacks ADD CONSTRAINT midtrans_callbacks_notification_key
+    UNIQUE (transaction_id, transaction_status, fraud_status, status_code, gross_amount, refund_key);
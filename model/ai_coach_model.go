@@ -3,12 +3,64 @@ package model
 import "time"
 
 type CoachSession struct {
-	ID          int       `json:"id"`
-	UserID      int       `json:"user_id"`
-	SessionID   string    `json:"session_id"`
-	Timestamp   time.Time `json:"timestamp"`
-	UserInput   string    `json:"user_input"`
-	AIResponse  string    `json:"ai_response"`
+	ID             int       `json:"id"`
+	UserID         int       `json:"user_id"`
+	SessionID      string    `json:"session_id"`
+	Title          string    `json:"title"`
+	Timestamp      time.Time `json:"timestamp"`
+	UserInput      string    `json:"user_input"`  // pesan user terakhir
+	AIResponse     string    `json:"ai_response"` // jawaban AI terakhir
+	MessageCount   int       `json:"message_count"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	Archived       bool      `json:"archived"`
+	Pinned         bool      `json:"pinned"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CoachSessionFilter adalah filter daftar sesi milik user
+type CoachSessionFilter struct {
+	UserID   int
+	Archived *bool // nil berarti semua sesi
+	Page     int
+	Limit    int
+}
+
+type CoachSessionListResponse struct {
+	Sessions   []CoachSession `json:"sessions"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+// CoachSessionUpdateRequest mengubah judul atau flag sesi; field yang kosong tidak diubah
+type CoachSessionUpdateRequest struct {
+	Title    *string `json:"title" binding:"omitempty,max=120"`
+	Archived *bool   `json:"archived"`
+	Pinned   *bool   `json:"pinned"`
+}
+
+// CoachMessage adalah satu pesan yang tersimpan di coach_messages
+type CoachMessage struct {
+	ID        int64     `json:"id"`
+	SessionID string    `json:"session_id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CoachTranscript adalah isi lengkap sesi coach untuk diekspor
+type CoachTranscript struct {
+	SessionID  string                   `json:"session_id"`
+	Title      string                   `json:"title"`
+	Persona    string                   `json:"persona,omitempty"`
+	StartedAt  time.Time                `json:"started_at"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Messages   []CoachTranscriptMessage `json:"messages"`
+}
+
+type CoachTranscriptMessage struct {
+	Role      string     `json:"role"`
+	Speaker   string     `json:"speaker"`
+	Content   string     `json:"content"`
+	Timestamp *time.Time `json:"timestamp,omitempty"` // kosong jika pesan hanya ada di konteks JSON sesi lama
 }
 
 type ConversationContext struct {
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// AuthSession menyimpan sesi login beserta hash refresh token yang sedang aktif
+type AuthSession struct {
+	ID               int        `json:"id"`
+	SessionID        string     `json:"session_id"`
+	UserID           int        `json:"user_id"`
+	RefreshTokenHash string     `json:"-"`
+	UserAgent        string     `json:"user_agent"`
+	IPAddress        string     `json:"ip_address"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
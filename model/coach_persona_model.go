@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+)
+
+// Status persona coach, persona yang diarsipkan tidak bisa dipilih untuk sesi baru
+const (
+	CoachPersonaStatusActive   = "active"
+	CoachPersonaStatusArchived = "archived"
+)
+
+// Key di ConversationContext.Metadata untuk persona yang dipakai sebuah sesi
+const MetadataPersonaID = "persona_id"
+
+// CoachPersona adalah gaya coach yang bisa dipilih user saat memulai sesi
+type CoachPersona struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	SystemPrompt string    `json:"system_prompt"`
+	Temperature  float64   `json:"temperature"`
+	MaxTokens    int       `json:"max_tokens"`
+	Language     string    `json:"language"` // kode bahasa jawaban, misalnya "id" atau "en"; kosong mengikuti bahasa user
+	IsDefault    bool      `json:"is_default"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CoachPersonaRequest adalah body create dan update persona. Saat update, temperature,
+// max_tokens, is_default dan status yang tidak dikirim mengikuti nilai persona yang lama.
+type CoachPersonaRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Description  string   `json:"description"`
+	SystemPrompt string   `json:"system_prompt" binding:"required"`
+	Temperature  *float64 `json:"temperature" binding:"omitempty,gte=0,lte=2"` // kosong berarti 0.7
+	MaxTokens    int      `json:"max_tokens" binding:"gte=0"`                  // 0 berarti 500
+	Language     string   `json:"language"`
+	IsDefault    *bool    `json:"is_default"`
+	Status       string   `json:"status"`
+}
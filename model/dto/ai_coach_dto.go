@@ -4,6 +4,7 @@ import "pijar/model"
 
 type CoachRequest struct {
 	UserInput string `json:"user_input"`
+	PersonaID int    `json:"persona_id"` // opsional, kosong berarti persona default
 }
 
 type StartSessionResponse struct {
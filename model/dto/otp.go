@@ -1,11 +1,28 @@
 package dto
 
 type RegisterRequest struct {
-    Email    string `json:"email" binding:"required,email"`
-    Password string `json:"password" binding:"required"`
+    Email     string `json:"email" binding:"required,email"`
+    Password  string `json:"password" binding:"required"`
+    Name      string `json:"name"`
+    BirthYear int    `json:"birth_year"`
+    Phone     string `json:"phone"`
+}
+
+type ResendOTPRequest struct {
+    Email string `json:"email" binding:"required,email"`
 }
 
 type VerifyOTPRequest struct {
     Email  string `json:"email" binding:"required,email"`
     OTP    string `json:"otp" binding:"required"`
 }
+
+type ForgotPasswordRequest struct {
+    Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+    Email       string `json:"email" binding:"required,email"`
+    Code        string `json:"code" binding:"required"`
+    NewPassword string `json:"new_password" binding:"required"`
+}
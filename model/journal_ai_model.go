@@ -18,13 +18,15 @@ type JournalAnalysis struct {
 	AnalyzedAt      time.Time `json:"analyzed_at"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+	// JournalCreatedAt adalah waktu jurnal ditulis, hanya diisi GetByUserIDBetween untuk trend
+	JournalCreatedAt time.Time `json:"-"`
 }
 
 // TrendAnalysis untuk analisis trend jangka panjang
 type TrendAnalysis struct {
 	ID               int      `json:"id" gorm:"primaryKey"`
 	UserID           int      `json:"user_id"`
-	PeriodType       string    `json:"period_type"` // "weekly", "monthly"
+	PeriodType       string    `json:"period_type"` // "weekly", "monthly", "custom"
 	PeriodStart      time.Time `json:"period_start"`
 	PeriodEnd        time.Time `json:"period_end"`
 	AverageSentiment float64   `json:"average_sentiment"`
@@ -33,10 +35,23 @@ type TrendAnalysis struct {
 	MoodTrend        string    `json:"mood_trend"`   // "improving", "declining", "stable"
 	TrendInsights    string    `json:"trend_insights"`
 	ProgressNotes    string    `json:"progress_notes"`
+	Digest           string     `json:"digest,omitempty"`     // ringkasan AI untuk laporan otomatis
+	EmailedAt        *time.Time `json:"emailed_at,omitempty"` // diisi jika laporan sudah dikirim lewat email
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
 
+// TrendReportSettings adalah pengaturan laporan trend otomatis milik user
+type TrendReportSettings struct {
+	EmailEnabled bool   `json:"email_enabled"`
+	Timezone     string `json:"timezone"` // zona waktu IANA untuk menentukan akhir minggu dan bulan
+}
+
+type TrendReportSettingsRequest struct {
+	EmailEnabled *bool  `json:"email_enabled" binding:"required"`
+	Timezone     string `json:"timezone"` // kosong berarti tidak diubah
+}
+
 // AnalysisRequest untuk request analisis
 type AnalysisRequest struct {
 	JournalID int   `json:"journal_id"`
@@ -51,13 +66,18 @@ type AnalysisResponse struct {
 	JournalAnalysis *JournalAnalysis `json:"analysis"`
 	Summary         string           `json:"summary"`
 	ActionItems     []string         `json:"action_items"`
+	CrisisSupport   *CrisisSupport   `json:"crisis_support,omitempty"` // diisi jika jurnal terdeteksi berisiko tinggi
 }
 
 // TrendRequest untuk request trend analysis
 type TrendRequest struct {
 	UserID     int   `json:"user_id"`
-	PeriodType string `json:"period_type"` // "weekly", "monthly"
-	Days       int    `json:"days"`        // berapa hari ke belakang
+	PeriodType string `json:"period_type"` // "weekly", "monthly", "custom"
+	Timezone   string `json:"timezone"`    // zona waktu IANA user, misalnya "Asia/Jakarta"
+	Date       string `json:"date"`        // YYYY-MM-DD, weekly/monthly: minggu atau bulan yang berisi tanggal ini (default hari ini)
+	StartDate  string `json:"start_date"`  // YYYY-MM-DD, awal periode custom
+	EndDate    string `json:"end_date"`    // YYYY-MM-DD, akhir periode custom (inklusif)
+	Days       int    `json:"days"`        // custom tanpa start_date/end_date: berapa hari ke belakang termasuk hari ini
 }
 
 // TrendResponse untuk response trend analysis
@@ -67,3 +87,45 @@ type TrendResponse struct {
 	Recommendations []string               `json:"recommendations"`
 	Charts          map[string]interface{} `json:"charts"` // Data untuk chart visualization
 }
+
+// Status job analisis jurnal di antrean
+const (
+	JournalAnalysisQueued  = "queued"
+	JournalAnalysisRunning = "running"
+	JournalAnalysisDone    = "done"
+	JournalAnalysisFailed  = "failed"
+)
+
+// JournalAnalysisJob adalah antrean analisis AI untuk satu jurnal. Setiap jurnal hanya punya
+// satu job; membuat atau mengubah jurnal memasukkan job yang sama ke antrean lagi.
+type JournalAnalysisJob struct {
+	ID          int        `json:"-"`
+	JournalID   int        `json:"journal_id"`
+	UserID      int        `json:"user_id"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	Crisis      bool       `json:"-"`
+	Lease       int        `json:"-"` // naik setiap kali job diambil worker, agar worker lama tidak menimpa hasil
+	RunAfter    time.Time  `json:"next_attempt_at"`
+	LockedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JournalAnalysisJobResult adalah hasil satu percobaan job yang dicatat oleh worker
+type JournalAnalysisJobResult struct {
+	Status     string        // queued (dicoba lagi), done atau failed
+	Attempts   int
+	RetryDelay time.Duration // jeda sebelum percobaan berikutnya jika Status queued
+	Error      string
+	Crisis     bool
+}
+
+// JournalAnalysisStatus untuk response status analisis per jurnal
+type JournalAnalysisStatus struct {
+	JournalAnalysisJob
+	Analysis *AnalysisResponse `json:"analysis,omitempty"` // diisi jika status done
+}
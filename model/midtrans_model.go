@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Item struct {
 	ID                  string `json:"id"`
 	Name                string `json:"name"`
@@ -44,9 +46,47 @@ type MidtransCallbackRequest struct {
 	GrossAmount       string `json:"gross_amount"`
 	FraudStatus       string `json:"fraud_status"`
 	Currency          string `json:"currency"`
+	// RefundAmount dan Refunds hanya ada pada notifikasi refund dan partial_refund
+	RefundAmount string                       `json:"refund_amount,omitempty"`
+	Refunds      []MidtransRefundNotification `json:"refunds,omitempty"`
+}
+
+// MidtransRefundNotification adalah satu refund pada notifikasi refund Midtrans
+type MidtransRefundNotification struct {
+	RefundKey    string `json:"refund_key"`
+	RefundAmount string `json:"refund_amount"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// LatestRefundKey mengembalikan refund_key refund terakhir, kosong untuk notifikasi selain refund.
+// Setiap partial_refund menambah satu refund, sehingga refund_key ini membedakan notifikasinya.
+func (c MidtransCallbackRequest) LatestRefundKey() string {
+	if len(c.Refunds) == 0 {
+		return ""
+	}
+	return c.Refunds[len(c.Refunds)-1].RefundKey
+}
+
+// MidtransCallbackLog adalah catatan setiap notifikasi Midtrans yang diterima, unik per
+// (transaction_id, transaction_status, fraud_status, status_code, gross_amount, refund_key)
+// agar notifikasi ulang bisa dikenali tanpa membuang perubahan status yang sebenarnya
+type MidtransCallbackLog struct {
+	ID                int        `json:"id"`
+	TransactionID     string     `json:"transaction_id"`
+	OrderID           string     `json:"order_id"`
+	TransactionStatus string     `json:"transaction_status"`
+	StatusCode        string     `json:"status_code"`
+	FraudStatus       string     `json:"fraud_status"`
+	GrossAmount       string     `json:"gross_amount"`
+	RefundKey         string     `json:"refund_key,omitempty"`
+	Payload           []byte     `json:"-"`
+	ProcessedAt       *time.Time `json:"processed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
 }
 
+// PaymentRequest adalah body pembuatan pembayaran. UserID tidak dibaca dari body,
+// controller mengisinya dari token agar transaksi selalu milik user yang login.
 type PaymentRequest struct {
-	UserID    int `json:"user_id"`
+	UserID    int `json:"-"`
 	ProductID int `json:"product_id"`
 }
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestLatestRefundKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		callback MidtransCallbackRequest
+		want     string
+	}{
+		{
+			name:     "payment notification has no refund key",
+			callback: MidtransCallbackRequest{TransactionStatus: "settlement"},
+			want:     "",
+		},
+		{
+			name: "first partial refund",
+			callback: MidtransCallbackRequest{
+				TransactionStatus: "partial_refund",
+				Refunds:           []MidtransRefundNotification{{RefundKey: "ORDER-1-R1", RefundAmount: "3000.00"}},
+			},
+			want: "ORDER-1-R1",
+		},
+		{
+			name: "second partial refund uses the newest refund",
+			callback: MidtransCallbackRequest{
+				TransactionStatus: "partial_refund",
+				Refunds: []MidtransRefundNotification{
+					{RefundKey: "ORDER-1-R1", RefundAmount: "3000.00"},
+					{RefundKey: "ORDER-1-R2", RefundAmount: "3000.00"},
+				},
+			},
+			want: "ORDER-1-R2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.callback.LatestRefundKey(); got != tt.want {
+				t.Errorf("LatestRefundKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,19 +2,19 @@ package model
 
 import "time"
 
+// Tujuan penggunaan OTP, satu email bisa punya OTP aktif untuk tiap tujuan
+const (
+    OTPPurposeRegistration  = "registration"
+    OTPPurposePasswordReset = "password_reset"
+)
+
 type OTP struct {
     ID        int       `json:"id" gorm:"primaryKey"`
     Email     string    `json:"email"`
-    Code      string    `json:"code"`
+    Code      string    `json:"-"` // hash bcrypt dari kode OTP
+    Purpose   string    `json:"purpose"`
     ExpiresAt time.Time `json:"expires_at"`
     Attempts  int       `json:"attempts"`
     CreatedAt time.Time `json:"created_at"`
     UpdatedAt time.Time `json:"updated_at"`
 }
-
-// OTPRepository interface untuk repository OTP
-type OTPRepository interface {
-    SaveOTP(otp *OTP) error
-    GetOTPByCode(code string) (*OTP, error)
-    DeleteExpiredOTPs() error
-}
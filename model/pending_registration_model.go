@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PendingRegistration menyimpan data registrasi yang menunggu verifikasi OTP
+type PendingRegistration struct {
+	ID           int       `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	BirthYear    int       `json:"birth_year"`
+	Phone        string    `json:"phone"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+// Key di ConversationContext.Metadata untuk profil terakhir yang dikirim ke AI pada sebuah sesi
+const MetadataPersonalContext = "personal_context"
+
+// PersonalContext adalah profil ringkas user yang dikirim ke AI coach jika user mengaktifkannya.
+// Isi jurnal, judul, kata kunci dan tema sengaja tidak disertakan.
+type PersonalContext struct {
+	MoodTrend        string                `json:"mood_trend,omitempty"` // "improving", "declining" atau "stable"
+	AverageSentiment *float64              `json:"average_sentiment,omitempty"`
+	TopEmotions      []string              `json:"top_emotions"`
+	ActiveGoals      []PersonalContextGoal `json:"active_goals"`
+	GeneratedAt      time.Time             `json:"generated_at"`
+}
+
+type PersonalContextGoal struct {
+	Title             string `json:"title"`
+	CompletedArticles int    `json:"completed_articles"`
+	TotalArticles     int    `json:"total_articles"`
+}
+
+// IsEmpty bernilai true jika tidak ada data yang bisa dibagikan
+func (p PersonalContext) IsEmpty() bool {
+	return p.MoodTrend == "" && p.AverageSentiment == nil && len(p.TopEmotions) == 0 && len(p.ActiveGoals) == 0
+}
+
+// PersonalContextSettings adalah status opt-in user beserta profil yang akan dibagikan saat ini
+type PersonalContextSettings struct {
+	Enabled bool             `json:"enabled"`
+	Preview *PersonalContext `json:"preview"`
+}
+
+type PersonalContextRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
@@ -4,11 +4,30 @@ import (
 	"time"
 )
 
+// Status produk, produk yang diarsipkan tidak bisa dibeli lagi
+const (
+	ProductStatusActive   = "active"
+	ProductStatusArchived = "archived"
+)
+
 type Product struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       int       `json:"price"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       int    `json:"price"`
+	Currency    string `json:"currency"`
+	// MonthlySubscription adalah interval tagihan dalam bulan, 0 berarti pembelian sekali bayar
+	MonthlySubscription int       `json:"monthly_subscription"`
+	Status              string    `json:"status"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type ProductRequest struct {
+	Name                string `json:"name" binding:"required"`
+	Description         string `json:"description"`
+	Price               int    `json:"price" binding:"required,gt=0"`
+	Currency            string `json:"currency"`
+	MonthlySubscription int    `json:"monthly_subscription" binding:"gte=0"`
+	Status              string `json:"status"`
 }
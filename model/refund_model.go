@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// Status refund
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund adalah satu pengembalian dana (penuh atau sebagian) untuk sebuah transaksi
+type Refund struct {
+	ID            int       `json:"id"`
+	TransactionID int       `json:"transaction_id"`
+	RefundKey     string    `json:"refund_key"`
+	Amount        int       `json:"amount"`
+	Reason        string    `json:"reason"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type RefundRequest struct {
+	Amount int    `json:"amount" binding:"required,gt=0"`
+	Reason string `json:"reason" binding:"required"`
+}
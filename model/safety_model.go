@@ -0,0 +1,91 @@
+package model
+
+import (
+	"time"
+)
+
+// Tingkat risiko hasil pemeriksaan keamanan
+const (
+	SafetyRiskNone     = "none"
+	SafetyRiskElevated = "elevated" // perlu ditinjau, percakapan tetap dilanjutkan
+	SafetyRiskHigh     = "high"     // AI tidak dipakai, user mendapat respons krisis
+)
+
+// Sumber teks yang diperiksa
+const (
+	SafetySourceCoachInput      = "coach_input"
+	SafetySourceCoachOutput     = "coach_output"
+	SafetySourceJournal         = "journal"
+	SafetySourceJournalAnalysis = "journal_analysis"
+)
+
+// Status flag di antrean review admin
+const (
+	SafetyFlagStatusOpen      = "open"
+	SafetyFlagStatusReviewed  = "reviewed"
+	SafetyFlagStatusDismissed = "dismissed"
+)
+
+// SafetyAssessment adalah hasil klasifikasi risiko sebuah teks
+type SafetyAssessment struct {
+	RiskLevel       string   `json:"risk_level"`
+	Categories      []string `json:"categories"`
+	MatchedKeywords []string `json:"matched_keywords"`
+	Method          string   `json:"method"` // "keyword" atau "llm"
+}
+
+// Flagged bernilai true jika teks perlu dicatat untuk ditinjau
+func (a SafetyAssessment) Flagged() bool {
+	return a.RiskLevel == SafetyRiskElevated || a.RiskLevel == SafetyRiskHigh
+}
+
+// SafetyCheck adalah teks yang akan diperiksa beserta asalnya
+type SafetyCheck struct {
+	UserID    int
+	Source    string
+	SessionID string
+	JournalID *int
+	Text      string
+}
+
+// SafetyFlag adalah catatan sesi atau jurnal yang terdeteksi berisiko, untuk ditinjau admin
+type SafetyFlag struct {
+	ID              int        `json:"id"`
+	UserID          int        `json:"user_id"`
+	Source          string     `json:"source"`
+	SessionID       string     `json:"session_id,omitempty"`
+	JournalID       *int       `json:"journal_id,omitempty"`
+	RiskLevel       string     `json:"risk_level"`
+	Categories      []string   `json:"categories"`
+	MatchedKeywords []string   `json:"matched_keywords"`
+	Method          string     `json:"method"`
+	Excerpt         string     `json:"excerpt"`
+	Status          string     `json:"status"`
+	ReviewedBy      *int       `json:"reviewed_by,omitempty"`
+	ReviewNote      string     `json:"review_note,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type SafetyFlagListResponse struct {
+	Flags      []SafetyFlag `json:"flags"`
+	Pagination Pagination   `json:"pagination"`
+}
+
+type SafetyFlagReviewRequest struct {
+	Status string `json:"status" binding:"required,oneof=reviewed dismissed"`
+	Note   string `json:"note"`
+}
+
+// Hotline adalah layanan bantuan yang ditampilkan pada respons krisis
+type Hotline struct {
+	Name    string `json:"name"`
+	Contact string `json:"contact"`
+}
+
+// CrisisSupport adalah pesan dan daftar layanan bantuan untuk user yang terdeteksi berisiko
+type CrisisSupport struct {
+	Message  string    `json:"message"`
+	Hotlines []Hotline `json:"hotlines"`
+}
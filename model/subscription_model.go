@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+)
+
+// Entitlement yang bisa diberikan oleh sebuah plan
+const (
+	EntitlementAICoach           = "ai_coach"
+	EntitlementJournalAI         = "journal_ai"
+	EntitlementArticleGeneration = "article_generation"
+)
+
+// Status periode langganan, dihitung dari waktu sekarang
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusGrace     = "grace"
+	SubscriptionStatusExpired   = "expired"
+	SubscriptionStatusScheduled = "scheduled"
+	SubscriptionStatusRevoked   = "revoked"
+)
+
+// Plan menghubungkan sebuah produk dengan entitlement dan durasi langganannya
+type Plan struct {
+	ID           int       `json:"id"`
+	ProductID    int       `json:"product_id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	DurationDays int       `json:"duration_days"`
+	GraceDays    int       `json:"grace_days"`
+	Entitlements []string  `json:"entitlements"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SubscriptionPeriod adalah satu periode langganan yang dibuat dari satu transaksi sukses
+type SubscriptionPeriod struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	PlanID        int        `json:"plan_id"`
+	PlanCode      string     `json:"plan_code"`
+	TransactionID int        `json:"transaction_id"`
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        time.Time  `json:"ends_at"`
+	GraceUntil    time.Time  `json:"grace_until"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// StatusAt menghitung status periode pada waktu tertentu
+func (p SubscriptionPeriod) StatusAt(now time.Time) string {
+	switch {
+	case p.RevokedAt != nil:
+		return SubscriptionStatusRevoked
+	case now.Before(p.StartsAt):
+		return SubscriptionStatusScheduled
+	case now.Before(p.EndsAt):
+		return SubscriptionStatusActive
+	case now.Before(p.GraceUntil):
+		return SubscriptionStatusGrace
+	default:
+		return SubscriptionStatusExpired
+	}
+}
+
+// UserEntitlement adalah entitlement aktif milik user beserta batas waktunya
+type UserEntitlement struct {
+	Entitlement string    `json:"entitlement"`
+	ActiveUntil time.Time `json:"active_until"`
+	GraceUntil  time.Time `json:"grace_until"`
+	InGrace     bool      `json:"in_grace"`
+}
@@ -4,6 +4,46 @@ import (
 	"time"
 )
 
+// Status transaksi
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusSuccess   = "success"
+	TransactionStatusFailed    = "failed"
+	TransactionStatusCancelled = "cancelled"
+
+	TransactionStatusPartiallyRefunded = "partially_refunded"
+	TransactionStatusRefunded          = "refunded"
+)
+
+// transactionTransitions adalah perpindahan status yang diperbolehkan. Status hanya bergerak
+// maju, sehingga notifikasi yang datang terlambat atau terkirim ulang tidak bisa
+// mengembalikan transaksi success ke pending.
+var transactionTransitions = map[string][]string{
+	TransactionStatusPending:           {TransactionStatusSuccess, TransactionStatusFailed, TransactionStatusCancelled},
+	TransactionStatusSuccess:           {TransactionStatusPartiallyRefunded, TransactionStatusRefunded},
+	TransactionStatusPartiallyRefunded: {TransactionStatusRefunded},
+}
+
+// IsValidTransactionStatus mengecek apakah status dikenal, dipakai untuk filter
+func IsValidTransactionStatus(status string) bool {
+	switch status {
+	case TransactionStatusPending, TransactionStatusSuccess, TransactionStatusFailed, TransactionStatusCancelled,
+		TransactionStatusPartiallyRefunded, TransactionStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo mengecek apakah status transaksi boleh berpindah dari from ke to
+func CanTransitionTo(from, to string) bool {
+	for _, allowed := range transactionTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Transaction struct {
 	ID         int       `json:"id"`
 	UserID     int       `json:"user_id"`
@@ -16,3 +56,28 @@ type Transaction struct {
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
+
+// TransactionFilter adalah filter untuk daftar transaksi. UserID 0 berarti semua user,
+// From inklusif dan To eksklusif.
+type TransactionFilter struct {
+	UserID int
+	Status string
+	From   *time.Time
+	To     *time.Time
+	Page   int
+	Limit  int
+}
+
+type TransactionListResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	Pagination   Pagination    `json:"pagination"`
+}
+
+// Receipt berisi data yang dicetak pada bukti pembayaran
+type Receipt struct {
+	Transaction    Transaction `json:"transaction"`
+	Product        Product     `json:"product"`
+	Customer       Users       `json:"customer"`
+	Refunds        []Refund    `json:"refunds"`
+	RefundedAmount int         `json:"refunded_amount"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "testing"
+
+func TestCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{TransactionStatusPending, TransactionStatusSuccess, true},
+		{TransactionStatusPending, TransactionStatusFailed, true},
+		{TransactionStatusPending, TransactionStatusCancelled, true},
+		{TransactionStatusPending, TransactionStatusRefunded, false},
+		{TransactionStatusSuccess, TransactionStatusPending, false},
+		{TransactionStatusSuccess, TransactionStatusFailed, false},
+		{TransactionStatusSuccess, TransactionStatusPartiallyRefunded, true},
+		{TransactionStatusSuccess, TransactionStatusRefunded, true},
+		{TransactionStatusPartiallyRefunded, TransactionStatusRefunded, true},
+		{TransactionStatusPartiallyRefunded, TransactionStatusSuccess, false},
+		{TransactionStatusRefunded, TransactionStatusPartiallyRefunded, false},
+		{TransactionStatusFailed, TransactionStatusSuccess, false},
+		{TransactionStatusCancelled, TransactionStatusPending, false},
+		{"unknown", TransactionStatusSuccess, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			if got := CanTransitionTo(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransitionTo(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
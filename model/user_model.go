@@ -18,6 +18,9 @@ type Users struct {
 }
 
 type AuthResponse struct {
-	Token string     `json:"token"`
-	User  Users      `json:"user"`
+	Token            string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             Users     `json:"user"`
 }
@@ -12,21 +12,48 @@ import (
 
 type CoachSessionRepository interface {
 	// Session Management
-	CreateSession(c context.Context, userID int, input string) (string, error)
-	UpdateSessionResponse(c context.Context, sessionID string, response string) error
+	CreateSession(c context.Context, userID int, title string, input string) (string, error)
 	GetOrCreateConversationContext(c context.Context, userID int, sessionID string) (*model.ConversationContext, error)
 	SaveConversationContext(c context.Context, ctx *model.ConversationContext) error
 	SaveConversation(c context.Context, userID int, sessionID, userInput, aiResponse string) error
 	GetSessionHistory(c context.Context, userID int, sessionID string, limit int) ([]model.Message, error)
+	GetSessionMessages(c context.Context, userID int, sessionID string) ([]model.CoachMessage, error)
 	GetUserSessions(c context.Context, userID int) ([]model.CoachSession, error)
+	ListUserSessions(c context.Context, filter model.CoachSessionFilter) ([]model.CoachSession, int64, error)
+	GetSession(c context.Context, userID int, sessionID string) (model.CoachSession, error)
+	UpdateSession(c context.Context, userID int, sessionID string, req model.CoachSessionUpdateRequest) (model.CoachSession, error)
 	DeleteSession(c context.Context, userID int, sessionID string) error
 }
 
+const coachSessionColumns = `
+	id, user_id, session_id, COALESCE(title, ''), timestamp, COALESCE(user_input, ''), COALESCE(ai_response, ''),
+	message_count, COALESCE(last_activity_at, timestamp), archived, pinned, COALESCE(created_at, timestamp)
+`
+
+func scanCoachSession(row rowScanner) (model.CoachSession, error) {
+	var session model.CoachSession
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.SessionID,
+		&session.Title,
+		&session.Timestamp,
+		&session.UserInput,
+		&session.AIResponse,
+		&session.MessageCount,
+		&session.LastActivityAt,
+		&session.Archived,
+		&session.Pinned,
+		&session.CreatedAt,
+	)
+	return session, err
+}
+
 type coachSessionRepository struct {
 	db *sql.DB
 }
 
-func (r *coachSessionRepository) CreateSession(c context.Context, userID int, input string) (string, error) {
+func (r *coachSessionRepository) CreateSession(c context.Context, userID int, title string, input string) (string, error) {
 	// Cek apakah user ada
 	var exists bool
 	checkUserQuery := `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`
@@ -45,11 +72,11 @@ func (r *coachSessionRepository) CreateSession(c context.Context, userID int, in
 
 	// Input session ke database
 	query := `INSERT INTO coach_sessions 
-		  (user_id, session_id, timestamp, user_input) 
-		  VALUES ($1, $2, $3, $4) 
+		  (user_id, session_id, title, timestamp, user_input, last_activity_at, created_at, updated_at) 
+		  VALUES ($1, $2, $3, $4, $5, $4, $4, $4) 
 		  RETURNING session_id`
 
-	_, err = r.db.Exec(query, userID, sessionID, now, input)
+	_, err = r.db.Exec(query, userID, sessionID, title, now, input)
 	if err != nil {
 		return "", fmt.Errorf("gagal membuat sesi: %w", err)
 	}
@@ -57,14 +84,6 @@ func (r *coachSessionRepository) CreateSession(c context.Context, userID int, in
 	return sessionID, nil
 }
 
-func (r *coachSessionRepository) UpdateSessionResponse(c context.Context, sessionID string, response string) error {
-	query := `UPDATE coach_sessions 
-	         SET ai_response = $1, updated_at = $2 
-	         WHERE session_id = $3`
-	_, err := r.db.Exec(query, response, time.Now(), sessionID)
-	return err
-}
-
 func (r *coachSessionRepository) GetOrCreateConversationContext(c context.Context, userID int, sessionID string) (*model.ConversationContext, error) {
 	// Cek apakah session ada
 	var exists bool
@@ -107,9 +126,15 @@ func (r *coachSessionRepository) GetOrCreateConversationContext(c context.Contex
 }
 
 func (r *coachSessionRepository) SaveConversation(c context.Context, userID int, sessionID, userInput, aiResponse string) error {
+	tx, err := r.db.BeginTx(c, nil)
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Cek apakah sesi sudah ada
 	var exists bool
-	err := r.db.QueryRow(
+	err = tx.QueryRowContext(c,
 		"SELECT EXISTS(SELECT 1 FROM coach_sessions WHERE session_id = $1 AND user_id = $2)",
 		sessionID, userID,
 	).Scan(&exists)
@@ -117,32 +142,45 @@ func (r *coachSessionRepository) SaveConversation(c context.Context, userID int,
 		return fmt.Errorf("gagal memeriksa sesi: %w", err)
 	}
 
+	now := time.Now()
 	if exists {
-		// Update sesi yang sudah ada
+		// Pasangan pesan terakhir tetap disimpan di coach_sessions untuk ringkasan daftar sesi
 		query := `
 			UPDATE coach_sessions 
 			SET user_input = $1, 
 				ai_response = $2, 
 				timestamp = $3,
+				last_activity_at = $3,
+				message_count = message_count + 2,
 				updated_at = $3
 			WHERE session_id = $4 AND user_id = $5`
 
-		_, err = r.db.Exec(query, userInput, aiResponse, time.Now(), sessionID, userID)
+		_, err = tx.ExecContext(c, query, userInput, aiResponse, now, sessionID, userID)
 	} else {
 		// Buat sesi baru
 		query := `
 			INSERT INTO coach_sessions 
-			(user_id, session_id, user_input, ai_response, timestamp, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $6)`
+			(user_id, session_id, title, user_input, ai_response, timestamp, last_activity_at, message_count, created_at, updated_at)
+			VALUES ($1, $2, '', $3, $4, $5, $5, 2, $5, $5)`
 
-		now := time.Now()
-		_, err = r.db.Exec(query, userID, sessionID, userInput, aiResponse, now, now)
+		_, err = tx.ExecContext(c, query, userID, sessionID, userInput, aiResponse, now)
 	}
-
 	if err != nil {
 		return fmt.Errorf("gagal menyimpan percakapan: %w", err)
 	}
 
+	// Setiap pesan disimpan sebagai baris sendiri di coach_messages
+	messageQuery := `
+		INSERT INTO coach_messages (session_id, user_id, role, content, created_at)
+		VALUES ($1, $2, 'user', $3, $4), ($1, $2, 'assistant', $5, $4)`
+	if _, err := tx.ExecContext(c, messageQuery, sessionID, userID, userInput, now, aiResponse); err != nil {
+		return fmt.Errorf("gagal menyimpan pesan: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal menyimpan percakapan: %w", err)
+	}
+
 	return nil
 }
 
@@ -184,7 +222,37 @@ func (r *coachSessionRepository) GetSessionHistory(c context.Context, userID int
 		return nil, fmt.Errorf("sesi tidak ditemukan atau tidak dapat diakses")
 	}
 
-	// Ambil konteks percakapan
+	// Ambil pesan terakhir dari coach_messages, lalu urutkan kembali dari yang paling lama
+	query := `
+		SELECT role, content FROM (
+			SELECT id, role, content FROM coach_messages
+			WHERE session_id = $1
+			ORDER BY id DESC
+			LIMIT NULLIF($2, 0)
+		) recent
+		ORDER BY id`
+	rows, err := r.db.QueryContext(c, query, sessionID, max(limit, 0))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pesan: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []model.Message{}
+	for rows.Next() {
+		var msg model.Message
+		if err := rows.Scan(&msg.Role, &msg.Content); err != nil {
+			return nil, fmt.Errorf("gagal membaca pesan: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("gagal membaca pesan: %w", err)
+	}
+	if len(messages) > 0 {
+		return messages, nil
+	}
+
+	// Sesi lama yang belum punya baris di coach_messages dibaca dari konteks percakapan
 	var contextJSON []byte
 	err = r.db.QueryRow(
 		"SELECT context FROM conversation_contexts WHERE session_id = $1",
@@ -213,6 +281,34 @@ func (r *coachSessionRepository) GetSessionHistory(c context.Context, userID int
 	return ctx.Messages, nil
 }
 
+// GetSessionMessages mengembalikan semua pesan sesi dari coach_messages beserta waktunya
+func (r *coachSessionRepository) GetSessionMessages(c context.Context, userID int, sessionID string) ([]model.CoachMessage, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, session_id, role, content, created_at
+		FROM coach_messages
+		WHERE session_id = $1 AND user_id = $2
+		ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, sessionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pesan: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []model.CoachMessage{}
+	for rows.Next() {
+		var msg model.CoachMessage
+		if err := rows.Scan(&msg.ID, &msg.SessionID, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("gagal membaca pesan: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
 func (r *coachSessionRepository) GetUserSessions(c context.Context, userID int) ([]model.CoachSession, error) {
 	var sessions []model.CoachSession
 
@@ -229,7 +325,7 @@ func (r *coachSessionRepository) GetUserSessions(c context.Context, userID int)
 	}
 
 	// Query untuk mendapatkan semua sesi dari user
-	query := `SELECT id, user_id,session_id, timestamp, user_input, ai_response FROM coach_sessions WHERE user_id=$1 ORDER BY timestamp DESC`
+	query := `SELECT ` + coachSessionColumns + ` FROM coach_sessions WHERE user_id=$1 ORDER BY timestamp DESC`
 	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, err
@@ -238,15 +334,7 @@ func (r *coachSessionRepository) GetUserSessions(c context.Context, userID int)
 
 	// Iterasi hasil query
 	for rows.Next() {
-		var session model.CoachSession
-		err := rows.Scan(
-			&session.ID,
-			&session.UserID,
-			&session.SessionID,
-			&session.Timestamp,
-			&session.UserInput,
-			&session.AIResponse,
-		)
+		session, err := scanCoachSession(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -260,9 +348,80 @@ func (r *coachSessionRepository) GetUserSessions(c context.Context, userID int)
 	return sessions, nil
 }
 
+// ListUserSessions mengembalikan sesi milik user, yang di-pin lebih dulu lalu berdasarkan aktivitas terakhir
+func (r *coachSessionRepository) ListUserSessions(c context.Context, filter model.CoachSessionFilter) ([]model.CoachSession, int64, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	where := `WHERE user_id = $1 AND ($2::boolean IS NULL OR archived = $2)`
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM coach_sessions `+where, filter.UserID, filter.Archived).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("gagal menghitung sesi: %w", err)
+	}
+
+	query := `SELECT ` + coachSessionColumns + ` FROM coach_sessions ` + where + `
+		ORDER BY pinned DESC, COALESCE(last_activity_at, timestamp) DESC
+		LIMIT $3 OFFSET $4`
+	rows, err := r.db.QueryContext(ctx, query, filter.UserID, filter.Archived, filter.Limit, (filter.Page-1)*filter.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("gagal mengambil sesi: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []model.CoachSession{}
+	for rows.Next() {
+		session, err := scanCoachSession(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, total, rows.Err()
+}
+
+func (r *coachSessionRepository) GetSession(c context.Context, userID int, sessionID string) (model.CoachSession, error) {
+	query := `SELECT ` + coachSessionColumns + ` FROM coach_sessions WHERE session_id = $1 AND user_id = $2`
+	session, err := scanCoachSession(r.db.QueryRowContext(c, query, sessionID, userID))
+	if err == sql.ErrNoRows {
+		return model.CoachSession{}, fmt.Errorf("session not found or not owned by user")
+	}
+	if err != nil {
+		return model.CoachSession{}, err
+	}
+	return session, nil
+}
+
+// UpdateSession mengubah judul, arsip atau pin; field yang nil tidak diubah
+func (r *coachSessionRepository) UpdateSession(c context.Context, userID int, sessionID string, req model.CoachSessionUpdateRequest) (model.CoachSession, error) {
+	query := `
+		UPDATE coach_sessions
+		SET title = COALESCE($1, title),
+			archived = COALESCE($2, archived),
+			pinned = COALESCE($3, pinned),
+			updated_at = $4
+		WHERE session_id = $5 AND user_id = $6
+		RETURNING ` + coachSessionColumns
+	session, err := scanCoachSession(r.db.QueryRowContext(c, query, req.Title, req.Archived, req.Pinned, time.Now(), sessionID, userID))
+	if err == sql.ErrNoRows {
+		return model.CoachSession{}, fmt.Errorf("session not found or not owned by user")
+	}
+	if err != nil {
+		return model.CoachSession{}, fmt.Errorf("gagal memperbarui sesi: %w", err)
+	}
+	return session, nil
+}
+
 func (r *coachSessionRepository) DeleteSession(c context.Context, userID int, sessionID string) error {
+	tx, err := r.db.BeginTx(c, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM coach_sessions WHERE user_id = $1 AND session_id = $2 `
-	result, err := r.db.Exec(query, userID, sessionID)
+	result, err := tx.ExecContext(c, query, userID, sessionID)
 	if err != nil {
 		return err
 	}
@@ -277,7 +436,16 @@ func (r *coachSessionRepository) DeleteSession(c context.Context, userID int, se
 		return fmt.Errorf("session not found or not owned by user")
 	}
 
-	return nil
+	if _, err := tx.ExecContext(c, `DELETE FROM coach_messages WHERE session_id = $1`, sessionID); err != nil {
+		return err
+	}
+
+	// Ringkasan berjalan sesi ikut dihapus bersama pesannya
+	if _, err := tx.ExecContext(c, `DELETE FROM conversation_contexts WHERE session_id = $1`, sessionID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func NewSession(db *sql.DB) CoachSessionRepository {
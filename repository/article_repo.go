@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/http"
 	"pijar/model"
 	"pijar/utils/service"
 	"time"
@@ -28,12 +29,14 @@ type ArticleRepository interface {
 }
 
 type articleRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	generator service.ArticleGenerator
 }
 
-func NewArticleRepository(db *sql.DB) ArticleRepository {
+func NewArticleRepository(db *sql.DB, generator service.ArticleGenerator) ArticleRepository {
 	return &articleRepository{
-		db: db,
+		db:        db,
+		generator: generator,
 	}
 }
 
@@ -63,8 +66,8 @@ func (r *articleRepository) GenerateArticle(ctx context.Context, tx *sql.Tx, top
 	// Create a new context with topic_id for the article generation service
 	ginCtx, ok := ctx.(*gin.Context)
 	if !ok {
-		// If we can't get the gin context, create a new one
-		ginCtx = &gin.Context{}
+		// If we can't get the gin context, create a new one that still carries ctx for cancellation
+		ginCtx = &gin.Context{Request: (&http.Request{}).WithContext(ctx)}
 	}
 
 	// Set topic_id and user_id in the context
@@ -78,7 +81,7 @@ func (r *articleRepository) GenerateArticle(ctx context.Context, tx *sql.Tx, top
 	fmt.Printf("Generating articles for topic ID %d with preference: %s\n", topicID, preference)
 
 	// Generate articles using the service package
-	generatedArticles, err := service.GenerateArticles(ginCtx, preferences)
+	generatedArticles, err := r.generator.GenerateArticles(ginCtx, preferences)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate articles: %w", err)
 	}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+)
+
+// AuthSessionRepository adalah interface untuk repository sesi login (refresh token)
+type AuthSessionRepository interface {
+	CreateSession(session model.AuthSession) (model.AuthSession, error)
+	GetSessionByRefreshTokenHash(tokenHash string) (model.AuthSession, error)
+	RotateRefreshToken(sessionID string, oldTokenHash string, newTokenHash string, expiresAt time.Time) error
+	IsSessionActive(sessionID string) (bool, error)
+	RevokeSession(sessionID string) error
+	RevokeAllUserSessions(userID int) error
+	DeleteExpiredSessions() error
+}
+
+// authSessionRepository adalah implementasi dari AuthSessionRepository
+type authSessionRepository struct {
+	db *sql.DB
+}
+
+func NewAuthSessionRepository(db *sql.DB) AuthSessionRepository {
+	return &authSessionRepository{db: db}
+}
+
+func (r *authSessionRepository) CreateSession(session model.AuthSession) (model.AuthSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO auth_sessions
+		(session_id, user_id, refresh_token_hash, user_agent, ip_address, expires_at, last_used_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7)
+		RETURNING id
+	`
+
+	now := time.Now()
+	session.LastUsedAt = now
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		session.SessionID,
+		session.UserID,
+		session.RefreshTokenHash,
+		session.UserAgent,
+		session.IPAddress,
+		session.ExpiresAt,
+		now,
+	).Scan(&session.ID)
+	if err != nil {
+		return model.AuthSession{}, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *authSessionRepository) GetSessionByRefreshTokenHash(tokenHash string) (model.AuthSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, session_id, user_id, refresh_token_hash, user_agent, ip_address,
+		       expires_at, revoked_at, last_used_at, created_at, updated_at
+		FROM auth_sessions
+		WHERE refresh_token_hash = $1
+	`
+
+	var session model.AuthSession
+	var revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&session.ID,
+		&session.SessionID,
+		&session.UserID,
+		&session.RefreshTokenHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.ExpiresAt,
+		&revokedAt,
+		&session.LastUsedAt,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.AuthSession{}, errors.New("session not found")
+		}
+		return model.AuthSession{}, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken mengganti hash refresh token hanya jika token lama masih yang tersimpan,
+// sehingga dua request refresh yang bersamaan tidak bisa sama-sama berhasil
+func (r *authSessionRepository) RotateRefreshToken(sessionID string, oldTokenHash string, newTokenHash string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE auth_sessions
+		SET refresh_token_hash = $1, expires_at = $2, last_used_at = $3, updated_at = $3
+		WHERE session_id = $4 AND refresh_token_hash = $5 AND revoked_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, newTokenHash, expiresAt, time.Now(), sessionID, oldTokenHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("session not found or already rotated")
+	}
+
+	return nil
+}
+
+func (r *authSessionRepository) IsSessionActive(sessionID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var active bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM auth_sessions
+			WHERE session_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		)
+	`
+	err := r.db.QueryRowContext(ctx, query, sessionID, time.Now()).Scan(&active)
+	return active, err
+}
+
+func (r *authSessionRepository) RevokeSession(sessionID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE auth_sessions
+		SET revoked_at = $1, updated_at = $1
+		WHERE session_id = $2 AND revoked_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, time.Now(), sessionID)
+	return err
+}
+
+func (r *authSessionRepository) RevokeAllUserSessions(userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE auth_sessions
+		SET revoked_at = $1, updated_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, time.Now(), userID)
+	return err
+}
+
+// DeleteExpiredSessions menghapus sesi yang sudah kedaluwarsa atau dicabut.
+// Keduanya sudah ditolak IsSessionActive, jadi barisnya tidak diperlukan lagi.
+func (r *authSessionRepository) DeleteExpiredSessions() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM auth_sessions WHERE expires_at < $1 OR revoked_at IS NOT NULL`
+	_, err := r.db.ExecContext(ctx, query, time.Now())
+	return err
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrPersonaNotFound dikembalikan jika persona dengan ID tersebut (atau persona default) tidak ada
+var ErrPersonaNotFound = errors.New("persona not found")
+
+// CoachPersonaRepository adalah interface untuk repository persona coach
+type CoachPersonaRepository interface {
+	GetPersonaByID(id int) (model.CoachPersona, error)
+	GetDefaultPersona() (model.CoachPersona, error)
+	GetPersonas(includeArchived bool) ([]model.CoachPersona, error)
+	CreatePersona(persona model.CoachPersona) (model.CoachPersona, error)
+	UpdatePersona(persona model.CoachPersona) (model.CoachPersona, error)
+	UpdatePersonaStatus(id int, status string) error
+}
+
+type coachPersonaRepository struct {
+	db *sql.DB
+}
+
+func NewCoachPersonaRepository(db *sql.DB) CoachPersonaRepository {
+	return &coachPersonaRepository{db: db}
+}
+
+const coachPersonaColumns = `
+	id, name, description, system_prompt, temperature, max_tokens, language, is_default, status, created_at, updated_at
+`
+
+func scanCoachPersona(row rowScanner) (model.CoachPersona, error) {
+	var persona model.CoachPersona
+	err := row.Scan(
+		&persona.ID,
+		&persona.Name,
+		&persona.Description,
+		&persona.SystemPrompt,
+		&persona.Temperature,
+		&persona.MaxTokens,
+		&persona.Language,
+		&persona.IsDefault,
+		&persona.Status,
+		&persona.CreatedAt,
+		&persona.UpdatedAt,
+	)
+	return persona, err
+}
+
+func (r *coachPersonaRepository) GetPersonaByID(id int) (model.CoachPersona, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + coachPersonaColumns + ` FROM coach_personas WHERE id = $1`
+	persona, err := scanCoachPersona(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CoachPersona{}, ErrPersonaNotFound
+		}
+		return model.CoachPersona{}, err
+	}
+	return persona, nil
+}
+
+func (r *coachPersonaRepository) GetDefaultPersona() (model.CoachPersona, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + coachPersonaColumns + ` FROM coach_personas WHERE is_default AND status = $1`
+	persona, err := scanCoachPersona(r.db.QueryRowContext(ctx, query, model.CoachPersonaStatusActive))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CoachPersona{}, ErrPersonaNotFound
+		}
+		return model.CoachPersona{}, err
+	}
+	return persona, nil
+}
+
+func (r *coachPersonaRepository) GetPersonas(includeArchived bool) ([]model.CoachPersona, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + coachPersonaColumns + `
+		FROM coach_personas
+		WHERE $1 OR status = $2
+		ORDER BY is_default DESC, name
+	`
+	rows, err := r.db.QueryContext(ctx, query, includeArchived, model.CoachPersonaStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	personas := []model.CoachPersona{}
+	for rows.Next() {
+		persona, err := scanCoachPersona(rows)
+		if err != nil {
+			return nil, err
+		}
+		personas = append(personas, persona)
+	}
+
+	return personas, rows.Err()
+}
+
+// CreatePersona menyimpan persona baru; jika persona ini default, default lama dilepas dalam transaksi yang sama
+func (r *coachPersonaRepository) CreatePersona(persona model.CoachPersona) (model.CoachPersona, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.CoachPersona{}, err
+	}
+	defer tx.Rollback()
+
+	if persona.IsDefault {
+		if _, err := tx.ExecContext(ctx, `UPDATE coach_personas SET is_default = FALSE WHERE is_default`); err != nil {
+			return model.CoachPersona{}, fmt.Errorf("failed to reset default persona: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO coach_personas (name, description, system_prompt, temperature, max_tokens, language, is_default, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id
+	`
+
+	now := time.Now()
+	persona.CreatedAt = now
+	persona.UpdatedAt = now
+
+	err = tx.QueryRowContext(ctx, query,
+		persona.Name,
+		persona.Description,
+		persona.SystemPrompt,
+		persona.Temperature,
+		persona.MaxTokens,
+		persona.Language,
+		persona.IsDefault,
+		persona.Status,
+		now,
+	).Scan(&persona.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return model.CoachPersona{}, errors.New("persona name already exists")
+		}
+		return model.CoachPersona{}, fmt.Errorf("failed to create persona: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.CoachPersona{}, err
+	}
+	return persona, nil
+}
+
+func (r *coachPersonaRepository) UpdatePersona(persona model.CoachPersona) (model.CoachPersona, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.CoachPersona{}, err
+	}
+	defer tx.Rollback()
+
+	if persona.IsDefault {
+		if _, err := tx.ExecContext(ctx, `UPDATE coach_personas SET is_default = FALSE WHERE is_default AND id <> $1`, persona.ID); err != nil {
+			return model.CoachPersona{}, fmt.Errorf("failed to reset default persona: %w", err)
+		}
+	}
+
+	query := `
+		UPDATE coach_personas
+		SET name = $1, description = $2, system_prompt = $3, temperature = $4, max_tokens = $5,
+			language = $6, is_default = $7, status = $8, updated_at = $9
+		WHERE id = $10
+		RETURNING created_at
+	`
+
+	persona.UpdatedAt = time.Now()
+	err = tx.QueryRowContext(ctx, query,
+		persona.Name,
+		persona.Description,
+		persona.SystemPrompt,
+		persona.Temperature,
+		persona.MaxTokens,
+		persona.Language,
+		persona.IsDefault,
+		persona.Status,
+		persona.UpdatedAt,
+		persona.ID,
+	).Scan(&persona.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CoachPersona{}, ErrPersonaNotFound
+		}
+		if isUniqueViolation(err) {
+			return model.CoachPersona{}, errors.New("persona name already exists")
+		}
+		return model.CoachPersona{}, fmt.Errorf("failed to update persona: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.CoachPersona{}, err
+	}
+	return persona, nil
+}
+
+// UpdatePersonaStatus mengubah status persona; persona yang diarsipkan tidak lagi menjadi default
+func (r *coachPersonaRepository) UpdatePersonaStatus(id int, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE coach_personas
+		SET status = $1, is_default = is_default AND $1 = $2, updated_at = $3
+		WHERE id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, status, model.CoachPersonaStatusActive, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrPersonaNotFound
+	}
+
+	return nil
+}
+
+// isUniqueViolation mengecek error Postgres 23505 (unique_violation)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CoachPreferenceRepository menyimpan pengaturan AI coach per user
+type CoachPreferenceRepository interface {
+	GetPersonalContextEnabled(c context.Context, userID int) (bool, error)
+	SetPersonalContextEnabled(c context.Context, userID int, enabled bool) error
+}
+
+type coachPreferenceRepository struct {
+	db *sql.DB
+}
+
+func NewCoachPreferenceRepository(db *sql.DB) CoachPreferenceRepository {
+	return &coachPreferenceRepository{db: db}
+}
+
+// GetPersonalContextEnabled mengembalikan false jika user belum pernah mengubah pengaturan
+func (r *coachPreferenceRepository) GetPersonalContextEnabled(c context.Context, userID int) (bool, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	var enabled bool
+	query := `SELECT personal_context_enabled FROM coach_preferences WHERE user_id = $1`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get coach preferences: %w", err)
+	}
+	return enabled, nil
+}
+
+func (r *coachPreferenceRepository) SetPersonalContextEnabled(c context.Context, userID int, enabled bool) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO coach_preferences (user_id, personal_context_enabled, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET personal_context_enabled = $2, updated_at = $3
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, enabled, time.Now()); err != nil {
+		return fmt.Errorf("failed to save coach preferences: %w", err)
+	}
+	return nil
+}
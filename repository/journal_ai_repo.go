@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"pijar/model"
+	"time"
 )
 
 type JournalAnalysisRepository struct {
@@ -17,54 +18,140 @@ func NewJournalAnalysisRepository(db *sql.DB) *JournalAnalysisRepository {
 
 func (r *JournalAnalysisRepository) Save(analysis *model.JournalAnalysis) error {
 	query := `
-		INSERT INTO journal_analyses (journal_id, user_id, sentiment_score, analyzed_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
+		INSERT INTO journal_analyses (journal_id, user_id, sentiment_score, emotions, keywords, themes, insights, recommendations, analyzed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
 	`
-	return r.db.QueryRow(query, analysis.JournalID, analysis.UserID, analysis.SentimentScore, analysis.AnalyzedAt).Scan(&analysis.ID)
+	return r.db.QueryRow(
+		query,
+		analysis.JournalID,
+		analysis.UserID,
+		analysis.SentimentScore,
+		analysis.Emotions,
+		analysis.Keywords,
+		analysis.Themes,
+		analysis.Insights,
+		analysis.Recommendations,
+		analysis.AnalyzedAt,
+	).Scan(&analysis.ID, &analysis.CreatedAt, &analysis.UpdatedAt)
 }
 
+// SaveTrend menyimpan trend, atau menimpa trend yang sudah ada untuk user dan periode yang sama.
+// Digest dan status email dari trend lama dipertahankan.
 func (r *JournalAnalysisRepository) SaveTrend(trend *model.TrendAnalysis) error {
 	query := `
 		INSERT INTO trend_analyses (user_id, period_type, period_start, period_end, average_sentiment, top_emotions, key_themes, mood_trend, trend_insights, progress_notes)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id, created_at, updated_at
+		ON CONFLICT (user_id, period_type, period_start, period_end) DO UPDATE SET
+			average_sentiment = EXCLUDED.average_sentiment,
+			top_emotions = EXCLUDED.top_emotions,
+			key_themes = EXCLUDED.key_themes,
+			mood_trend = EXCLUDED.mood_trend,
+			trend_insights = EXCLUDED.trend_insights,
+			progress_notes = EXCLUDED.progress_notes,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, COALESCE(digest, ''), emailed_at, created_at, updated_at
 	`
-	return r.db.QueryRow(
+	var emailedAt sql.NullTime
+	err := r.db.QueryRow(
 		query,
 		trend.UserID,
 		trend.PeriodType,
-		trend.PeriodStart,
-		trend.PeriodEnd,
+		trend.PeriodStart.Format(trendDateLayout),
+		trend.PeriodEnd.Format(trendDateLayout),
 		trend.AverageSentiment,
 		trend.TopEmotions,
 		trend.KeyThemes,
 		trend.MoodTrend,
 		trend.TrendInsights,
 		trend.ProgressNotes,
-	).Scan(&trend.ID, &trend.CreatedAt, &trend.UpdatedAt)
+	).Scan(&trend.ID, &trend.Digest, &emailedAt, &trend.CreatedAt, &trend.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if emailedAt.Valid {
+		trend.EmailedAt = &emailedAt.Time
+	}
+	return nil
 }
 
-func (r *JournalAnalysisRepository) GetByJournalID(journalID int) (*model.JournalAnalysis, error) {
+// GetTrendByPeriod mengambil trend user untuk satu periode, sql.ErrNoRows jika belum ada
+func (r *JournalAnalysisRepository) GetTrendByPeriod(userID int, periodType string, periodStart, periodEnd time.Time) (*model.TrendAnalysis, error) {
+	query := `SELECT` + trendColumns + `
+		FROM trend_analyses
+		WHERE user_id = $1 AND period_type = $2 AND period_start = $3 AND period_end = $4
+	`
+	return scanTrend(r.db.QueryRow(query, userID, periodType, periodStart.Format(trendDateLayout), periodEnd.Format(trendDateLayout)))
+}
+
+// SaveTrendDigest menyimpan ringkasan AI untuk laporan trend
+func (r *JournalAnalysisRepository) SaveTrendDigest(trendID int, digest string) error {
+	query := `UPDATE trend_analyses SET digest = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	_, err := r.db.Exec(query, digest, trendID)
+	return err
+}
+
+// ClaimTrendEmail menandai laporan sebagai terkirim. Mengembalikan false jika laporan sudah
+// ditandai sebelumnya, misalnya oleh instance lain, agar email tidak terkirim dua kali.
+func (r *JournalAnalysisRepository) ClaimTrendEmail(trendID int) (bool, error) {
+	query := `UPDATE trend_analyses SET emailed_at = CURRENT_TIMESTAMP WHERE id = $1 AND emailed_at IS NULL`
+	result, err := r.db.Exec(query, trendID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// ReleaseTrendEmail membatalkan ClaimTrendEmail jika email gagal dikirim
+func (r *JournalAnalysisRepository) ReleaseTrendEmail(trendID int) error {
+	query := `UPDATE trend_analyses SET emailed_at = NULL WHERE id = $1`
+	_, err := r.db.Exec(query, trendID)
+	return err
+}
+
+// GetActiveUserIDs mengambil user yang punya analisis untuk jurnal yang ditulis sejak waktu tertentu
+func (r *JournalAnalysisRepository) GetActiveUserIDs(since time.Time) ([]int, error) {
 	query := `
-		SELECT id, journal_id, user_id, sentiment_score, analyzed_at
-		FROM journal_analyses
-		WHERE journal_id = $1
-		LIMIT 1
+		SELECT DISTINCT ja.user_id
+		FROM journal_analyses ja
+		JOIN journals j ON j.id = ja.journal_id
+		WHERE j.created_at >= $1
+		ORDER BY ja.user_id
 	`
-	analysis := &model.JournalAnalysis{}
-	err := r.db.QueryRow(query, journalID).Scan(&analysis.ID, &analysis.JournalID, &analysis.UserID, &analysis.SentimentScore, &analysis.AnalyzedAt)
+	rows, err := r.db.Query(query, serverWallClock(since))
 	if err != nil {
 		return nil, err
 	}
-	return analysis, nil
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (r *JournalAnalysisRepository) GetByJournalID(journalID int) (*model.JournalAnalysis, error) {
+	query := `SELECT` + analysisColumns + `
+		FROM journal_analyses
+		WHERE journal_id = $1
+		LIMIT 1
+	`
+	return scanAnalysis(r.db.QueryRow(query, journalID))
 }
 
 func (r *JournalAnalysisRepository) GetByUserID(userID int, limit int) ([]*model.JournalAnalysis, error) {
 	log.Printf("Executing GetByUserID with userID: %d, limit: %d", userID, limit)
 	
-	query := `
-		SELECT id, journal_id, user_id, sentiment_score, analyzed_at
+	query := `SELECT` + analysisColumns + `
 		FROM journal_analyses
 		WHERE user_id = $1
 		ORDER BY analyzed_at DESC
@@ -81,8 +168,7 @@ func (r *JournalAnalysisRepository) GetByUserID(userID int, limit int) ([]*model
 
 	var analyses []*model.JournalAnalysis
 	for rows.Next() {
-		analysis := &model.JournalAnalysis{}
-		err := rows.Scan(&analysis.ID, &analysis.JournalID, &analysis.UserID, &analysis.SentimentScore, &analysis.AnalyzedAt)
+		analysis, err := scanAnalysis(rows)
 		if err != nil {
 			log.Printf("Error scanning row: %v", err)
 			return nil, err
@@ -101,10 +187,119 @@ func (r *JournalAnalysisRepository) GetByUserID(userID int, limit int) ([]*model
 	return analyses, nil
 }
 
-func (r *JournalAnalysisRepository) GetTrendsByUserID(userID int, periodType string) ([]*model.TrendAnalysis, error) {
+// GetByUserIDBetween mengambil analisis user untuk jurnal yang ditulis di [start, end), urut dari
+// jurnal terlama. Periode mengikuti journals.created_at, bukan waktu analisis, karena analisis
+// bisa berjalan jauh setelah jurnal ditulis atau diulang. Kolom TIMESTAMP tanpa zona waktu berisi
+// jam lokal server, jadi batas periode diubah ke zona waktu server dulu dan hasilnya dibaca
+// kembali sebagai jam lokal server.
+func (r *JournalAnalysisRepository) GetByUserIDBetween(userID int, start, end time.Time) ([]*model.JournalAnalysis, error) {
 	query := `
-		SELECT id, user_id, period_type, period_start, period_end, average_sentiment, 
-			top_emotions, key_themes, mood_trend, trend_insights, progress_notes, created_at, updated_at
+		SELECT ja.id, ja.journal_id, ja.user_id, ja.sentiment_score, ja.emotions, ja.keywords, ja.themes,
+			ja.insights, ja.recommendations, ja.analyzed_at, ja.created_at, ja.updated_at, j.created_at
+		FROM journal_analyses ja
+		JOIN journals j ON j.id = ja.journal_id
+		WHERE ja.user_id = $1 AND j.created_at >= $2 AND j.created_at < $3
+		ORDER BY j.created_at ASC, ja.id ASC
+	`
+	rows, err := r.db.Query(query, userID, serverWallClock(start), serverWallClock(end))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var analyses []*model.JournalAnalysis
+	for rows.Next() {
+		var journalCreatedAt time.Time
+		analysis, err := scanAnalysis(rows, &journalCreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		analysis.AnalyzedAt = serverLocalTime(analysis.AnalyzedAt)
+		analysis.JournalCreatedAt = serverLocalTime(journalCreatedAt)
+		analyses = append(analyses, analysis)
+	}
+	return analyses, rows.Err()
+}
+
+// trendDateLayout adalah format kolom DATE period_start dan period_end. Tanggal dikirim sebagai
+// teks agar tanggal lokal user tidak bergeser karena zona waktu.
+const trendDateLayout = "2006-01-02"
+
+// serverWallClock mengirim waktu sebagai jam lokal server tanpa zona waktu, sesuai isi kolom TIMESTAMP
+func serverWallClock(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02 15:04:05.999999")
+}
+
+// serverLocalTime membaca kolom TIMESTAMP (yang oleh driver dianggap UTC) sebagai jam lokal server
+func serverLocalTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
+
+const analysisColumns = `
+	id, journal_id, user_id, sentiment_score, emotions, keywords, themes,
+	insights, recommendations, analyzed_at, created_at, updated_at
+`
+
+// scanAnalysis membaca kolom analysisColumns, diikuti kolom tambahan query ke extra
+func scanAnalysis(row rowScanner, extra ...interface{}) (*model.JournalAnalysis, error) {
+	analysis := &model.JournalAnalysis{}
+	dest := []interface{}{
+		&analysis.ID,
+		&analysis.JournalID,
+		&analysis.UserID,
+		&analysis.SentimentScore,
+		&analysis.Emotions,
+		&analysis.Keywords,
+		&analysis.Themes,
+		&analysis.Insights,
+		&analysis.Recommendations,
+		&analysis.AnalyzedAt,
+		&analysis.CreatedAt,
+		&analysis.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+const trendColumns = `
+	id, user_id, period_type, period_start, period_end, average_sentiment,
+	top_emotions, key_themes, mood_trend, trend_insights, progress_notes,
+	COALESCE(digest, ''), emailed_at, created_at, updated_at
+`
+
+func scanTrend(row rowScanner) (*model.TrendAnalysis, error) {
+	trend := &model.TrendAnalysis{}
+	var emailedAt sql.NullTime
+	err := row.Scan(
+		&trend.ID,
+		&trend.UserID,
+		&trend.PeriodType,
+		&trend.PeriodStart,
+		&trend.PeriodEnd,
+		&trend.AverageSentiment,
+		&trend.TopEmotions,
+		&trend.KeyThemes,
+		&trend.MoodTrend,
+		&trend.TrendInsights,
+		&trend.ProgressNotes,
+		&trend.Digest,
+		&emailedAt,
+		&trend.CreatedAt,
+		&trend.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if emailedAt.Valid {
+		trend.EmailedAt = &emailedAt.Time
+	}
+	return trend, nil
+}
+
+func (r *JournalAnalysisRepository) GetTrendsByUserID(userID int, periodType string) ([]*model.TrendAnalysis, error) {
+	query := `SELECT` + trendColumns + `
 		FROM trend_analyses
 		WHERE user_id = $1 AND period_type = $2
 		ORDER BY period_start DESC
@@ -117,22 +312,7 @@ func (r *JournalAnalysisRepository) GetTrendsByUserID(userID int, periodType str
 
 	var trends []*model.TrendAnalysis
 	for rows.Next() {
-		trend := &model.TrendAnalysis{}
-		err := rows.Scan(
-			&trend.ID,
-			&trend.UserID,
-			&trend.PeriodType,
-			&trend.PeriodStart,
-			&trend.PeriodEnd,
-			&trend.AverageSentiment,
-			&trend.TopEmotions,
-			&trend.KeyThemes,
-			&trend.MoodTrend,
-			&trend.TrendInsights,
-			&trend.ProgressNotes,
-			&trend.CreatedAt,
-			&trend.UpdatedAt,
-		)
+		trend, err := scanTrend(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -144,7 +324,7 @@ func (r *JournalAnalysisRepository) GetTrendsByUserID(userID int, periodType str
 func (r *JournalAnalysisRepository) GetAnalysisWithJournal(userID int, limit int) ([]map[string]interface{}, error) {
 	query := `
 		SELECT ja.id, ja.journal_id, ja.user_id, ja.sentiment_score, ja.analyzed_at,
-		       j.title, j.content, j.feeling
+		       j.judul, j.isi, j.perasaan
 		FROM journal_analyses ja
 		LEFT JOIN journals j ON j.id = ja.journal_id
 		WHERE ja.user_id = $1
@@ -246,9 +426,22 @@ func (r *JournalAnalysisRepository) DeleteAnalysis(id int) error {
 func (r *JournalAnalysisRepository) UpdateAnalysis(analysis *model.JournalAnalysis) error {
 	query := `
 		UPDATE journal_analyses
-		SET journal_id = $1, user_id = $2, sentiment_score = $3, analyzed_at = $4
-		WHERE id = $5
+		SET journal_id = $1, user_id = $2, sentiment_score = $3, emotions = $4, keywords = $5, themes = $6,
+		    insights = $7, recommendations = $8, analyzed_at = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10
 	`
-	_, err := r.db.Exec(query, analysis.JournalID, analysis.UserID, analysis.SentimentScore, analysis.AnalyzedAt, analysis.ID)
+	_, err := r.db.Exec(
+		query,
+		analysis.JournalID,
+		analysis.UserID,
+		analysis.SentimentScore,
+		analysis.Emotions,
+		analysis.Keywords,
+		analysis.Themes,
+		analysis.Insights,
+		analysis.Recommendations,
+		analysis.AnalyzedAt,
+		analysis.ID,
+	)
 	return err
 }
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+)
+
+// JournalAnalysisJobRepository adalah antrean analisis jurnal berbasis tabel journal_analysis_jobs
+type JournalAnalysisJobRepository interface {
+	// Enqueue memasukkan jurnal ke antrean. Job yang sedang berjalan ditandai untuk dijalankan ulang.
+	Enqueue(c context.Context, journalID, userID, maxAttempts int) (model.JournalAnalysisJob, error)
+	// ClaimNext mengambil satu job yang sudah waktunya, atau job running yang lebih lama dari staleAfter.
+	// Mengembalikan nil jika antrean kosong.
+	ClaimNext(c context.Context, staleAfter time.Duration) (*model.JournalAnalysisJob, error)
+	// Finish mencatat hasil percobaan; diabaikan jika job sudah diambil worker lain
+	Finish(c context.Context, id, lease int, result model.JournalAnalysisJobResult) error
+	GetByJournalID(c context.Context, journalID int) (model.JournalAnalysisJob, error)
+}
+
+type journalAnalysisJobRepository struct {
+	db *sql.DB
+}
+
+func NewJournalAnalysisJobRepository(db *sql.DB) JournalAnalysisJobRepository {
+	return &journalAnalysisJobRepository{db: db}
+}
+
+const journalAnalysisJobColumns = `
+	id, journal_id, user_id, status, attempts, max_attempts, COALESCE(last_error, ''), crisis, lease,
+	run_after, locked_at, finished_at, created_at, updated_at
+`
+
+func scanJournalAnalysisJob(row rowScanner) (model.JournalAnalysisJob, error) {
+	var job model.JournalAnalysisJob
+	var lockedAt, finishedAt sql.NullTime
+	err := row.Scan(
+		&job.ID,
+		&job.JournalID,
+		&job.UserID,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.Crisis,
+		&job.Lease,
+		&job.RunAfter,
+		&lockedAt,
+		&finishedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return model.JournalAnalysisJob{}, err
+	}
+	if lockedAt.Valid {
+		job.LockedAt = &lockedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
+
+func (r *journalAnalysisJobRepository) Enqueue(c context.Context, journalID, userID, maxAttempts int) (model.JournalAnalysisJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	// Job yang sedang berjalan tidak diganggu: worker-nya yang mengantrekan ulang setelah selesai
+	query := `
+		INSERT INTO journal_analysis_jobs (journal_id, user_id, max_attempts)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (journal_id) DO UPDATE SET
+			status = CASE WHEN journal_analysis_jobs.status = 'running' THEN 'running' ELSE 'queued' END,
+			rerun = journal_analysis_jobs.status = 'running',
+			attempts = CASE WHEN journal_analysis_jobs.status = 'running' THEN journal_analysis_jobs.attempts ELSE 0 END,
+			max_attempts = EXCLUDED.max_attempts,
+			last_error = NULL,
+			run_after = CURRENT_TIMESTAMP,
+			finished_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING` + journalAnalysisJobColumns
+
+	job, err := scanJournalAnalysisJob(r.db.QueryRowContext(ctx, query, journalID, userID, maxAttempts))
+	if err != nil {
+		return model.JournalAnalysisJob{}, fmt.Errorf("failed to enqueue journal analysis: %w", err)
+	}
+	return job, nil
+}
+
+func (r *journalAnalysisJobRepository) ClaimNext(c context.Context, staleAfter time.Duration) (*model.JournalAnalysisJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	// SKIP LOCKED agar beberapa worker (juga di instance lain) tidak mengambil job yang sama
+	query := `
+		UPDATE journal_analysis_jobs
+		SET status = 'running', attempts = attempts + 1, lease = lease + 1, rerun = FALSE,
+			locked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM journal_analysis_jobs
+			WHERE (status = 'queued' AND run_after <= CURRENT_TIMESTAMP)
+			   OR (status = 'running' AND locked_at < CURRENT_TIMESTAMP - make_interval(secs => $1))
+			ORDER BY run_after
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING` + journalAnalysisJobColumns
+
+	job, err := scanJournalAnalysisJob(r.db.QueryRowContext(ctx, query, staleAfter.Seconds()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim journal analysis job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *journalAnalysisJobRepository) Finish(c context.Context, id, lease int, result model.JournalAnalysisJobResult) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	// Jurnal yang berubah selama job berjalan (rerun) langsung diantrekan lagi
+	query := `
+		UPDATE journal_analysis_jobs
+		SET status = CASE WHEN rerun THEN 'queued' ELSE $3::varchar END,
+			attempts = CASE WHEN rerun THEN 0 ELSE $4 END,
+			last_error = CASE WHEN rerun THEN NULL ELSE NULLIF($5, '') END,
+			crisis = $6,
+			run_after = CASE WHEN rerun THEN CURRENT_TIMESTAMP ELSE CURRENT_TIMESTAMP + make_interval(secs => $7) END,
+			finished_at = CASE WHEN NOT rerun AND $3::varchar IN ('done', 'failed') THEN CURRENT_TIMESTAMP END,
+			rerun = FALSE,
+			locked_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND lease = $2 AND status = 'running'
+	`
+	_, err := r.db.ExecContext(ctx, query, id, lease, result.Status, result.Attempts, result.Error, result.Crisis, result.RetryDelay.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to update journal analysis job: %w", err)
+	}
+	return nil
+}
+
+func (r *journalAnalysisJobRepository) GetByJournalID(c context.Context, journalID int) (model.JournalAnalysisJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	query := `SELECT` + journalAnalysisJobColumns + `FROM journal_analysis_jobs WHERE journal_id = $1`
+	job, err := scanJournalAnalysisJob(r.db.QueryRowContext(ctx, query, journalID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.JournalAnalysisJob{}, errors.New("journal analysis job not found")
+	}
+	if err != nil {
+		return model.JournalAnalysisJob{}, fmt.Errorf("failed to get journal analysis job: %w", err)
+	}
+	return job, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"pijar/model"
+	"time"
+)
+
+// MidtransCallbackRepository adalah interface untuk log notifikasi Midtrans
+type MidtransCallbackRepository interface {
+	SaveCallback(callback model.MidtransCallbackLog) (model.MidtransCallbackLog, error)
+	MarkCallbackProcessed(id int) error
+}
+
+// midtransCallbackRepository adalah implementasi dari MidtransCallbackRepository
+type midtransCallbackRepository struct {
+	db *sql.DB
+}
+
+func NewMidtransCallbackRepository(db *sql.DB) MidtransCallbackRepository {
+	return &midtransCallbackRepository{db: db}
+}
+
+// SaveCallback mencatat notifikasi. Jika notifikasi yang sama (transaction_id, transaction_status,
+// fraud_status, status_code, gross_amount dan refund_key) sudah pernah diterima, baris yang lama
+// dikembalikan beserta processed_at-nya sehingga pemanggil tahu notifikasi tersebut sudah diproses.
+// capture yang berubah dari challenge ke accept, atau partial_refund berikutnya, dicatat sebagai baris baru.
+func (r *midtransCallbackRepository) SaveCallback(callback model.MidtransCallbackLog) (model.MidtransCallbackLog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO midtrans_callbacks
+		(transaction_id, order_id, transaction_status, status_code, fraud_status, gross_amount, refund_key, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (transaction_id, transaction_status, fraud_status, status_code, gross_amount, refund_key)
+		DO UPDATE SET delivery_count = midtrans_callbacks.delivery_count + 1
+		RETURNING id, processed_at, created_at
+	`
+
+	var processedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query,
+		callback.TransactionID,
+		callback.OrderID,
+		callback.TransactionStatus,
+		callback.StatusCode,
+		callback.FraudStatus,
+		callback.GrossAmount,
+		callback.RefundKey,
+		callback.Payload,
+		time.Now(),
+	).Scan(&callback.ID, &processedAt, &callback.CreatedAt)
+	if err != nil {
+		return model.MidtransCallbackLog{}, fmt.Errorf("failed to save midtrans callback: %w", err)
+	}
+
+	if processedAt.Valid {
+		callback.ProcessedAt = &processedAt.Time
+	}
+
+	return callback, nil
+}
+
+func (r *midtransCallbackRepository) MarkCallbackProcessed(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `UPDATE midtrans_callbacks SET processed_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	return err
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"pijar/model"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // ProductRepository adalah interface untuk repository produk
 type ProductRepository interface {
 	GetProductByID(id int) (model.Product, error)
+	GetProducts(includeArchived bool) ([]model.Product, error)
+	CreateProduct(product model.Product) (model.Product, error)
+	UpdateProduct(product model.Product) (model.Product, error)
+	UpdateProductStatus(id int, status string) error
 }
 
 // productRepository adalah implementasi dari ProductRepository
@@ -27,12 +35,19 @@ func (r *productRepository) GetProductByID(id int) (model.Product, error) {
 	defer cancel()
 
 	var product model.Product
-	query := `SELECT id, name, description, price, created_at, updated_at FROM products WHERE id = $1`
+	query := `
+		SELECT id, name, description, price, currency, monthly_subscription, status, created_at, updated_at
+		FROM products
+		WHERE id = $1
+	`
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&product.ID,
 		&product.Name,
 		&product.Description,
 		&product.Price,
+		&product.Currency,
+		&product.MonthlySubscription,
+		&product.Status,
 		&product.CreatedAt,
 		&product.UpdatedAt,
 	)
@@ -43,16 +58,142 @@ func (r *productRepository) GetProductByID(id int) (model.Product, error) {
 		}
 		return model.Product{}, err
 	}
-	
+
 	return product, nil
 }
 
+func (r *productRepository) GetProducts(includeArchived bool) ([]model.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, name, description, price, currency, monthly_subscription, status, created_at, updated_at
+		FROM products
+		WHERE $1 OR status = $2
+		ORDER BY price, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, includeArchived, model.ProductStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []model.Product
+	for rows.Next() {
+		var product model.Product
+		if err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.Currency,
+			&product.MonthlySubscription,
+			&product.Status,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+func (r *productRepository) CreateProduct(product model.Product) (model.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO products (name, description, price, currency, monthly_subscription, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id
+	`
+
+	now := time.Now()
+	product.CreatedAt = now
+	product.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		product.Name,
+		product.Description,
+		product.Price,
+		product.Currency,
+		product.MonthlySubscription,
+		product.Status,
+		now,
+	).Scan(&product.ID)
+	if err != nil {
+		return model.Product{}, fmt.Errorf("failed to create product: %w", err)
+	}
+
+	return product, nil
+}
+
+func (r *productRepository) UpdateProduct(product model.Product) (model.Product, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE products
+		SET name = $1, description = $2, price = $3, currency = $4, monthly_subscription = $5, status = $6, updated_at = $7
+		WHERE id = $8
+		RETURNING created_at
+	`
+
+	product.UpdatedAt = time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		product.Name,
+		product.Description,
+		product.Price,
+		product.Currency,
+		product.MonthlySubscription,
+		product.Status,
+		product.UpdatedAt,
+		product.ID,
+	).Scan(&product.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Product{}, errors.New("product not found")
+		}
+		return model.Product{}, fmt.Errorf("failed to update product: %w", err)
+	}
+
+	return product, nil
+}
+
+func (r *productRepository) UpdateProductStatus(id int, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `UPDATE products SET status = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("product not found")
+	}
+
+	return nil
+}
+
 // TransactionRepository adalah interface untuk repository transaksi
 type TransactionRepository interface {
 	CreateTransaction(transaction model.Transaction) (model.Transaction, error)
 	UpdateTransactionStatus(id int, status string) error
 	GetTransactionByID(id int) (model.Transaction, error)
+	GetTransactionByOrderID(orderID string) (model.Transaction, error)
 	UpdateTransactionStatusByOrderID(orderID string, status string, midtransID string) error
+	TransitionTransactionStatus(id int, fromStatus string, toStatus string, midtransID string) error
+	ListTransactions(filter model.TransactionFilter) ([]model.Transaction, int64, error)
+	GetStalePendingTransactions(updatedBefore time.Time, limit int, excludeIDs []int) ([]model.Transaction, error)
 }
 
 // transactionRepository adalah implementasi dari TransactionRepository
@@ -156,6 +297,41 @@ func (r *transactionRepository) GetTransactionByID(id int) (model.Transaction, e
 	return transaction, nil
 }
 
+func (r *transactionRepository) GetTransactionByOrderID(orderID string) (model.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var transaction model.Transaction
+	query := `
+		SELECT
+			id, user_id, product_id, amount, status, order_id, payment_url, midtrans_id, created_at, updated_at
+		FROM transactions
+		WHERE order_id = $1
+	`
+
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&transaction.ID,
+		&transaction.UserID,
+		&transaction.ProductID,
+		&transaction.Amount,
+		&transaction.Status,
+		&transaction.OrderID,
+		&transaction.PaymentURL,
+		&transaction.MidtransID,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Transaction{}, errors.New("transaction not found")
+		}
+		return model.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
 func (r *transactionRepository) UpdateTransactionStatusByOrderID(orderID string, status string, midtransID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -176,4 +352,150 @@ func (r *transactionRepository) UpdateTransactionStatusByOrderID(orderID string,
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// TransitionTransactionStatus mengubah status hanya jika status di database masih fromStatus,
+// sehingga dua notifikasi yang diproses bersamaan tidak saling menimpa
+func (r *transactionRepository) TransitionTransactionStatus(id int, fromStatus string, toStatus string, midtransID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE transactions
+		SET status = $1, midtrans_id = COALESCE(NULLIF($2, ''), midtrans_id), updated_at = $3
+		WHERE id = $4 AND status = $5
+	`
+	result, err := r.db.ExecContext(ctx, query, toStatus, midtransID, time.Now(), id, fromStatus)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("transaction status changed concurrently")
+	}
+
+	return nil
+}
+
+// ListTransactions mengembalikan transaksi sesuai filter beserta total barisnya untuk paginasi
+func (r *transactionRepository) ListTransactions(filter model.TransactionFilter) ([]model.Transaction, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM transactions WHERE " + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf(`
+		SELECT
+			id, user_id, product_id, amount, status, order_id, payment_url, midtrans_id, created_at, updated_at
+		FROM transactions
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transactions := []model.Transaction{}
+	for rows.Next() {
+		var transaction model.Transaction
+		if err := rows.Scan(
+			&transaction.ID,
+			&transaction.UserID,
+			&transaction.ProductID,
+			&transaction.Amount,
+			&transaction.Status,
+			&transaction.OrderID,
+			&transaction.PaymentURL,
+			&transaction.MidtransID,
+			&transaction.CreatedAt,
+			&transaction.UpdatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, total, rows.Err()
+}
+
+// GetStalePendingTransactions mengembalikan transaksi pending yang tidak berubah sejak updatedBefore,
+// yang paling lama lebih dulu. Transaksi di excludeIDs dilewati sehingga tidak ikut memenuhi limit.
+func (r *transactionRepository) GetStalePendingTransactions(updatedBefore time.Time, limit int, excludeIDs []int) ([]model.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			id, user_id, product_id, amount, status, order_id, payment_url, midtrans_id, created_at, updated_at
+		FROM transactions
+		WHERE status = $1 AND updated_at < $2 AND NOT (id = ANY($3))
+		ORDER BY updated_at ASC
+		LIMIT $4
+	`
+
+	// Slice nil dikirim sebagai NULL, yang membuat NOT (id = ANY(NULL)) tidak pernah benar
+	if excludeIDs == nil {
+		excludeIDs = []int{}
+	}
+	rows, err := r.db.QueryContext(ctx, query, model.TransactionStatusPending, updatedBefore, pq.Array(excludeIDs), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		var transaction model.Transaction
+		if err := rows.Scan(
+			&transaction.ID,
+			&transaction.UserID,
+			&transaction.ProductID,
+			&transaction.Amount,
+			&transaction.Status,
+			&transaction.OrderID,
+			&transaction.PaymentURL,
+			&transaction.MidtransID,
+			&transaction.CreatedAt,
+			&transaction.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
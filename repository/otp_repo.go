@@ -9,58 +9,102 @@ import (
 // OTPRepository interface untuk repository OTP
 type OTPRepository interface {
 	SaveOTP(otp *model.OTP) error
-	GetOTPByCode(code string) (*model.OTP, error)
-	DeleteOTP(code string) error
+	GetActiveOTP(email string, purpose string) (*model.OTP, error)
+	GetLatestOTP(email string, purpose string) (*model.OTP, error)
+	IncrementOTPAttempts(id int) error
+	DeleteOTPByID(id int) error
+	DeleteOTPsByEmail(email string, purpose string) error
 	DeleteExpiredOTPs() error
+	SavePendingRegistration(reg *model.PendingRegistration) error
+	GetPendingRegistration(email string) (*model.PendingRegistration, error)
+	DeletePendingRegistration(email string) error
+	DeleteExpiredPendingRegistrations() error
 }
 
 // Implementasi repository OTP
 func (r *UserRepo) SaveOTP(otp *model.OTP) error {
 	query := `
-		INSERT INTO otps (email, code, expires_at, attempts, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		INSERT INTO otps (email, code, purpose, expires_at, attempts, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING id
 	`
 	
-	_, err := r.DB.Exec(query,
+	if otp.Purpose == "" {
+		otp.Purpose = model.OTPPurposeRegistration
+	}
+
+	return r.DB.QueryRow(query,
 		otp.Email,
 		otp.Code,
+		otp.Purpose,
 		otp.ExpiresAt,
 		otp.Attempts,
-	)
-	return err
+	).Scan(&otp.ID)
 }
 
-func (r *UserRepo) GetOTPByCode(code string) (*model.OTP, error) {
+// GetActiveOTP mengambil OTP terbaru yang belum kedaluwarsa untuk email dan tujuan tertentu
+func (r *UserRepo) GetActiveOTP(email string, purpose string) (*model.OTP, error) {
 	query := `
-		SELECT id, email, code, expires_at, attempts, created_at, updated_at
+		SELECT id, email, code, purpose, expires_at, attempts, created_at, updated_at
 		FROM otps
-		WHERE code = $1
+		WHERE LOWER(email) = LOWER($1) AND purpose = $2 AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY created_at DESC
+		LIMIT 1
 	`
-	
+	return r.scanOTP(r.DB.QueryRow(query, email, purpose))
+}
+
+// GetLatestOTP mengambil OTP terbaru termasuk yang sudah kedaluwarsa, dipakai untuk
+// membawa jumlah percobaan ke OTP pengganti
+func (r *UserRepo) GetLatestOTP(email string, purpose string) (*model.OTP, error) {
+	query := `
+		SELECT id, email, code, purpose, expires_at, attempts, created_at, updated_at
+		FROM otps
+		WHERE LOWER(email) = LOWER($1) AND purpose = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	return r.scanOTP(r.DB.QueryRow(query, email, purpose))
+}
+
+func (r *UserRepo) scanOTP(row *sql.Row) (*model.OTP, error) {
 	var otp model.OTP
-	err := r.DB.QueryRow(query, code).Scan(
+	err := row.Scan(
 		&otp.ID,
 		&otp.Email,
 		&otp.Code,
+		&otp.Purpose,
 		&otp.ExpiresAt,
 		&otp.Attempts,
 		&otp.CreatedAt,
 		&otp.UpdatedAt,
 	)
-	
+
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("OTP not found")
 		}
 		return nil, err
 	}
-	
+
 	return &otp, nil
 }
 
-func (r *UserRepo) DeleteOTP(code string) error {
-	query := `DELETE FROM otps WHERE code = $1`
-	_, err := r.DB.Exec(query, code)
+func (r *UserRepo) IncrementOTPAttempts(id int) error {
+	query := `UPDATE otps SET attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := r.DB.Exec(query, id)
+	return err
+}
+
+func (r *UserRepo) DeleteOTPByID(id int) error {
+	query := `DELETE FROM otps WHERE id = $1`
+	_, err := r.DB.Exec(query, id)
+	return err
+}
+
+func (r *UserRepo) DeleteOTPsByEmail(email string, purpose string) error {
+	query := `DELETE FROM otps WHERE LOWER(email) = LOWER($1) AND purpose = $2`
+	_, err := r.DB.Exec(query, email, purpose)
 	return err
 }
 
@@ -69,3 +113,65 @@ func (r *UserRepo) DeleteExpiredOTPs() error {
 	_, err := r.DB.Exec(query)
 	return err
 }
+
+// SavePendingRegistration menyimpan (atau menimpa) data registrasi yang menunggu verifikasi OTP
+func (r *UserRepo) SavePendingRegistration(reg *model.PendingRegistration) error {
+	query := `
+		INSERT INTO pending_registrations (email, name, password_hash, birth_year, phone, expires_at, created_at, updated_at)
+		VALUES (LOWER($1), $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (email)
+		DO UPDATE SET name = $2, password_hash = $3, birth_year = $4, phone = $5, expires_at = $6, updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.DB.QueryRow(query,
+		reg.Email,
+		reg.Name,
+		reg.PasswordHash,
+		reg.BirthYear,
+		reg.Phone,
+		reg.ExpiresAt,
+	).Scan(&reg.ID, &reg.CreatedAt, &reg.UpdatedAt)
+}
+
+func (r *UserRepo) GetPendingRegistration(email string) (*model.PendingRegistration, error) {
+	query := `
+		SELECT id, email, name, password_hash, birth_year, phone, expires_at, created_at, updated_at
+		FROM pending_registrations
+		WHERE email = LOWER($1) AND expires_at > CURRENT_TIMESTAMP
+	`
+
+	var reg model.PendingRegistration
+	err := r.DB.QueryRow(query, email).Scan(
+		&reg.ID,
+		&reg.Email,
+		&reg.Name,
+		&reg.PasswordHash,
+		&reg.BirthYear,
+		&reg.Phone,
+		&reg.ExpiresAt,
+		&reg.CreatedAt,
+		&reg.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("pending registration not found")
+		}
+		return nil, err
+	}
+
+	return &reg, nil
+}
+
+func (r *UserRepo) DeletePendingRegistration(email string) error {
+	query := `DELETE FROM pending_registrations WHERE email = LOWER($1)`
+	_, err := r.DB.Exec(query, email)
+	return err
+}
+
+func (r *UserRepo) DeleteExpiredPendingRegistrations() error {
+	query := `DELETE FROM pending_registrations WHERE expires_at < CURRENT_TIMESTAMP`
+	_, err := r.DB.Exec(query)
+	return err
+}
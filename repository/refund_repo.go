@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+)
+
+// ErrRefundExceedsAmount dikembalikan jika total refund melebihi nilai transaksi
+var ErrRefundExceedsAmount = errors.New("refund amount exceeds the refundable amount")
+
+// RefundRepository adalah interface untuk repository refund
+type RefundRepository interface {
+	CreatePendingRefund(refund model.Refund) (model.Refund, error)
+	UpdateRefundStatus(id int, status string) error
+	GetRefundsByTransactionID(transactionID int) ([]model.Refund, error)
+	GetRefundedAmount(transactionID int) (int, error)
+}
+
+// refundRepository adalah implementasi dari RefundRepository
+type refundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+// CreatePendingRefund menyimpan refund berstatus pending. Baris transaksi dikunci selama
+// pengecekan sisa dana, sehingga dua refund yang bersamaan tidak bisa melebihi nilai transaksi.
+func (r *refundRepository) CreatePendingRefund(refund model.Refund) (model.Refund, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Refund{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var transactionAmount int
+	err = tx.QueryRowContext(ctx, `SELECT amount FROM transactions WHERE id = $1 FOR UPDATE`, refund.TransactionID).
+		Scan(&transactionAmount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Refund{}, errors.New("transaction not found")
+		}
+		return model.Refund{}, err
+	}
+
+	// refund yang masih pending ikut dihitung karena dananya mungkin sudah diproses Midtrans
+	var reserved int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM refunds
+		WHERE transaction_id = $1 AND status <> $2
+	`, refund.TransactionID, model.RefundStatusFailed).Scan(&reserved)
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	if err := checkRefundable(refund.Amount, transactionAmount, reserved); err != nil {
+		return model.Refund{}, err
+	}
+
+	now := time.Now()
+	refund.Status = model.RefundStatusPending
+	refund.CreatedAt = now
+	refund.UpdatedAt = now
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO refunds (transaction_id, refund_key, amount, reason, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id
+	`, refund.TransactionID, refund.RefundKey, refund.Amount, refund.Reason, refund.Status, now).Scan(&refund.ID)
+	if err != nil {
+		return model.Refund{}, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Refund{}, fmt.Errorf("failed to commit refund: %w", err)
+	}
+
+	return refund, nil
+}
+
+// checkRefundable memastikan refund baru bersama refund yang sudah ada (reserved) tidak melebihi nilai transaksi
+func checkRefundable(amount int, transactionAmount int, reserved int) error {
+	if amount > transactionAmount-reserved {
+		return ErrRefundExceedsAmount
+	}
+	return nil
+}
+
+func (r *refundRepository) UpdateRefundStatus(id int, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `UPDATE refunds SET status = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("refund not found")
+	}
+
+	return nil
+}
+
+func (r *refundRepository) GetRefundsByTransactionID(transactionID int) ([]model.Refund, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, transaction_id, refund_key, amount, reason, status, created_at, updated_at
+		FROM refunds
+		WHERE transaction_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []model.Refund
+	for rows.Next() {
+		var refund model.Refund
+		if err := rows.Scan(
+			&refund.ID,
+			&refund.TransactionID,
+			&refund.RefundKey,
+			&refund.Amount,
+			&refund.Reason,
+			&refund.Status,
+			&refund.CreatedAt,
+			&refund.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+// GetRefundedAmount mengembalikan total refund yang sudah berhasil
+func (r *refundRepository) GetRefundedAmount(transactionID int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var total int
+	query := `SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE transaction_id = $1 AND status = $2`
+	err := r.db.QueryRowContext(ctx, query, transactionID, model.RefundStatusSucceeded).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckRefundable(t *testing.T) {
+	tests := []struct {
+		name              string
+		amount            int
+		transactionAmount int
+		reserved          int
+		wantErr           error
+	}{
+		{name: "partial refund", amount: 4000, transactionAmount: 10000, reserved: 0},
+		{name: "full refund", amount: 10000, transactionAmount: 10000, reserved: 0},
+		{name: "rest after a partial refund", amount: 6000, transactionAmount: 10000, reserved: 4000},
+		{name: "more than the transaction", amount: 10001, transactionAmount: 10000, reserved: 0, wantErr: ErrRefundExceedsAmount},
+		{name: "more than what is left", amount: 6001, transactionAmount: 10000, reserved: 4000, wantErr: ErrRefundExceedsAmount},
+		{name: "already fully refunded", amount: 1, transactionAmount: 10000, reserved: 10000, wantErr: ErrRefundExceedsAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRefundable(tt.amount, tt.transactionAmount, tt.reserved)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkRefundable(%d, %d, %d) error = %v, want %v", tt.amount, tt.transactionAmount, tt.reserved, err, tt.wantErr)
+			}
+		})
+	}
+}
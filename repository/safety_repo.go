@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SafetyRepository adalah interface untuk antrean flag keamanan
+type SafetyRepository interface {
+	CreateFlag(flag model.SafetyFlag) (model.SafetyFlag, error)
+	GetFlags(status string, page int, limit int) ([]model.SafetyFlag, int64, error)
+	GetFlagByID(id int) (model.SafetyFlag, error)
+	ReviewFlag(id int, status string, reviewerID int, note string) (model.SafetyFlag, error)
+}
+
+type safetyRepository struct {
+	db *sql.DB
+}
+
+func NewSafetyRepository(db *sql.DB) SafetyRepository {
+	return &safetyRepository{db: db}
+}
+
+const safetyFlagColumns = `
+	id, user_id, source, COALESCE(session_id, ''), journal_id, risk_level, categories, matched_keywords,
+	method, excerpt, status, reviewed_by, COALESCE(review_note, ''), reviewed_at, created_at, updated_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSafetyFlag(row rowScanner) (model.SafetyFlag, error) {
+	var flag model.SafetyFlag
+	var journalID, reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	err := row.Scan(
+		&flag.ID,
+		&flag.UserID,
+		&flag.Source,
+		&flag.SessionID,
+		&journalID,
+		&flag.RiskLevel,
+		pq.Array(&flag.Categories),
+		pq.Array(&flag.MatchedKeywords),
+		&flag.Method,
+		&flag.Excerpt,
+		&flag.Status,
+		&reviewedBy,
+		&flag.ReviewNote,
+		&reviewedAt,
+		&flag.CreatedAt,
+		&flag.UpdatedAt,
+	)
+	if err != nil {
+		return model.SafetyFlag{}, err
+	}
+	if journalID.Valid {
+		id := int(journalID.Int64)
+		flag.JournalID = &id
+	}
+	if reviewedBy.Valid {
+		id := int(reviewedBy.Int64)
+		flag.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		flag.ReviewedAt = &reviewedAt.Time
+	}
+	return flag, nil
+}
+
+func (r *safetyRepository) CreateFlag(flag model.SafetyFlag) (model.SafetyFlag, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO safety_flags
+		(user_id, source, session_id, journal_id, risk_level, categories, matched_keywords, method, excerpt, status, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $11)
+		RETURNING id
+	`
+
+	now := time.Now()
+	flag.Status = model.SafetyFlagStatusOpen
+	flag.CreatedAt = now
+	flag.UpdatedAt = now
+	if flag.Categories == nil {
+		flag.Categories = []string{}
+	}
+	if flag.MatchedKeywords == nil {
+		flag.MatchedKeywords = []string{}
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		flag.UserID,
+		flag.Source,
+		flag.SessionID,
+		flag.JournalID,
+		flag.RiskLevel,
+		pq.Array(flag.Categories),
+		pq.Array(flag.MatchedKeywords),
+		flag.Method,
+		flag.Excerpt,
+		flag.Status,
+		now,
+	).Scan(&flag.ID)
+	if err != nil {
+		return model.SafetyFlag{}, fmt.Errorf("failed to create safety flag: %w", err)
+	}
+
+	return flag, nil
+}
+
+// GetFlags mengembalikan flag dengan status tertentu (kosong berarti semua), risiko tinggi dan terlama lebih dulu
+func (r *safetyRepository) GetFlags(status string, page int, limit int) ([]model.SafetyFlag, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM safety_flags WHERE ($1 = '' OR status = $1)`
+	if err := r.db.QueryRowContext(ctx, countQuery, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + safetyFlagColumns + `
+		FROM safety_flags
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY (risk_level = 'high') DESC, created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	flags := []model.SafetyFlag{}
+	for rows.Next() {
+		flag, err := scanSafetyFlag(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, total, rows.Err()
+}
+
+func (r *safetyRepository) GetFlagByID(id int) (model.SafetyFlag, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + safetyFlagColumns + ` FROM safety_flags WHERE id = $1`
+	flag, err := scanSafetyFlag(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.SafetyFlag{}, errors.New("safety flag not found")
+		}
+		return model.SafetyFlag{}, err
+	}
+	return flag, nil
+}
+
+func (r *safetyRepository) ReviewFlag(id int, status string, reviewerID int, note string) (model.SafetyFlag, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE safety_flags
+		SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = $4, updated_at = $4
+		WHERE id = $5
+		RETURNING ` + safetyFlagColumns
+
+	flag, err := scanSafetyFlag(r.db.QueryRowContext(ctx, query, status, reviewerID, note, time.Now(), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.SafetyFlag{}, errors.New("safety flag not found")
+		}
+		return model.SafetyFlag{}, fmt.Errorf("failed to review safety flag: %w", err)
+	}
+	return flag, nil
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrPlanNotFound dikembalikan jika produk tidak punya plan, artinya produk tersebut bukan produk langganan
+var ErrPlanNotFound = errors.New("plan not found")
+
+// SubscriptionRepository adalah interface untuk repository plan dan periode langganan
+type SubscriptionRepository interface {
+	GetPlanByProductID(productID int) (model.Plan, error)
+	GetLatestPeriodEnd(userID int, planID int) (time.Time, error)
+	CreatePeriod(period model.SubscriptionPeriod) (model.SubscriptionPeriod, bool, error)
+	GetPeriodsByUserID(userID int) ([]model.SubscriptionPeriod, error)
+	GetActiveEntitlements(userID int, at time.Time) ([]model.UserEntitlement, error)
+	HasEntitlement(userID int, entitlement string, at time.Time) (bool, error)
+	RevokePeriodByTransactionID(transactionID int) (bool, error)
+	ShortenPeriodByTransactionID(transactionID int, remainingShare float64) (bool, error)
+}
+
+// subscriptionRepository adalah implementasi dari SubscriptionRepository
+type subscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+func (r *subscriptionRepository) GetPlanByProductID(productID int) (model.Plan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT p.id, p.product_id, p.code, p.name, p.duration_days, p.grace_days,
+		       COALESCE(array_agg(pe.entitlement) FILTER (WHERE pe.entitlement IS NOT NULL), '{}'),
+		       p.created_at, p.updated_at
+		FROM plans p
+		LEFT JOIN plan_entitlements pe ON pe.plan_id = p.id
+		WHERE p.product_id = $1
+		GROUP BY p.id
+	`
+
+	var plan model.Plan
+	err := r.db.QueryRowContext(ctx, query, productID).Scan(
+		&plan.ID,
+		&plan.ProductID,
+		&plan.Code,
+		&plan.Name,
+		&plan.DurationDays,
+		&plan.GraceDays,
+		pq.Array(&plan.Entitlements),
+		&plan.CreatedAt,
+		&plan.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Plan{}, ErrPlanNotFound
+		}
+		return model.Plan{}, err
+	}
+
+	return plan, nil
+}
+
+// GetLatestPeriodEnd mengembalikan ends_at terakhir dari periode yang belum dicabut,
+// atau zero time jika user belum pernah berlangganan plan ini
+func (r *subscriptionRepository) GetLatestPeriodEnd(userID int, planID int) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT MAX(ends_at)
+		FROM subscription_periods
+		WHERE user_id = $1 AND plan_id = $2 AND revoked_at IS NULL
+	`
+
+	var endsAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, userID, planID).Scan(&endsAt); err != nil {
+		return time.Time{}, err
+	}
+	if !endsAt.Valid {
+		return time.Time{}, nil
+	}
+	return endsAt.Time, nil
+}
+
+// CreatePeriod menyimpan periode langganan. Satu transaksi hanya menghasilkan satu periode,
+// jadi callback yang terkirim ulang tidak memperpanjang langganan dua kali. Nilai bool
+// bernilai false jika periode untuk transaksi tersebut sudah ada.
+func (r *subscriptionRepository) CreatePeriod(period model.SubscriptionPeriod) (model.SubscriptionPeriod, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO subscription_periods
+		(user_id, plan_id, transaction_id, starts_at, ends_at, grace_until, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (transaction_id) DO NOTHING
+		RETURNING id
+	`
+
+	now := time.Now()
+	period.CreatedAt = now
+	period.UpdatedAt = now
+
+	err := r.db.QueryRowContext(ctx, query,
+		period.UserID,
+		period.PlanID,
+		period.TransactionID,
+		period.StartsAt,
+		period.EndsAt,
+		period.GraceUntil,
+		now,
+	).Scan(&period.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.SubscriptionPeriod{}, false, nil
+		}
+		return model.SubscriptionPeriod{}, false, fmt.Errorf("failed to create subscription period: %w", err)
+	}
+
+	return period, true, nil
+}
+
+func (r *subscriptionRepository) GetPeriodsByUserID(userID int) ([]model.SubscriptionPeriod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT sp.id, sp.user_id, sp.plan_id, p.code, sp.transaction_id, sp.starts_at, sp.ends_at,
+		       sp.grace_until, sp.revoked_at, sp.created_at, sp.updated_at
+		FROM subscription_periods sp
+		JOIN plans p ON p.id = sp.plan_id
+		WHERE sp.user_id = $1
+		ORDER BY sp.starts_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var periods []model.SubscriptionPeriod
+	for rows.Next() {
+		var period model.SubscriptionPeriod
+		var revokedAt sql.NullTime
+		if err := rows.Scan(
+			&period.ID,
+			&period.UserID,
+			&period.PlanID,
+			&period.PlanCode,
+			&period.TransactionID,
+			&period.StartsAt,
+			&period.EndsAt,
+			&period.GraceUntil,
+			&revokedAt,
+			&period.CreatedAt,
+			&period.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if revokedAt.Valid {
+			period.RevokedAt = &revokedAt.Time
+		}
+		period.Status = period.StatusAt(now)
+		periods = append(periods, period)
+	}
+
+	return periods, rows.Err()
+}
+
+// GetActiveEntitlements mengembalikan entitlement yang masih berlaku (termasuk masa tenggang)
+func (r *subscriptionRepository) GetActiveEntitlements(userID int, at time.Time) ([]model.UserEntitlement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT pe.entitlement, MAX(sp.ends_at), MAX(sp.grace_until)
+		FROM subscription_periods sp
+		JOIN plan_entitlements pe ON pe.plan_id = sp.plan_id
+		WHERE sp.user_id = $1
+		  AND sp.revoked_at IS NULL
+		  AND sp.starts_at <= $2
+		  AND sp.grace_until > $2
+		GROUP BY pe.entitlement
+		ORDER BY pe.entitlement
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entitlements []model.UserEntitlement
+	for rows.Next() {
+		var e model.UserEntitlement
+		if err := rows.Scan(&e.Entitlement, &e.ActiveUntil, &e.GraceUntil); err != nil {
+			return nil, err
+		}
+		e.InGrace = !at.Before(e.ActiveUntil)
+		entitlements = append(entitlements, e)
+	}
+
+	return entitlements, rows.Err()
+}
+
+func (r *subscriptionRepository) HasEntitlement(userID int, entitlement string, at time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM subscription_periods sp
+			JOIN plan_entitlements pe ON pe.plan_id = sp.plan_id
+			WHERE sp.user_id = $1
+			  AND pe.entitlement = $2
+			  AND sp.revoked_at IS NULL
+			  AND sp.starts_at <= $3
+			  AND sp.grace_until > $3
+		)
+	`
+
+	var ok bool
+	err := r.db.QueryRowContext(ctx, query, userID, entitlement, at).Scan(&ok)
+	return ok, err
+}
+
+// RevokePeriodByTransactionID mencabut periode langganan yang dibuat oleh sebuah transaksi.
+// Nilai bool bernilai false jika tidak ada periode aktif untuk transaksi tersebut.
+func (r *subscriptionRepository) RevokePeriodByTransactionID(transactionID int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE subscription_periods
+		SET revoked_at = $1, updated_at = $1
+		WHERE transaction_id = $2 AND revoked_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, time.Now(), transactionID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ShortenPeriodByTransactionID memendekkan periode langganan sebuah transaksi menjadi remainingShare
+// dari durasi plan, dihitung dari starts_at. Masa tenggang ikut bergeser, dan periode tidak pernah
+// diperpanjang. Nilai bool bernilai false jika tidak ada periode aktif untuk transaksi tersebut.
+func (r *subscriptionRepository) ShortenPeriodByTransactionID(transactionID int, remainingShare float64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE subscription_periods sp
+		SET ends_at = LEAST(sp.ends_at, sp.starts_at + p.duration_days * $1::float8 * INTERVAL '1 day'),
+			grace_until = LEAST(sp.grace_until, sp.starts_at + (p.duration_days * $1::float8 + p.grace_days) * INTERVAL '1 day'),
+			updated_at = $2
+		FROM plans p
+		WHERE p.id = sp.plan_id AND sp.transaction_id = $3 AND sp.revoked_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, remainingShare, time.Now(), transactionID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
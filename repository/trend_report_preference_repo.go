@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+)
+
+// TrendReportPreferenceRepository menyimpan pengaturan laporan trend otomatis per user
+type TrendReportPreferenceRepository interface {
+	// GetSettings mengembalikan pengaturan default (tanpa email, zona waktu defaultTimezone)
+	// jika user belum pernah mengubahnya
+	GetSettings(c context.Context, userID int, defaultTimezone string) (model.TrendReportSettings, error)
+	SaveSettings(c context.Context, userID int, settings model.TrendReportSettings) error
+}
+
+type trendReportPreferenceRepository struct {
+	db *sql.DB
+}
+
+func NewTrendReportPreferenceRepository(db *sql.DB) TrendReportPreferenceRepository {
+	return &trendReportPreferenceRepository{db: db}
+}
+
+func (r *trendReportPreferenceRepository) GetSettings(c context.Context, userID int, defaultTimezone string) (model.TrendReportSettings, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	var settings model.TrendReportSettings
+	query := `SELECT email_enabled, timezone FROM trend_report_preferences WHERE user_id = $1`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&settings.EmailEnabled, &settings.Timezone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TrendReportSettings{Timezone: defaultTimezone}, nil
+	}
+	if err != nil {
+		return model.TrendReportSettings{}, fmt.Errorf("failed to get trend report preferences: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *trendReportPreferenceRepository) SaveSettings(c context.Context, userID int, settings model.TrendReportSettings) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO trend_report_preferences (user_id, email_enabled, timezone, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id)
+		DO UPDATE SET email_enabled = $2, timezone = $3, updated_at = $4
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, settings.EmailEnabled, settings.Timezone, time.Now()); err != nil {
+		return fmt.Errorf("failed to save trend report preferences: %w", err)
+	}
+	return nil
+}
@@ -25,12 +25,10 @@ type UserRepoInterface interface {
 	GetAllUsers() ([]model.Users, error)
 	GetUserByID(id int) (model.Users, error)
 	UpdateUser(user model.Users) (model.Users, error)
+	UpdatePassword(id int, passwordHash string) error
 	DeleteUser(id int) error
 	GetUserByEmail(email string) (model.Users, error)
-	SaveOTP(otp *model.OTP) error
-	GetOTPByCode(code string) (*model.OTP, error)
-	DeleteOTP(code string) error
-	DeleteExpiredOTPs() error
+	OTPRepository
 }
 
 // Ensure *UserRepo implements UserRepoInterface
@@ -131,7 +129,7 @@ func (r *UserRepo) GetAllUsers() ([]model.Users, error) {
 
 func (r *UserRepo) GetUserByID(id int) (model.Users, error) {
 	query := `
-		SELECT id, name, email, password_hash, birth_year, phone, created_at, updated_at
+		SELECT id, name, email, password_hash, birth_year, phone, role, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -144,6 +142,7 @@ func (r *UserRepo) GetUserByID(id int) (model.Users, error) {
 		&user.PasswordHash,
 		&user.BirthYear,
 		&user.Phone,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -195,6 +194,29 @@ func (r *UserRepo) UpdateUser(user model.Users) (model.Users, error) {
 
 
 
+// UpdatePassword only replaces the password hash of a user
+func (r *UserRepo) UpdatePassword(id int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+
+	res, err := r.DB.Exec(query, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+
+
 // DeleteUser deletes a user from the database by ID
 func (r *UserRepo) DeleteUser(id int) error {
 	tx, err := r.DB.Begin()
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"pijar/model"
+	"pijar/utils/service"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pengaturan jendela konteks coach. Riwayat lengkap tetap disimpan di
+// ConversationContext.Messages, tetapi yang dikirim ke AI hanya ringkasan ditambah
+// beberapa giliran terakhir.
+const (
+	coachRecentTurns     = 6    // jumlah minimal giliran (pesan user + jawaban AI) yang dikirim apa adanya
+	coachSummaryBatch    = 4    // giliran lama baru diringkas setelah terkumpul sebanyak ini, agar AI tidak dipanggil tiap giliran
+	coachMaxPromptTokens = 6000 // batas perkiraan token per prompt, termasuk system prompt dan ringkasan
+
+	// Key di ConversationContext.Metadata
+	metadataSummary         = "summary"
+	metadataSummarizedCount = "summarized_count"
+)
+
+var coachSummaryOptions = service.LLMOptions{
+	SystemPrompt: "You summarize coaching conversations between a user and a mental health coach. Write a concise running summary in the language of the conversation. Keep the user's situation, feelings, goals, decisions and anything the coach promised to follow up on. Do not add advice. Answer with the summary only.",
+	Temperature:  0.2,
+	MaxTokens:    400,
+}
+
+// estimateTokens memperkirakan jumlah token dari panjang teks (sekitar 4 karakter per token)
+func estimateTokens(s string) int {
+	return utf8.RuneCountInString(s)/4 + 1
+}
+
+// buildCoachPrompt menyiapkan riwayat dan opsi yang dikirim ke AI untuk satu giliran.
+// Pesan lama yang keluar dari jendela digabung ke ringkasan di ctx.Metadata, jadi ctx
+// harus disimpan setelahnya.
+func (u *sessionUsecase) buildCoachPrompt(c context.Context, userID int, ctx *model.ConversationContext) ([]model.Message, service.LLMOptions) {
+	if ctx.Metadata == nil {
+		ctx.Metadata = make(map[string]any)
+	}
+
+	summary, _ := ctx.Metadata[metadataSummary].(string)
+	summarized := metadataInt(ctx.Metadata, metadataSummarizedCount)
+	if summarized > len(ctx.Messages) {
+		summarized = 0
+	}
+
+	// Gabungkan pesan di luar jendela terbaru ke ringkasan
+	windowStart := len(ctx.Messages) - coachRecentTurns*2
+	// Jendela selalu diawali pesan user, jawaban AI sebelumnya ikut diringkas
+	for windowStart > 0 && windowStart < len(ctx.Messages) && ctx.Messages[windowStart].Role != "user" {
+		windowStart++
+	}
+	if windowStart-summarized >= coachSummaryBatch*2 {
+		newSummary, err := u.summarize(c, summary, ctx.Messages[summarized:windowStart])
+		if err != nil {
+			// Ringkasan gagal tidak menggagalkan percakapan; pesan lama tetap terpotong oleh batas token
+			log.Printf("Failed to summarize coach session %s: %v", ctx.SessionID, err)
+		} else {
+			summary = newSummary
+			summarized = windowStart
+			ctx.Metadata[metadataSummary] = summary
+			ctx.Metadata[metadataSummarizedCount] = summarized
+		}
+	}
+
+	opts := u.withPersonalContext(c, userID, ctx, u.sessionOptions(ctx))
+	if summary != "" {
+		opts.SystemPrompt = strings.TrimSpace(opts.SystemPrompt + "\n\nSummary of the earlier conversation:\n" + summary)
+	}
+
+	messages := ctx.Messages[summarized:]
+	if maxMessages := (coachRecentTurns+coachSummaryBatch)*2 + 1; len(messages) > maxMessages {
+		messages = messages[len(messages)-maxMessages:]
+	}
+
+	// Buang pesan paling lama sampai prompt muat, pesan terakhir user selalu dikirim
+	budget := coachMaxPromptTokens - estimateTokens(opts.SystemPrompt) - opts.MaxTokens
+	used := 0
+	start := len(messages)
+	for start > 0 {
+		cost := estimateTokens(messages[start-1].Content)
+		if start < len(messages) && used+cost > budget {
+			break
+		}
+		used += cost
+		start--
+	}
+	messages = messages[start:]
+
+	// Gemini mengharuskan percakapan diawali pesan user
+	for len(messages) > 1 && messages[0].Role != "user" {
+		messages = messages[1:]
+	}
+
+	return messages, opts
+}
+
+// sessionOptions mengembalikan opsi LLM dari persona yang dipilih saat sesi dimulai. Persona yang
+// sudah diarsipkan tetap dipakai; sesi lama tanpa persona atau persona yang sudah hilang memakai default.
+func (u *sessionUsecase) sessionOptions(ctx *model.ConversationContext) service.LLMOptions {
+	if personaID := metadataInt(ctx.Metadata, model.MetadataPersonaID); personaID != 0 {
+		persona, err := u.personas.GetPersonaByID(personaID)
+		if err == nil {
+			return personaOptions(persona)
+		}
+		log.Printf("Failed to load persona %d for coach session %s: %v", personaID, ctx.SessionID, err)
+	}
+
+	persona, _ := u.personas.ResolvePersona(0)
+	return personaOptions(persona)
+}
+
+// withPersonalContext menambahkan profil user ke system prompt jika user mengaktifkan personal context.
+// Profil yang dikirim disimpan di ctx.Metadata supaya user bisa melihat apa yang dibagikan.
+func (u *sessionUsecase) withPersonalContext(c context.Context, userID int, ctx *model.ConversationContext, opts service.LLMOptions) service.LLMOptions {
+	profile, err := u.personalContext.ProfileFor(c, userID)
+	if err != nil {
+		// Coach tetap menjawab tanpa profil
+		log.Printf("Failed to build personal context for user %d: %v", userID, err)
+		return opts
+	}
+	if profile == nil || profile.IsEmpty() {
+		return opts
+	}
+
+	if ctx.Metadata == nil {
+		ctx.Metadata = make(map[string]any)
+	}
+	ctx.Metadata[model.MetadataPersonalContext] = profile
+	opts.SystemPrompt = strings.TrimSpace(opts.SystemPrompt + "\n\n" + personalContextPrompt(*profile))
+	return opts
+}
+
+// summarize memperbarui ringkasan berjalan dengan pesan-pesan yang keluar dari jendela konteks
+func (u *sessionUsecase) summarize(c context.Context, summary string, messages []model.Message) (string, error) {
+	var b strings.Builder
+	if summary != "" {
+		b.WriteString("Current summary:\n")
+		b.WriteString(summary)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("New messages to add to the summary:\n")
+	for _, msg := range messages {
+		role := "User"
+		if msg.Role == "assistant" {
+			role = "Coach"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", role, msg.Content)
+	}
+
+	newSummary, err := service.AskLLM(c, u.ai, b.String(), coachSummaryOptions)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(newSummary), nil
+}
+
+// metadataInt membaca angka dari Metadata; setelah dibaca dari JSON angka menjadi float64
+func metadataInt(metadata map[string]any, key string) int {
+	switch v := metadata[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"pijar/model"
+	"pijar/utils/service"
+)
+
+type fixedPersona struct {
+	CoachPersonaUsecase
+	persona model.CoachPersona
+}
+
+func (p fixedPersona) ResolvePersona(int) (model.CoachPersona, error) {
+	return p.persona, nil
+}
+
+type noPersonalContext struct {
+	PersonalContextUsecase
+}
+
+func (noPersonalContext) ProfileFor(context.Context, int) (*model.PersonalContext, error) {
+	return nil, nil
+}
+
+// summaryLLM mencatat prompt ringkasan yang diterima dan menjawab dengan summary atau err
+type summaryLLM struct {
+	prompts []string
+	summary string
+	err     error
+}
+
+func (l *summaryLLM) Chat(_ context.Context, messages []model.Message, _ service.LLMOptions) (string, error) {
+	l.prompts = append(l.prompts, messages[len(messages)-1].Content)
+	return l.summary, l.err
+}
+
+// turns membuat n pesan bergantian user dan assistant, diawali user
+func turns(n int) []model.Message {
+	messages := make([]model.Message, n)
+	for i := range messages {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages[i] = model.Message{Role: role, Content: fmt.Sprintf("%s message %d", role, i)}
+	}
+	return messages
+}
+
+// sized membuat pesan yang diperkirakan estimateTokens tepat sebanyak tokens
+func sized(role string, tokens int) model.Message {
+	return model.Message{Role: role, Content: strings.Repeat("x", (tokens-1)*4)}
+}
+
+func TestBuildCoachPrompt(t *testing.T) {
+	persona := model.CoachPersona{SystemPrompt: "You are a coach.", MaxTokens: 500}
+	budget := coachMaxPromptTokens - estimateTokens(persona.SystemPrompt) - persona.MaxTokens
+	longSummary := strings.Repeat("y", 2000*4)
+
+	history := turns(22)
+	atBudget := []model.Message{sized("user", 2000), sized("assistant", 2000), sized("user", budget-4000)}
+	overBudget := []model.Message{sized("user", 2001), sized("assistant", 2000), sized("user", budget-4000)}
+	withLongSummary := []model.Message{
+		sized("user", 1000), sized("assistant", 1000), sized("user", 1000), sized("assistant", 1000), sized("user", 1000),
+	}
+
+	tests := []struct {
+		name           string
+		messages       []model.Message
+		metadata       map[string]any
+		llmErr         error
+		wantMessages   []model.Message
+		wantSummary    string
+		wantSummarized int
+		wantLLMCalls   int
+	}{
+		{
+			name:         "empty history",
+			wantMessages: []model.Message{},
+		},
+		{
+			name:         "window exactly at the budget is sent whole",
+			messages:     atBudget,
+			wantMessages: atBudget,
+		},
+		{
+			name:         "one token over the budget drops the oldest turn",
+			messages:     overBudget,
+			wantMessages: overBudget[2:],
+		},
+		{
+			name:         "history below the summary batch is not summarized",
+			messages:     history[:18],
+			wantMessages: history[:18],
+		},
+		{
+			name:           "overflow is folded into the summary",
+			messages:       history[:20],
+			wantMessages:   history[8:20],
+			wantSummary:    "new summary",
+			wantSummarized: 8,
+			wantLLMCalls:   1,
+		},
+		{
+			name:         "overflow with a failed summary keeps older messages",
+			messages:     history[:20],
+			llmErr:       errors.New("AI down"),
+			wantMessages: history[:20],
+			wantLLMCalls: 1,
+		},
+		{
+			name:           "summary plus window under the budget",
+			messages:       history[:22],
+			metadata:       map[string]any{metadataSummary: "earlier summary", metadataSummarizedCount: float64(8)},
+			wantMessages:   history[8:22],
+			wantSummary:    "earlier summary",
+			wantSummarized: 8,
+		},
+		{
+			name:         "summary counts against the budget",
+			messages:     withLongSummary,
+			metadata:     map[string]any{metadataSummary: longSummary},
+			wantMessages: withLongSummary[2:],
+			wantSummary:  longSummary,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &summaryLLM{summary: "new summary", err: tt.llmErr}
+			u := &sessionUsecase{
+				personas:        fixedPersona{persona: persona},
+				personalContext: noPersonalContext{},
+				ai:              llm,
+			}
+			ctx := &model.ConversationContext{SessionID: "session-1", Messages: tt.messages, Metadata: tt.metadata}
+
+			messages, opts := u.buildCoachPrompt(context.Background(), 1, ctx)
+
+			if len(messages) != len(tt.wantMessages) || (len(messages) > 0 && !reflect.DeepEqual(messages, tt.wantMessages)) {
+				t.Errorf("messages = %d messages starting with %.20q, want %d", len(messages), firstContent(messages), len(tt.wantMessages))
+			}
+			if len(llm.prompts) != tt.wantLLMCalls {
+				t.Errorf("summary calls = %d, want %d", len(llm.prompts), tt.wantLLMCalls)
+			}
+
+			summary, _ := ctx.Metadata[metadataSummary].(string)
+			if summary != tt.wantSummary {
+				t.Errorf("metadata summary = %.30q, want %.30q", summary, tt.wantSummary)
+			}
+			if got := metadataInt(ctx.Metadata, metadataSummarizedCount); got != tt.wantSummarized {
+				t.Errorf("summarized count = %d, want %d", got, tt.wantSummarized)
+			}
+
+			wantPrompt := persona.SystemPrompt
+			if tt.wantSummary != "" {
+				wantPrompt += "\n\nSummary of the earlier conversation:\n" + tt.wantSummary
+			}
+			if opts.SystemPrompt != wantPrompt {
+				t.Errorf("system prompt = %.60q, want %.60q", opts.SystemPrompt, wantPrompt)
+			}
+
+			used := estimateTokens(opts.SystemPrompt) + opts.MaxTokens
+			for _, msg := range messages {
+				used += estimateTokens(msg.Content)
+			}
+			if used > coachMaxPromptTokens {
+				t.Errorf("prompt uses %d tokens, budget is %d", used, coachMaxPromptTokens)
+			}
+		})
+	}
+
+	t.Run("summary prompt covers only messages outside the window", func(t *testing.T) {
+		llm := &summaryLLM{summary: "new summary"}
+		u := &sessionUsecase{personas: fixedPersona{persona: persona}, personalContext: noPersonalContext{}, ai: llm}
+		u.buildCoachPrompt(context.Background(), 1, &model.ConversationContext{Messages: history[:20]})
+
+		if len(llm.prompts) != 1 {
+			t.Fatalf("summary calls = %d, want 1", len(llm.prompts))
+		}
+		prompt := llm.prompts[0]
+		if !strings.Contains(prompt, "User: user message 0") || !strings.Contains(prompt, "Coach: assistant message 7") {
+			t.Errorf("summary prompt is missing old messages: %q", prompt)
+		}
+		if strings.Contains(prompt, "message 8") {
+			t.Errorf("summary prompt includes messages from the window: %q", prompt)
+		}
+	})
+}
+
+func firstContent(messages []model.Message) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[0].Content
+}
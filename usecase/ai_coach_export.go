@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"pijar/model"
+	"time"
+)
+
+// Label pembicara pada transkrip yang diekspor
+const (
+	transcriptUserSpeaker  = "Pengguna"
+	transcriptCoachSpeaker = "AI Coach"
+)
+
+// ExportSession menyusun transkrip lengkap sesi milik user. Pesan diambil dari coach_messages
+// agar setiap pesan punya waktu; sesi lama yang belum punya baris dibaca dari konteks JSON.
+func (u *sessionUsecase) ExportSession(c context.Context, userID int, sessionID string) (model.CoachTranscript, error) {
+	session, err := u.repo.GetSession(c, userID, sessionID)
+	if err != nil {
+		return model.CoachTranscript{}, err
+	}
+
+	ctx, err := u.repo.GetOrCreateConversationContext(c, userID, sessionID)
+	if err != nil {
+		return model.CoachTranscript{}, fmt.Errorf("gagal mendapatkan konteks: %w", err)
+	}
+
+	transcript := model.CoachTranscript{
+		SessionID:  session.SessionID,
+		Title:      session.Title,
+		StartedAt:  session.CreatedAt,
+		ExportedAt: time.Now(),
+		Messages:   []model.CoachTranscriptMessage{},
+	}
+	if personaID := metadataInt(ctx.Metadata, model.MetadataPersonaID); personaID != 0 {
+		if persona, err := u.personas.GetPersonaByID(personaID); err == nil {
+			transcript.Persona = persona.Name
+		}
+	}
+
+	messages, err := u.repo.GetSessionMessages(c, userID, sessionID)
+	if err != nil {
+		return model.CoachTranscript{}, err
+	}
+	for _, msg := range messages {
+		createdAt := msg.CreatedAt
+		transcript.Messages = append(transcript.Messages, model.CoachTranscriptMessage{
+			Role:      msg.Role,
+			Speaker:   transcriptSpeaker(msg.Role),
+			Content:   msg.Content,
+			Timestamp: &createdAt,
+		})
+	}
+
+	if len(transcript.Messages) == 0 {
+		for _, msg := range ctx.Messages {
+			if msg.Role != "user" && msg.Role != "assistant" {
+				continue
+			}
+			transcript.Messages = append(transcript.Messages, model.CoachTranscriptMessage{
+				Role:    msg.Role,
+				Speaker: transcriptSpeaker(msg.Role),
+				Content: msg.Content,
+			})
+		}
+	}
+
+	return transcript, nil
+}
+
+func transcriptSpeaker(role string) string {
+	if role == "user" {
+		return transcriptUserSpeaker
+	}
+	return transcriptCoachSpeaker
+}
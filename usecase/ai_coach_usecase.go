@@ -3,27 +3,52 @@ package usecase
 import (
 	"fmt"
 	"context"
+	"math"
 	"pijar/model"
 	"pijar/repository"
 	"pijar/utils/service"
+	"strings"
+)
+
+const (
+	defaultSessionPageLimit = 20
+	maxSessionPageLimit     = 100
+	sessionTitleMaxRunes    = 60
 )
 
 type SessionUsecase interface {
-	StartSession(c context.Context, userID int, userInput string) (string, string, error)
+	// StartSession memulai sesi dengan persona tertentu; personaID 0 berarti persona default
+	StartSession(c context.Context, userID int, personaID int, userInput string) (string, string, error)
 	ContinueSession(c context.Context, userID int, sessionID string, userInput string) (string, error)
+	ContinueSessionStream(c context.Context, userID int, sessionID string, userInput string, onChunk func(chunk string) error) (string, error)
 	GetSessionHistory(c context.Context, userID int, sessionID string, limit int) ([]model.Message, error)
 	GetUserSessions(c context.Context, userID int) ([]model.CoachSession, error)
+	// ListSessions mengembalikan sesi milik user untuk ditampilkan dan dilanjutkan kembali
+	ListSessions(c context.Context, filter model.CoachSessionFilter) (model.CoachSessionListResponse, error)
+	GetSession(c context.Context, userID int, sessionID string) (model.CoachSession, error)
+	// ExportSession mengembalikan transkrip lengkap sesi untuk diunduh sebagai PDF, Markdown atau JSON
+	ExportSession(c context.Context, userID int, sessionID string) (model.CoachTranscript, error)
+	UpdateSession(c context.Context, userID int, sessionID string, req model.CoachSessionUpdateRequest) (model.CoachSession, error)
 	DeleteSession(c context.Context, userID int, sessionID string) error
 }
 
 type sessionUsecase struct {
-	repo repository.CoachSessionRepository
-	ai   *service.GeminiClient
+	repo            repository.CoachSessionRepository
+	personas        CoachPersonaUsecase
+	personalContext PersonalContextUsecase
+	ai              service.LLMProvider
+	safety          SafetyUsecase
 }
 
-func (u *sessionUsecase) StartSession(c context.Context, userID int, userInput string) (string, string, error) {
-	// Buat sesi baru
-	sessionID, err := u.repo.CreateSession(c, userID, userInput)
+func (u *sessionUsecase) StartSession(c context.Context, userID int, personaID int, userInput string) (string, string, error) {
+	// Pilih persona sebelum sesi dibuat agar persona yang tidak valid tidak meninggalkan sesi kosong
+	persona, err := u.personas.ResolvePersona(personaID)
+	if err != nil {
+		return "", "", fmt.Errorf("gagal memilih persona: %w", err)
+	}
+
+	// Buat sesi baru, judul awal diambil dari pesan pertama
+	sessionID, err := u.repo.CreateSession(c, userID, sessionTitle(userInput), userInput)
 	if err != nil {
 		return "", "", fmt.Errorf("gagal membuat sesi: %w", err)
 	}
@@ -34,32 +59,29 @@ func (u *sessionUsecase) StartSession(c context.Context, userID int, userInput s
 		return "", "", fmt.Errorf("gagal mendapatkan konteks: %w", err)
 	}
 
+	// Persona disimpan di metadata supaya giliran berikutnya memakai persona yang sama
+	if persona.ID != 0 {
+		ctx.Metadata[model.MetadataPersonaID] = persona.ID
+	}
+
 	// Tambahkan pesan user ke konteks
 	ctx.Messages = append(ctx.Messages, model.Message{
 		Role:    "user",
 		Content: userInput,
 	})
 
+	// Periksa risiko krisis sebelum pesan dikirim ke AI
+	inputCheck := u.screenInput(c, userID, sessionID, userInput)
+
 	// Dapatkan respons AI dengan konteks
-	aiResp, err := u.ai.GetAIResponseWithContext(ctx.Messages)
+	opts := u.withPersonalContext(c, userID, ctx, personaOptions(persona))
+	aiResp, err := u.reply(c, userID, sessionID, ctx.Messages, opts, inputCheck)
 	if err != nil {
-		return "", "", fmt.Errorf("gagal mendapatkan respons AI: %w", err)
-	}
-
-	// Tambahkan respons AI ke konteks
-	ctx.Messages = append(ctx.Messages, model.Message{
-		Role:    "assistant",
-		Content: aiResp,
-	})
-
-	// Simpan konteks yang diperbarui
-	if err := u.repo.SaveConversationContext(c, ctx); err != nil {
-		return "", "", fmt.Errorf("gagal menyimpan konteks: %w", err)
+		return "", "", err
 	}
 
-	// Update respons ke DB
-	if err := u.repo.UpdateSessionResponse(c, sessionID, aiResp); err != nil {
-		return "", "", fmt.Errorf("gagal memperbarui respons: %w", err)
+	if err := u.saveTurn(c, userID, ctx, userInput, aiResp); err != nil {
+		return "", "", err
 	}
 
 	return sessionID, aiResp, nil
@@ -79,30 +101,190 @@ func (u *sessionUsecase) ContinueSession(c context.Context, userID int, sessionI
 	}
 	ctx.Messages = append(ctx.Messages, userMessage)
 
-	// Dapatkan respons AI dengan konteks
-	aiResp, err := u.ai.GetAIResponseWithContext(ctx.Messages)
+	inputCheck := u.screenInput(c, userID, sessionID, userInput)
+
+	// Dapatkan respons AI dengan ringkasan dan giliran terakhir
+	messages, opts := u.buildCoachPrompt(c, userID, ctx)
+	aiResp, err := u.reply(c, userID, sessionID, messages, opts, inputCheck)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.saveTurn(c, userID, ctx, userInput, aiResp); err != nil {
+		return "", err
+	}
+
+	return aiResp, nil
+}
+
+// ContinueSessionStream sama seperti ContinueSession, tetapi jawaban dikirim ke onChunk per kalimat
+// selama AI masih menulis. Percakapan hanya disimpan jika jawaban selesai; jika client
+// terputus (c dibatalkan) atau onChunk gagal, tidak ada yang disimpan.
+// Setiap kalimat baru dikirim setelah lolos pemeriksaan kata kunci krisis. Begitu ada kata kunci,
+// sisa jawaban ditahan sampai jawaban lengkap diperiksa ScreenOutput. Untuk pesan user berisiko
+// sedang, seluruh jawaban ditahan dan diperiksa dulu sebelum dikirim sebagai satu potongan.
+// Jika jawaban lengkap ternyata berisiko, yang disimpan dan dikembalikan adalah respons krisis,
+// sehingga client perlu memakai jawaban akhir sebagai pengganti potongan yang sudah diterima.
+func (u *sessionUsecase) ContinueSessionStream(c context.Context, userID int, sessionID string, userInput string, onChunk func(chunk string) error) (string, error) {
+	ctx, err := u.repo.GetOrCreateConversationContext(c, userID, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("gagal mendapatkan konteks: %w", err)
+	}
+
+	ctx.Messages = append(ctx.Messages, model.Message{
+		Role:    "user",
+		Content: userInput,
+	})
+
+	inputCheck := u.screenInput(c, userID, sessionID, userInput)
+
+	var aiResp string
+	if inputCheck.RiskLevel == model.SafetyRiskHigh {
+		// AI tidak dipakai, respons krisis dikirim sebagai satu potongan
+		aiResp = u.safety.CrisisResponse()
+		if err := onChunk(aiResp); err != nil {
+			return "", err
+		}
+	} else {
+		stream := &screenedStream{
+			safety:  u.safety,
+			onChunk: onChunk,
+			hold:    inputCheck.RiskLevel != model.SafetyRiskNone,
+		}
+		messages, opts := u.buildCoachPrompt(c, userID, ctx)
+		opts = withSafetyGuidance(opts, inputCheck)
+		aiResp, err = service.StreamLLM(c, u.ai, messages, opts, stream.write)
+		if err != nil {
+			return "", fmt.Errorf("gagal mendapatkan respons AI: %w", err)
+		}
+		screened := u.screenOutput(context.WithoutCancel(c), userID, sessionID, aiResp)
+		if screened == aiResp {
+			// Jawaban lolos pemeriksaan, kirim bagian yang masih ditahan
+			if err := stream.flush(); err != nil {
+				return "", err
+			}
+		}
+		aiResp = screened
+	}
+
+	// Jawaban sudah lengkap, jadi tetap disimpan walaupun client terputus setelahnya
+	if err := u.saveTurn(context.WithoutCancel(c), userID, ctx, userInput, aiResp); err != nil {
+		return "", err
+	}
+
+	return aiResp, nil
+}
+
+// screenedStream meneruskan jawaban AI ke onChunk per kalimat. Sebelum setiap kalimat dikirim,
+// seluruh teks yang akan terkirim diperiksa kata kunci krisisnya; jika ada, atau jika hold
+// sejak awal, sisa jawaban ditahan di pending sampai jawaban lengkap selesai diperiksa.
+type screenedStream struct {
+	safety  SafetyUsecase
+	onChunk func(chunk string) error
+	hold    bool
+	sent    strings.Builder
+	pending strings.Builder
+}
+
+func (s *screenedStream) write(chunk string) error {
+	s.pending.WriteString(chunk)
+	if s.hold {
+		return nil
+	}
+
+	pending := s.pending.String()
+	end := strings.LastIndexAny(pending, ".!?\n")
+	if end < 0 {
+		return nil
+	}
+	sentences := pending[:end+1]
+	if s.safety.HasCrisisKeywords(s.sent.String() + sentences) {
+		s.hold = true
+		return nil
+	}
+
+	s.pending.Reset()
+	s.pending.WriteString(pending[end+1:])
+	s.sent.WriteString(sentences)
+	return s.onChunk(sentences)
+}
+
+// flush mengirim sisa jawaban yang masih ditahan, dipanggil setelah jawaban lengkap lolos ScreenOutput
+func (s *screenedStream) flush() error {
+	if s.pending.Len() == 0 {
+		return nil
+	}
+	rest := s.pending.String()
+	s.pending.Reset()
+	s.sent.WriteString(rest)
+	return s.onChunk(rest)
+}
+
+// screenInput memeriksa pesan user; flag dicatat oleh SafetyUsecase
+func (u *sessionUsecase) screenInput(c context.Context, userID int, sessionID string, userInput string) model.SafetyAssessment {
+	return u.safety.ScreenInput(c, model.SafetyCheck{
+		UserID:    userID,
+		Source:    model.SafetySourceCoachInput,
+		SessionID: sessionID,
+		Text:      userInput,
+	})
+}
+
+// screenOutput memeriksa jawaban AI dan menggantinya dengan respons krisis jika berisiko tinggi
+func (u *sessionUsecase) screenOutput(c context.Context, userID int, sessionID string, aiResp string) string {
+	check := u.safety.ScreenOutput(c, model.SafetyCheck{
+		UserID:    userID,
+		Source:    model.SafetySourceCoachOutput,
+		SessionID: sessionID,
+		Text:      aiResp,
+	})
+	if check.RiskLevel == model.SafetyRiskHigh {
+		return u.safety.CrisisResponse()
+	}
+	return aiResp
+}
+
+// reply meminta jawaban AI dengan memperhatikan hasil pemeriksaan pesan user.
+// Pesan berisiko tinggi langsung dijawab dengan respons krisis tanpa memanggil AI.
+func (u *sessionUsecase) reply(c context.Context, userID int, sessionID string, messages []model.Message, opts service.LLMOptions, inputCheck model.SafetyAssessment) (string, error) {
+	if inputCheck.RiskLevel == model.SafetyRiskHigh {
+		return u.safety.CrisisResponse(), nil
+	}
+
+	aiResp, err := u.ai.Chat(c, messages, withSafetyGuidance(opts, inputCheck))
 	if err != nil {
 		return "", fmt.Errorf("gagal mendapatkan respons AI: %w", err)
 	}
 
-	// Tambahkan respons AI ke konteks
-	aiMessage := model.Message{
+	return u.screenOutput(c, userID, sessionID, aiResp), nil
+}
+
+// withSafetyGuidance menambahkan arahan agar AI menjawab lebih hati-hati untuk pesan berisiko sedang
+func withSafetyGuidance(opts service.LLMOptions, inputCheck model.SafetyAssessment) service.LLMOptions {
+	if inputCheck.RiskLevel == model.SafetyRiskElevated {
+		opts.SystemPrompt = strings.TrimSpace(opts.SystemPrompt + "\n\n" + elevatedRiskSystemPrompt)
+	}
+	return opts
+}
+
+// saveTurn menambahkan jawaban AI ke konteks lalu menyimpan percakapan dan konteksnya
+func (u *sessionUsecase) saveTurn(c context.Context, userID int, ctx *model.ConversationContext, userInput string, aiResp string) error {
+	ctx.Messages = append(ctx.Messages, model.Message{
 		Role:    "assistant",
 		Content: aiResp,
-	}
-	ctx.Messages = append(ctx.Messages, aiMessage)
+	})
 
 	// Simpan pesan user dan respons AI ke database
-	if err := u.repo.SaveConversation(c, userID, sessionID, userInput, aiResp); err != nil {
-		return "", fmt.Errorf("gagal menyimpan percakapan: %w", err)
+	if err := u.repo.SaveConversation(c, userID, ctx.SessionID, userInput, aiResp); err != nil {
+		return fmt.Errorf("gagal menyimpan percakapan: %w", err)
 	}
 
 	// Simpan konteks yang diperbarui
 	if err := u.repo.SaveConversationContext(c, ctx); err != nil {
-		return "", fmt.Errorf("gagal menyimpan konteks: %w", err)
+		return fmt.Errorf("gagal menyimpan konteks: %w", err)
 	}
 
-	return aiResp, nil
+	return nil
 }
 
 func (u *sessionUsecase) GetSessionHistory(c context.Context, userID int, sessionID string, limit int) ([]model.Message, error) {
@@ -113,13 +295,75 @@ func (u *sessionUsecase) GetUserSessions(c context.Context, userID int) ([]model
 	return u.repo.GetUserSessions(c, userID)
 }
 
+func (u *sessionUsecase) ListSessions(c context.Context, filter model.CoachSessionFilter) (model.CoachSessionListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultSessionPageLimit
+	}
+	if filter.Limit > maxSessionPageLimit {
+		filter.Limit = maxSessionPageLimit
+	}
+
+	sessions, total, err := u.repo.ListUserSessions(c, filter)
+	if err != nil {
+		return model.CoachSessionListResponse{}, err
+	}
+
+	return model.CoachSessionListResponse{
+		Sessions: sessions,
+		Pagination: model.Pagination{
+			CurrentPage: filter.Page,
+			TotalPages:  int(math.Ceil(float64(total) / float64(filter.Limit))),
+			TotalItems:  total,
+			Limit:       filter.Limit,
+		},
+	}, nil
+}
+
+func (u *sessionUsecase) GetSession(c context.Context, userID int, sessionID string) (model.CoachSession, error) {
+	return u.repo.GetSession(c, userID, sessionID)
+}
+
+func (u *sessionUsecase) UpdateSession(c context.Context, userID int, sessionID string, req model.CoachSessionUpdateRequest) (model.CoachSession, error) {
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return model.CoachSession{}, fmt.Errorf("title cannot be empty")
+		}
+		req.Title = &title
+	}
+	return u.repo.UpdateSession(c, userID, sessionID, req)
+}
+
 func (u *sessionUsecase) DeleteSession(c context.Context, userID int, sessionID string) error {
 	return u.repo.DeleteSession(c, userID, sessionID)
 }
 
-func NewSessionUsecase(repo repository.CoachSessionRepository, aiClient *service.GeminiClient) SessionUsecase {
+// sessionTitle membuat judul dari pesan pertama: satu baris, dipotong di batas kata
+func sessionTitle(input string) string {
+	title := strings.Join(strings.Fields(input), " ")
+	runes := []rune(title)
+	if len(runes) <= sessionTitleMaxRunes {
+		return title
+	}
+
+	cut := string(runes[:sessionTitleMaxRunes])
+	if i := strings.LastIndex(cut, " "); i > sessionTitleMaxRunes/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "..."
+}
+
+// NewSessionUsecase membuat usecase coach; personas menentukan system prompt dan pengaturan generasi
+// tiap sesi, personalContext menambahkan profil user yang opt-in, safety memeriksa pesan user dan jawaban AI
+func NewSessionUsecase(repo repository.CoachSessionRepository, personas CoachPersonaUsecase, personalContext PersonalContextUsecase, ai service.LLMProvider, safety SafetyUsecase) SessionUsecase {
 	return &sessionUsecase{
-		repo: repo,
-		ai:   aiClient,
+		repo:            repo,
+		personas:        personas,
+		personalContext: personalContext,
+		ai:              ai,
+		safety:          safety,
 	}
 }
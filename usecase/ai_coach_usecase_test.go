@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// keywordSafety menganggap teks berisi kata kunci krisis jika memuat keyword
+type keywordSafety struct {
+	SafetyUsecase
+	keyword string
+}
+
+func (s keywordSafety) HasCrisisKeywords(text string) bool {
+	return strings.Contains(text, s.keyword)
+}
+
+func TestScreenedStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		hold        bool
+		chunks      []string
+		wantSent    []string
+		wantPending string
+	}{
+		{
+			name:     "complete sentences are sent",
+			chunks:   []string{"Halo, ", "apa kabar? Aku ", "di sini."},
+			wantSent: []string{"Halo, apa kabar?", " Aku di sini."},
+		},
+		{
+			name:        "unfinished sentence waits for its end",
+			chunks:      []string{"Ceritakan ", "lebih lanjut"},
+			wantPending: "Ceritakan lebih lanjut",
+		},
+		{
+			name:        "sentence with a crisis keyword holds the rest",
+			chunks:      []string{"Aku paham. ", "Jangan menyakiti diri. ", "Hubungi 119."},
+			wantSent:    []string{"Aku paham."},
+			wantPending: " Jangan menyakiti diri. Hubungi 119.",
+		},
+		{
+			name:        "keyword split across chunks is caught",
+			chunks:      []string{"Jangan menya", "kiti diri."},
+			wantPending: "Jangan menyakiti diri.",
+		},
+		{
+			name:        "held stream sends nothing",
+			hold:        true,
+			chunks:      []string{"Aku paham. ", "Tarik napas."},
+			wantPending: "Aku paham. Tarik napas.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			stream := &screenedStream{
+				safety: keywordSafety{keyword: "menyakiti diri"},
+				onChunk: func(chunk string) error {
+					sent = append(sent, chunk)
+					return nil
+				},
+				hold: tt.hold,
+			}
+			for _, chunk := range tt.chunks {
+				if err := stream.write(chunk); err != nil {
+					t.Fatalf("write(%q) error = %v", chunk, err)
+				}
+			}
+
+			if !reflect.DeepEqual(sent, tt.wantSent) {
+				t.Errorf("sent = %q, want %q", sent, tt.wantSent)
+			}
+			if got := stream.pending.String(); got != tt.wantPending {
+				t.Errorf("pending = %q, want %q", got, tt.wantPending)
+			}
+
+			if err := stream.flush(); err != nil {
+				t.Fatalf("flush() error = %v", err)
+			}
+			if got, want := strings.Join(sent, ""), strings.Join(tt.chunks, ""); got != want {
+				t.Errorf("after flush sent = %q, want the full answer %q", got, want)
+			}
+		})
+	}
+}
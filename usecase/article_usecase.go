@@ -23,18 +23,20 @@ type ArticleUsecase interface {
 
 type articleUsecase struct {
 	articleRepo repository.ArticleRepository
+	generator   service.ArticleGenerator
 }
 
-func NewArticleUsecase(articleRepo repository.ArticleRepository) ArticleUsecase {
+func NewArticleUsecase(articleRepo repository.ArticleRepository, generator service.ArticleGenerator) ArticleUsecase {
 	return &articleUsecase{
 		articleRepo: articleRepo,
+		generator:   generator,
 	}
 }
 
 // CreateArticle handles generating and creating multiple articles from preferences
 func (u *articleUsecase) CreateArticle(c *gin.Context, preferences []string) error {
-	// Generate articles using Deepseek
-	generatedArticles, err := service.GenerateArticles(c, preferences)
+	// Generate articles using the configured LLM provider
+	generatedArticles, err := u.generator.GenerateArticles(c, preferences)
 	if err != nil {
 		return fmt.Errorf("failed to generate articles: %w", err)
 	}
@@ -3,25 +3,37 @@ package usecase
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"pijar/model"
 	"pijar/repository"
 	"pijar/utils/service"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type AuthUsecase struct {
-	userRepo   repository.UserRepoInterface
-	jwtService service.JwtService
+	userRepo        repository.UserRepoInterface
+	sessionRepo     repository.AuthSessionRepository
+	jwtService      service.JwtService
+	refreshDuration time.Duration
+	mailer          service.Mailer
+	emailRenderer   *service.EmailRenderer
 }
 
-func NewAuthUsecase(userRepo repository.UserRepoInterface, jwtService service.JwtService) *AuthUsecase {
+func NewAuthUsecase(userRepo repository.UserRepoInterface, sessionRepo repository.AuthSessionRepository, jwtService service.JwtService, refreshDuration time.Duration, mailer service.Mailer, emailRenderer *service.EmailRenderer) *AuthUsecase {
 	return &AuthUsecase{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		jwtService:      jwtService,
+		refreshDuration: refreshDuration,
+		mailer:          mailer,
+		emailRenderer:   emailRenderer,
 	}
 }
 
-func (u *AuthUsecase) Login(email, password string) (model.AuthResponse, error) {
+func (u *AuthUsecase) Login(email, password, userAgent, ipAddress string) (model.AuthResponse, error) {
 	user, err := u.userRepo.GetUserByEmail(email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -35,14 +47,117 @@ func (u *AuthUsecase) Login(email, password string) (model.AuthResponse, error)
 		return model.AuthResponse{}, errors.New("incorrect password")
 	}
 
-	token, err := u.jwtService.CreateToken(user)
+	return u.startSession(user, userAgent, ipAddress)
+}
+
+// Refresh menukar refresh token yang masih berlaku dengan pasangan access token
+// dan refresh token baru. Refresh token lama langsung tidak berlaku lagi.
+func (u *AuthUsecase) Refresh(refreshToken string) (model.AuthResponse, error) {
+	if refreshToken == "" {
+		return model.AuthResponse{}, errors.New("refresh token is required")
+	}
+
+	oldHash := service.HashToken(refreshToken)
+	session, err := u.sessionRepo.GetSessionByRefreshTokenHash(oldHash)
+	if err != nil {
+		return model.AuthResponse{}, errors.New("invalid refresh token")
+	}
+
+	if session.RevokedAt != nil {
+		return model.AuthResponse{}, errors.New("session has been revoked")
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return model.AuthResponse{}, errors.New("refresh token has expired")
+	}
+
+	user, err := u.userRepo.GetUserByID(session.UserID)
+	if err != nil {
+		return model.AuthResponse{}, errors.New("failed to retrieve user")
+	}
+
+	newRefreshToken, err := service.GenerateSecureToken(32)
+	if err != nil {
+		return model.AuthResponse{}, err
+	}
+	refreshExpiresAt := time.Now().Add(u.refreshDuration)
+
+	err = u.sessionRepo.RotateRefreshToken(session.SessionID, oldHash, service.HashToken(newRefreshToken), refreshExpiresAt)
+	if err != nil {
+		return model.AuthResponse{}, errors.New("invalid refresh token")
+	}
+
+	token, err := u.jwtService.CreateToken(user, session.SessionID)
+	if err != nil {
+		return model.AuthResponse{}, err
+	}
+
+	return model.AuthResponse{
+		Token:            token,
+		RefreshToken:     newRefreshToken,
+		ExpiresAt:        time.Now().Add(u.jwtService.TokenDuration()),
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             user,
+	}, nil
+}
+
+// Logout mencabut sesi yang sedang dipakai sehingga access token dan refresh token-nya tidak berlaku
+func (u *AuthUsecase) Logout(sessionID string) error {
+	if sessionID == "" {
+		return errors.New("session not found")
+	}
+	if err := u.sessionRepo.RevokeSession(sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// LogoutAll mencabut seluruh sesi milik user, misalnya saat token dicuri
+func (u *AuthUsecase) LogoutAll(userID int) error {
+	if err := u.sessionRepo.RevokeAllUserSessions(userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// CleanupExpiredSessions menghapus sesi login yang sudah kedaluwarsa atau dicabut
+func (u *AuthUsecase) CleanupExpiredSessions() error {
+	if err := u.sessionRepo.DeleteExpiredSessions(); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return nil
+}
+
+// startSession membuat sesi login baru dan menerbitkan access token serta refresh token-nya
+func (u *AuthUsecase) startSession(user model.Users, userAgent, ipAddress string) (model.AuthResponse, error) {
+	refreshToken, err := service.GenerateSecureToken(32)
+	if err != nil {
+		return model.AuthResponse{}, err
+	}
+
+	session, err := u.sessionRepo.CreateSession(model.AuthSession{
+		SessionID:        uuid.New().String(),
+		UserID:           user.ID,
+		RefreshTokenHash: service.HashToken(refreshToken),
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		ExpiresAt:        time.Now().Add(u.refreshDuration),
+	})
+	if err != nil {
+		return model.AuthResponse{}, err
+	}
+
+	token, err := u.jwtService.CreateToken(user, session.SessionID)
 	if err != nil {
 		return model.AuthResponse{}, err
 	}
 
 	return model.AuthResponse{
-		Token: token,
-		User:  user,
+		Token:            token,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        time.Now().Add(u.jwtService.TokenDuration()),
+		RefreshExpiresAt: session.ExpiresAt,
+		User:             user,
 	}, nil
 }
 
@@ -70,6 +185,7 @@ func (u *AuthUsecase) Register(user model.Users, plainPassword string) (map[stri
 	}
 
 	log.Printf("User successfully registered!")
+	sendWelcomeEmail(u.emailRenderer, u.mailer, createdUser)
 
 	return map[string]interface{}{
 		"message": "Registration successful",
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"pijar/model"
+	"pijar/repository"
+	"pijar/utils/service"
+)
+
+// fakeSessionRepo menyimpan sesi di memori dan merotasi refresh token seperti query UPDATE-nya
+type fakeSessionRepo struct {
+	repository.AuthSessionRepository
+	sessions map[string]model.AuthSession
+}
+
+func (r *fakeSessionRepo) GetSessionByRefreshTokenHash(tokenHash string) (model.AuthSession, error) {
+	for _, session := range r.sessions {
+		if session.RefreshTokenHash == tokenHash {
+			return session, nil
+		}
+	}
+	return model.AuthSession{}, sql.ErrNoRows
+}
+
+func (r *fakeSessionRepo) RotateRefreshToken(sessionID string, oldTokenHash string, newTokenHash string, expiresAt time.Time) error {
+	session, ok := r.sessions[sessionID]
+	if !ok || session.RefreshTokenHash != oldTokenHash || session.RevokedAt != nil {
+		return errors.New("session not found or already rotated")
+	}
+	session.RefreshTokenHash = newTokenHash
+	session.ExpiresAt = expiresAt
+	r.sessions[sessionID] = session
+	return nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepoInterface
+	users map[int]model.Users
+}
+
+func (r *fakeUserRepo) GetUserByID(id int) (model.Users, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return model.Users{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func TestRefresh(t *testing.T) {
+	const refreshToken = "current-refresh-token"
+	revokedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		token     string
+		expiresIn time.Duration
+		revokedAt *time.Time
+		wantErr   string
+	}{
+		{
+			name:      "valid token is rotated",
+			token:     refreshToken,
+			expiresIn: time.Hour,
+		},
+		{
+			name:      "empty token",
+			token:     "",
+			expiresIn: time.Hour,
+			wantErr:   "refresh token is required",
+		},
+		{
+			name:      "unknown token",
+			token:     "someone-elses-token",
+			expiresIn: time.Hour,
+			wantErr:   "invalid refresh token",
+		},
+		{
+			name:      "revoked session",
+			token:     refreshToken,
+			expiresIn: time.Hour,
+			revokedAt: &revokedAt,
+			wantErr:   "session has been revoked",
+		},
+		{
+			name:      "expired token",
+			token:     refreshToken,
+			expiresIn: -time.Minute,
+			wantErr:   "refresh token has expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &fakeSessionRepo{sessions: map[string]model.AuthSession{
+				"session-1": {
+					SessionID:        "session-1",
+					UserID:           7,
+					RefreshTokenHash: service.HashToken(refreshToken),
+					ExpiresAt:        time.Now().Add(tt.expiresIn),
+					RevokedAt:        tt.revokedAt,
+				},
+			}}
+			users := &fakeUserRepo{users: map[int]model.Users{7: {ID: 7, Email: "user@example.com", Role: "USER"}}}
+			jwt := service.NewJwtService("test-signing-key", "pijar", 15*time.Minute)
+			uc := NewAuthUsecase(users, sessions, jwt, 24*time.Hour, nil, nil)
+
+			resp, err := uc.Refresh(tt.token)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Refresh() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+			if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == refreshToken {
+				t.Fatalf("Refresh() = %+v, want a new access token and refresh token", resp)
+			}
+
+			// Refresh token lama tidak boleh dipakai lagi, yang baru boleh
+			if _, err := uc.Refresh(refreshToken); err == nil {
+				t.Error("old refresh token still works after rotation")
+			}
+			if _, err := uc.Refresh(resp.RefreshToken); err != nil {
+				t.Errorf("new refresh token rejected: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"pijar/model"
+	"pijar/repository"
+	"pijar/utils/service"
+	"strings"
+)
+
+const (
+	defaultPersonaTemperature = 0.7
+	defaultPersonaMaxTokens   = 500
+	maxPersonaMaxTokens       = 4096
+)
+
+// ErrPersonaUnavailable dikembalikan jika persona yang dipilih sudah diarsipkan
+var ErrPersonaUnavailable = errors.New("persona is not available")
+
+// fallbackCoachPersona dipakai jika database belum punya persona default
+var fallbackCoachPersona = model.CoachPersona{
+	Name:         "General coach",
+	SystemPrompt: "You are a professional mental health coach. Your role is to provide empathetic support and guidance. When users need help with decision-making, use the cost-benefit analysis framework to help them think through their options. Maintain a cheerful and supportive tone, but use emoticons sparingly. Keep your responses concise and focused. Avoid repeating yourself. Your goal is to help users gain clarity and make informed decisions about their mental well-being.",
+	Temperature:  defaultPersonaTemperature,
+	MaxTokens:    defaultPersonaMaxTokens,
+	Status:       model.CoachPersonaStatusActive,
+}
+
+// Nama bahasa untuk instruksi jawaban, kode lain dipakai apa adanya
+var personaLanguages = map[string]string{
+	"id": "Indonesian (Bahasa Indonesia)",
+	"en": "English",
+}
+
+// CoachPersonaUsecase interface for coach persona operations
+type CoachPersonaUsecase interface {
+	GetActivePersonas() ([]model.CoachPersona, error)
+	GetAllPersonas() ([]model.CoachPersona, error)
+	GetPersonaByID(id int) (model.CoachPersona, error)
+	// ResolvePersona memilih persona untuk sesi baru; id 0 berarti persona default
+	ResolvePersona(id int) (model.CoachPersona, error)
+	CreatePersona(req model.CoachPersonaRequest) (model.CoachPersona, error)
+	UpdatePersona(id int, req model.CoachPersonaRequest) (model.CoachPersona, error)
+	ArchivePersona(id int) error
+}
+
+type coachPersonaUsecase struct {
+	personaRepo repository.CoachPersonaRepository
+}
+
+func NewCoachPersonaUsecase(personaRepo repository.CoachPersonaRepository) CoachPersonaUsecase {
+	return &coachPersonaUsecase{
+		personaRepo: personaRepo,
+	}
+}
+
+// GetActivePersonas mengembalikan persona yang bisa dipilih user
+func (p *coachPersonaUsecase) GetActivePersonas() ([]model.CoachPersona, error) {
+	return p.personaRepo.GetPersonas(false)
+}
+
+// GetAllPersonas mengembalikan semua persona termasuk yang sudah diarsipkan
+func (p *coachPersonaUsecase) GetAllPersonas() ([]model.CoachPersona, error) {
+	return p.personaRepo.GetPersonas(true)
+}
+
+func (p *coachPersonaUsecase) GetPersonaByID(id int) (model.CoachPersona, error) {
+	return p.personaRepo.GetPersonaByID(id)
+}
+
+func (p *coachPersonaUsecase) ResolvePersona(id int) (model.CoachPersona, error) {
+	if id == 0 {
+		persona, err := p.personaRepo.GetDefaultPersona()
+		if err != nil {
+			log.Printf("No default coach persona, using built-in prompt: %v", err)
+			return fallbackCoachPersona, nil
+		}
+		return persona, nil
+	}
+
+	persona, err := p.personaRepo.GetPersonaByID(id)
+	if err != nil {
+		return model.CoachPersona{}, err
+	}
+	if persona.Status != model.CoachPersonaStatusActive {
+		return model.CoachPersona{}, ErrPersonaUnavailable
+	}
+	return persona, nil
+}
+
+func (p *coachPersonaUsecase) CreatePersona(req model.CoachPersonaRequest) (model.CoachPersona, error) {
+	persona, err := personaFromRequest(req, model.CoachPersona{
+		Temperature: defaultPersonaTemperature,
+		MaxTokens:   defaultPersonaMaxTokens,
+		Status:      model.CoachPersonaStatusActive,
+	})
+	if err != nil {
+		return model.CoachPersona{}, err
+	}
+	return p.personaRepo.CreatePersona(persona)
+}
+
+func (p *coachPersonaUsecase) UpdatePersona(id int, req model.CoachPersonaRequest) (model.CoachPersona, error) {
+	existing, err := p.personaRepo.GetPersonaByID(id)
+	if err != nil {
+		return model.CoachPersona{}, err
+	}
+
+	// Field yang tidak dikirim tidak diubah, agar persona yang diarsipkan tidak aktif lagi
+	// dan persona default tidak kehilangan status default-nya
+	persona, err := personaFromRequest(req, existing)
+	if err != nil {
+		return model.CoachPersona{}, err
+	}
+	persona.ID = id
+
+	return p.personaRepo.UpdatePersona(persona)
+}
+
+// ArchivePersona menyembunyikan persona dari pilihan user. Persona tidak dihapus karena
+// sesi yang sudah berjalan tetap memakai persona yang dipilih saat sesi dimulai.
+func (p *coachPersonaUsecase) ArchivePersona(id int) error {
+	return p.personaRepo.UpdatePersonaStatus(id, model.CoachPersonaStatusArchived)
+}
+
+// personaFromRequest memvalidasi input. Field yang tidak dikirim diambil dari base,
+// yaitu nilai default saat create atau persona yang lama saat update.
+func personaFromRequest(req model.CoachPersonaRequest, base model.CoachPersona) (model.CoachPersona, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return model.CoachPersona{}, errors.New("persona name is required")
+	}
+	systemPrompt := strings.TrimSpace(req.SystemPrompt)
+	if systemPrompt == "" {
+		return model.CoachPersona{}, errors.New("system_prompt is required")
+	}
+	temperature := base.Temperature
+	if req.Temperature != nil {
+		temperature = *req.Temperature
+	}
+	if temperature < 0 || temperature > 2 {
+		return model.CoachPersona{}, errors.New("temperature must be between 0 and 2")
+	}
+	if req.MaxTokens < 0 || req.MaxTokens > maxPersonaMaxTokens {
+		return model.CoachPersona{}, fmt.Errorf("max_tokens must be between 1 and %d", maxPersonaMaxTokens)
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = base.MaxTokens
+	}
+
+	language := strings.ToLower(strings.TrimSpace(req.Language))
+	if len(language) > 10 {
+		return model.CoachPersona{}, fmt.Errorf("invalid language code: %s", req.Language)
+	}
+
+	status := req.Status
+	if status == "" {
+		status = base.Status
+	}
+	if status != model.CoachPersonaStatusActive && status != model.CoachPersonaStatusArchived {
+		return model.CoachPersona{}, fmt.Errorf("invalid persona status: %s", req.Status)
+	}
+	isDefault := base.IsDefault
+	if req.IsDefault != nil {
+		isDefault = *req.IsDefault
+	}
+	if isDefault && status != model.CoachPersonaStatusActive {
+		return model.CoachPersona{}, errors.New("default persona must be active")
+	}
+
+	return model.CoachPersona{
+		Name:         name,
+		Description:  strings.TrimSpace(req.Description),
+		SystemPrompt: systemPrompt,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+		Language:     language,
+		IsDefault:    isDefault,
+		Status:       status,
+	}, nil
+}
+
+// personaOptions mengubah persona menjadi opsi LLM, termasuk instruksi bahasa jawaban
+func personaOptions(persona model.CoachPersona) service.LLMOptions {
+	systemPrompt := persona.SystemPrompt
+	if persona.Language != "" {
+		language, ok := personaLanguages[persona.Language]
+		if !ok {
+			language = persona.Language
+		}
+		systemPrompt += "\n\nAlways reply in " + language + "."
+	}
+	return service.LLMOptions{
+		SystemPrompt: systemPrompt,
+		Temperature:  persona.Temperature,
+		MaxTokens:    persona.MaxTokens,
+	}
+}
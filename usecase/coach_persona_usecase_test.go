@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"testing"
+
+	"pijar/model"
+	"pijar/repository"
+)
+
+type fakePersonaRepo struct {
+	repository.CoachPersonaRepository
+	persona model.CoachPersona
+}
+
+func (r *fakePersonaRepo) GetPersonaByID(id int) (model.CoachPersona, error) {
+	return r.persona, nil
+}
+
+func (r *fakePersonaRepo) UpdatePersona(persona model.CoachPersona) (model.CoachPersona, error) {
+	r.persona = persona
+	return persona, nil
+}
+
+func TestUpdatePersonaKeepsOmittedFields(t *testing.T) {
+	notDefault := false
+
+	tests := []struct {
+		name          string
+		existing      model.CoachPersona
+		req           model.CoachPersonaRequest
+		wantStatus    string
+		wantDefault   bool
+		wantMaxTokens int
+	}{
+		{
+			name:          "archived persona stays archived",
+			existing:      model.CoachPersona{Temperature: 0.3, MaxTokens: 800, Status: model.CoachPersonaStatusArchived},
+			req:           model.CoachPersonaRequest{Name: "CBT coach", SystemPrompt: "Use CBT."},
+			wantStatus:    model.CoachPersonaStatusArchived,
+			wantMaxTokens: 800,
+		},
+		{
+			name:          "default persona stays default",
+			existing:      model.CoachPersona{Temperature: 0.7, MaxTokens: 500, IsDefault: true, Status: model.CoachPersonaStatusActive},
+			req:           model.CoachPersonaRequest{Name: "General coach", SystemPrompt: "Be kind."},
+			wantStatus:    model.CoachPersonaStatusActive,
+			wantDefault:   true,
+			wantMaxTokens: 500,
+		},
+		{
+			name:          "explicit is_default false clears the flag",
+			existing:      model.CoachPersona{Temperature: 0.7, MaxTokens: 500, IsDefault: true, Status: model.CoachPersonaStatusActive},
+			req:           model.CoachPersonaRequest{Name: "General coach", SystemPrompt: "Be kind.", IsDefault: &notDefault, MaxTokens: 300},
+			wantStatus:    model.CoachPersonaStatusActive,
+			wantMaxTokens: 300,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewCoachPersonaUsecase(&fakePersonaRepo{persona: tt.existing})
+
+			got, err := uc.UpdatePersona(1, tt.req)
+			if err != nil {
+				t.Fatalf("UpdatePersona() error = %v", err)
+			}
+			if got.Status != tt.wantStatus || got.IsDefault != tt.wantDefault || got.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("UpdatePersona() = status %s, default %v, max_tokens %d; want %s, %v, %d",
+					got.Status, got.IsDefault, got.MaxTokens, tt.wantStatus, tt.wantDefault, tt.wantMaxTokens)
+			}
+			if got.Temperature != tt.existing.Temperature {
+				t.Errorf("UpdatePersona() temperature = %v, want %v", got.Temperature, tt.existing.Temperature)
+			}
+		})
+	}
+}
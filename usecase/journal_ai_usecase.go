@@ -6,40 +6,112 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"pijar/model"
 	"pijar/repository"
 	"pijar/utils/service"
 )
 
+var (
+	// ErrAnalysisPending dikembalikan jika analisis jurnal belum selesai dan masih ada di antrean
+	ErrAnalysisPending = errors.New("journal analysis is queued, check its status later")
+	ErrJournalNotOwned = errors.New("unauthorized: journal does not belong to user")
+)
+
 type JournalAIUsecase interface {
-	AnalyzeJournal(ctx context.Context, req *model.AnalysisRequest) (*model.AnalysisResponse, error)
+	JournalAnalysisQueue
+	// RequestAnalysis mengantrekan analisis jurnal, kecuali analisis untuk isi jurnal saat ini sudah ada
+	RequestAnalysis(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error)
+	GetAnalysisStatus(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error)
 	GetJournalAnalysis(ctx context.Context, journalID int, userID int) (*model.AnalysisResponse, error)
-	ReanalyzeJournal(ctx context.Context, journalID int, userID int) (*model.AnalysisResponse, error)
+	ReanalyzeJournal(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error)
+	// ClaimAnalysisJob dan ProcessAnalysisJob dipakai oleh worker antrean analisis
+	ClaimAnalysisJob(ctx context.Context) (*model.JournalAnalysisJob, error)
+	ProcessAnalysisJob(ctx context.Context, job model.JournalAnalysisJob) error
 	GetUserAnalyses(ctx context.Context, userID int, limit int) ([]*model.JournalAnalysis, error)
 	GetAnalysisWithJournal(ctx context.Context, userID int, limit int) ([]map[string]interface{}, error)
-	GenerateTrendAnalysis(ctx context.Context, userID int, periodType string, days int) (*model.TrendResponse, error)
+	GenerateTrendAnalysis(ctx context.Context, req *model.TrendRequest) (*model.TrendResponse, error)
 	GetTrendHistory(ctx context.Context, userID int, periodType string) ([]*model.TrendAnalysis, error)
 	GetSentimentChart(ctx context.Context, userID int, days int) ([]map[string]interface{}, error)
 }
 
+// JournalAnalysisStore adalah method repository.JournalAnalysisRepository yang dipakai usecase journal AI
+type JournalAnalysisStore interface {
+	GetByJournalID(journalID int) (*model.JournalAnalysis, error)
+	GetTrendsByUserID(userID int, periodType string) ([]*model.TrendAnalysis, error)
+	GetAnalysisWithJournal(userID int, limit int) ([]map[string]interface{}, error)
+	GetSentimentTrend(userID int, days int) ([]map[string]interface{}, error)
+}
+
 type journalAIUsecase struct {
-	repo         repository.JournalAnalysisRepository
-	journalRepo  repository.JournalRepository
-	aiService    *service.JournalAnalysisService
+	repo        JournalAnalysisStore
+	journalRepo repository.JournalRepository
+	jobRepo     repository.JournalAnalysisJobRepository
+	aiService   *service.JournalAnalysisService
+	safety      SafetyUsecase
+	maxAttempts int
 }
 
-func NewJournalAIUsecase(repo repository.JournalAnalysisRepository, journalRepo repository.JournalRepository, aiService *service.JournalAnalysisService) JournalAIUsecase {
+func NewJournalAIUsecase(repo JournalAnalysisStore, journalRepo repository.JournalRepository, jobRepo repository.JournalAnalysisJobRepository, aiService *service.JournalAnalysisService, safety SafetyUsecase, maxAttempts int) JournalAIUsecase {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
 	return &journalAIUsecase{
 		repo:        repo,
 		journalRepo: journalRepo,
+		jobRepo:     jobRepo,
 		aiService:   aiService,
+		safety:      safety,
+		maxAttempts: maxAttempts,
 	}
 }
 
-func (u *journalAIUsecase) AnalyzeJournal(ctx context.Context, req *model.AnalysisRequest) (*model.AnalysisResponse, error) {
+// analyzeScreened menganalisis jurnal dengan screening keamanan pada isi jurnal dan hasil AI.
+// Hasil AI yang berisiko tinggi disensor sebelum disimpan, sehingga analisis tersimpan yang
+// dibaca kembali lewat GetAnalysisStatus atau GetJournalAnalysis juga sudah aman.
+func (u *journalAIUsecase) analyzeScreened(ctx context.Context, req *model.AnalysisRequest) (*model.AnalysisResponse, error) {
+	var journalID *int
+	if req.JournalID != 0 {
+		journalID = &req.JournalID
+	}
+
+	// Screen the journal entry itself
+	inputCheck := u.safety.ScreenInput(ctx, model.SafetyCheck{
+		UserID:    req.UserID,
+		Source:    model.SafetySourceJournal,
+		JournalID: journalID,
+		Text:      strings.Join([]string{req.Title, req.Feeling, req.Content}, "\n"),
+	})
+
 	// Use the journal AI service to analyze the journal entry
-	return u.aiService.AnalyzeJournalEntry(req)
+	resp, err := u.aiService.AnalyzeJournalEntry(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Screen the AI insights before they reach the user
+	outputCheck := u.safety.ScreenOutput(ctx, model.SafetyCheck{
+		UserID:    req.UserID,
+		Source:    model.SafetySourceJournalAnalysis,
+		JournalID: journalID,
+		Text:      resp.JournalAnalysis.Insights + "\n" + resp.JournalAnalysis.Recommendations,
+	})
+	if outputCheck.RiskLevel == model.SafetyRiskHigh {
+		resp.JournalAnalysis.Insights = ""
+		resp.JournalAnalysis.Recommendations = u.safety.CrisisResponse()
+		resp.ActionItems = nil
+	}
+
+	if inputCheck.RiskLevel == model.SafetyRiskHigh || outputCheck.RiskLevel == model.SafetyRiskHigh {
+		support := u.safety.CrisisSupport()
+		resp.CrisisSupport = &support
+	}
+
+	if err := u.aiService.SaveAnalysis(resp.JournalAnalysis); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 func (u *journalAIUsecase) GetJournalAnalysis(ctx context.Context, journalID int, userID int) (*model.AnalysisResponse, error) {
@@ -51,47 +123,27 @@ func (u *journalAIUsecase) GetJournalAnalysis(ctx context.Context, journalID int
 
 	// If analysis exists, return it
 	if analysis != nil {
-		return &model.AnalysisResponse{
-			JournalAnalysis: analysis,
-		}, nil
-	}
-
-	// Get the journal content
-	journal, err := u.journalRepo.FindByID(ctx, journalID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get journal: %w", err)
+		if analysis.UserID != userID {
+			return nil, ErrJournalNotOwned
+		}
+		return u.analysisResponse(ctx, analysis), nil
 	}
 
-	// Verify that the journal belongs to the user
-	if journal.UserID != userID {
-		return nil, errors.New("unauthorized: journal does not belong to user")
+	// Belum ada analisis: jurnal dimasukkan ke antrean dan worker yang menganalisisnya
+	if _, err := u.ownedJournal(ctx, journalID, userID); err != nil {
+		return nil, err
 	}
-
-	// Create analysis request
-	req := &model.AnalysisRequest{
-		JournalID: journalID,
-		UserID:    userID,
-		Title:     journal.Judul,
-		Content:   journal.Isi,
-		Feeling:   journal.Perasaan,
+	if _, err := u.EnqueueAnalysis(ctx, journalID, userID); err != nil {
+		return nil, err
 	}
-
-	return u.AnalyzeJournal(ctx, req)
+	return nil, ErrAnalysisPending
 }
 
-func (u *journalAIUsecase) ReanalyzeJournal(ctx context.Context, journalID int, userID int) (*model.AnalysisResponse, error) {
-	analysis, err := u.repo.GetByJournalID(journalID)
-	if err != nil {
+func (u *journalAIUsecase) ReanalyzeJournal(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error) {
+	if _, err := u.ownedJournal(ctx, journalID, userID); err != nil {
 		return nil, err
 	}
-
-	// Verify ownership - convert userID to int for comparison
-	if int(analysis.UserID) != userID {
-		return nil, errors.New("unauthorized access to journal analysis")
-	}
-
-	// TODO: Implement reanalysis logic using AI client
-	return &model.AnalysisResponse{JournalAnalysis: analysis}, nil
+	return u.EnqueueAnalysis(ctx, journalID, userID)
 }
 
 func (u *journalAIUsecase) GetUserAnalyses(ctx context.Context, userID int, limit int) ([]*model.JournalAnalysis, error) {
@@ -105,8 +157,11 @@ func (u *journalAIUsecase) GetUserAnalyses(ctx context.Context, userID int, limi
 
 	// For each journal, get its analysis
 	for _, journal := range journals {
-		// Get the analysis (this will create one if it doesn't exist)
+		// Get the analysis (journals without one are queued for analysis)
 		resp, err := u.GetJournalAnalysis(ctx, journal.ID, userID)
+		if errors.Is(err, ErrAnalysisPending) {
+			continue
+		}
 		if err != nil {
 			// Log the error but continue with other journals
 			log.Printf("Warning: failed to get/analyze journal %d: %v", journal.ID, err)
@@ -128,9 +183,8 @@ func (u *journalAIUsecase) GetAnalysisWithJournal(ctx context.Context, userID in
 	return u.repo.GetAnalysisWithJournal(userID, limit)
 }
 
-func (u *journalAIUsecase) GenerateTrendAnalysis(ctx context.Context, userID int, periodType string, days int) (*model.TrendResponse, error) {
-	// TODO: Implement trend analysis logic
-	return nil, errors.New("not implemented")
+func (u *journalAIUsecase) GenerateTrendAnalysis(ctx context.Context, req *model.TrendRequest) (*model.TrendResponse, error) {
+	return u.aiService.GenerateTrendAnalysis(req)
 }
 
 func (u *journalAIUsecase) GetTrendHistory(ctx context.Context, userID int, periodType string) ([]*model.TrendAnalysis, error) {
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"pijar/model"
+	"pijar/utils/service"
+	"time"
+)
+
+const (
+	journalAnalysisRetryBaseDelay = 30 * time.Second
+	journalAnalysisRetryMaxDelay  = 30 * time.Minute
+	// Job running yang lebih lama dari ini dianggap ditinggal worker yang mati dan diambil lagi.
+	// Harus lebih lama dari satu panggilan AI termasuk retry di transport.
+	journalAnalysisJobLease = 10 * time.Minute
+)
+
+// JournalAnalysisQueue menerima jurnal yang perlu dianalisis oleh worker di background
+type JournalAnalysisQueue interface {
+	EnqueueAnalysis(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error)
+}
+
+func (u *journalAIUsecase) EnqueueAnalysis(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error) {
+	job, err := u.jobRepo.Enqueue(ctx, journalID, userID, u.maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	return &model.JournalAnalysisStatus{JournalAnalysisJob: job}, nil
+}
+
+func (u *journalAIUsecase) RequestAnalysis(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error) {
+	journal, err := u.ownedJournal(ctx, journalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Analisis yang dibuat setelah perubahan terakhir jurnal masih berlaku, AI tidak perlu dipanggil lagi
+	if analysis, _ := u.repo.GetByJournalID(journalID); analysis != nil && !analysis.AnalyzedAt.Before(journal.UpdatedAt) {
+		status, err := u.GetAnalysisStatus(ctx, journalID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if status.Status == model.JournalAnalysisDone {
+			return status, nil
+		}
+	}
+
+	return u.EnqueueAnalysis(ctx, journalID, userID)
+}
+
+func (u *journalAIUsecase) GetAnalysisStatus(ctx context.Context, journalID int, userID int) (*model.JournalAnalysisStatus, error) {
+	if _, err := u.ownedJournal(ctx, journalID, userID); err != nil {
+		return nil, err
+	}
+
+	analysis, err := u.repo.GetByJournalID(journalID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check for existing analysis: %w", err)
+	}
+
+	job, err := u.jobRepo.GetByJournalID(ctx, journalID)
+	if err != nil {
+		// Analisis yang dibuat sebelum ada antrean tidak punya job
+		if analysis == nil {
+			return nil, err
+		}
+		job = model.JournalAnalysisJob{
+			JournalID:  journalID,
+			UserID:     userID,
+			Status:     model.JournalAnalysisDone,
+			RunAfter:   analysis.AnalyzedAt,
+			FinishedAt: &analysis.AnalyzedAt,
+			CreatedAt:  analysis.AnalyzedAt,
+			UpdatedAt:  analysis.AnalyzedAt,
+		}
+	}
+
+	status := &model.JournalAnalysisStatus{JournalAnalysisJob: job}
+	if job.Status == model.JournalAnalysisDone && analysis != nil {
+		status.Analysis = u.analysisResponse(ctx, analysis)
+	}
+	return status, nil
+}
+
+func (u *journalAIUsecase) ClaimAnalysisJob(ctx context.Context) (*model.JournalAnalysisJob, error) {
+	return u.jobRepo.ClaimNext(ctx, journalAnalysisJobLease)
+}
+
+// ProcessAnalysisJob menganalisis isi jurnal saat ini dan mencatat hasilnya di antrean. Job yang gagal
+// dicoba lagi dengan backoff sampai max_attempts. Jika ctx dibatalkan karena server berhenti,
+// job dikembalikan ke antrean tanpa menghabiskan percobaan.
+func (u *journalAIUsecase) ProcessAnalysisJob(ctx context.Context, job model.JournalAnalysisJob) error {
+	resp, err := u.runAnalysisJob(ctx, job)
+
+	result := model.JournalAnalysisJobResult{
+		Status:   model.JournalAnalysisDone,
+		Attempts: job.Attempts,
+	}
+	switch {
+	case err == nil:
+		result.Crisis = resp.CrisisSupport != nil
+	case ctx.Err() != nil:
+		result.Status = model.JournalAnalysisQueued
+		result.Attempts = job.Attempts - 1
+		result.Crisis = job.Crisis
+		log.Printf("Journal analysis for journal %d interrupted, returned to queue: %v", job.JournalID, err)
+		err = nil
+	case errors.Is(err, sql.ErrNoRows) || job.Attempts >= job.MaxAttempts:
+		result.Status = model.JournalAnalysisFailed
+		result.Error = err.Error()
+		result.Crisis = job.Crisis
+	default:
+		result.Status = model.JournalAnalysisQueued
+		result.RetryDelay = journalAnalysisRetryDelay(job.Attempts, err)
+		result.Error = err.Error()
+		result.Crisis = job.Crisis
+	}
+
+	// Hasil tetap dicatat walaupun ctx sudah dibatalkan saat shutdown
+	if finishErr := u.jobRepo.Finish(context.WithoutCancel(ctx), job.ID, job.Lease, result); finishErr != nil {
+		return finishErr
+	}
+	if err != nil {
+		return fmt.Errorf("journal %d attempt %d/%d: %w", job.JournalID, job.Attempts, job.MaxAttempts, err)
+	}
+	return nil
+}
+
+func (u *journalAIUsecase) runAnalysisJob(ctx context.Context, job model.JournalAnalysisJob) (*model.AnalysisResponse, error) {
+	// Job yang ditinggal worker lain bisa sudah melewati batas percobaan
+	if job.Attempts > job.MaxAttempts {
+		return nil, errors.New("worker stopped before the analysis finished")
+	}
+
+	journal, err := u.journalRepo.FindByID(ctx, job.JournalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal: %w", err)
+	}
+
+	req := &model.AnalysisRequest{
+		JournalID: journal.ID,
+		UserID:    journal.UserID,
+		Title:     journal.Judul,
+		Content:   journal.Isi,
+		Feeling:   journal.Perasaan,
+	}
+
+	// Isi jurnal bisa sudah berubah sejak analisis terakhir, jadi analisis lama selalu diganti
+	return u.analyzeScreened(ctx, req)
+}
+
+// ownedJournal mengambil jurnal dan memastikan jurnal itu milik user
+func (u *journalAIUsecase) ownedJournal(ctx context.Context, journalID int, userID int) (*model.Journal, error) {
+	journal, err := u.journalRepo.FindByID(ctx, journalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal: %w", err)
+	}
+	if journal.UserID != userID {
+		return nil, ErrJournalNotOwned
+	}
+	return journal, nil
+}
+
+// analysisResponse melengkapi analisis tersimpan dengan ringkasan dan info krisis dari job yang
+// membuatnya. Hasil AI yang berisiko sudah disensor sebelum disimpan oleh analyzeScreened.
+func (u *journalAIUsecase) analysisResponse(ctx context.Context, analysis *model.JournalAnalysis) *model.AnalysisResponse {
+	resp := u.aiService.BuildResponse(analysis)
+	if job, err := u.jobRepo.GetByJournalID(ctx, analysis.JournalID); err == nil && job.Crisis {
+		support := u.safety.CrisisSupport()
+		resp.CrisisSupport = &support
+		resp.ActionItems = nil
+	}
+	return resp
+}
+
+// journalAnalysisRetryDelay menggandakan jeda setiap percobaan, atau memakai Retry-After dari provider jika lebih lama
+func journalAnalysisRetryDelay(attempt int, err error) time.Duration {
+	delay := journalAnalysisRetryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > journalAnalysisRetryMaxDelay {
+		delay = journalAnalysisRetryMaxDelay
+	}
+	if retryAfter := service.LLMRetryAfter(err); retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
@@ -0,0 +1,398 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"pijar/model"
+	"pijar/repository"
+	"pijar/utils/service"
+)
+
+const riskyInsight = "kamu sebaiknya menyakiti dirimu"
+
+// fakeAnalysisStore menyimpan analisis di memori seperti tabel journal_analyses
+type fakeAnalysisStore struct {
+	analyses map[int]model.JournalAnalysis
+	nextID   int
+}
+
+func newFakeAnalysisStore() *fakeAnalysisStore {
+	return &fakeAnalysisStore{analyses: make(map[int]model.JournalAnalysis)}
+}
+
+func (s *fakeAnalysisStore) Save(analysis *model.JournalAnalysis) error {
+	s.nextID++
+	analysis.ID = s.nextID
+	s.analyses[analysis.JournalID] = *analysis
+	return nil
+}
+
+func (s *fakeAnalysisStore) UpdateAnalysis(analysis *model.JournalAnalysis) error {
+	s.analyses[analysis.JournalID] = *analysis
+	return nil
+}
+
+func (s *fakeAnalysisStore) GetByJournalID(journalID int) (*model.JournalAnalysis, error) {
+	analysis, ok := s.analyses[journalID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &analysis, nil
+}
+
+func (s *fakeAnalysisStore) SaveTrend(trend *model.TrendAnalysis) error { return nil }
+
+func (s *fakeAnalysisStore) GetByUserID(userID int, limit int) ([]*model.JournalAnalysis, error) {
+	return nil, nil
+}
+
+func (s *fakeAnalysisStore) GetByUserIDBetween(userID int, start, end time.Time) ([]*model.JournalAnalysis, error) {
+	return nil, nil
+}
+
+func (s *fakeAnalysisStore) GetTrendsByUserID(userID int, periodType string) ([]*model.TrendAnalysis, error) {
+	return nil, nil
+}
+
+func (s *fakeAnalysisStore) GetAnalysisWithJournal(userID int, limit int) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (s *fakeAnalysisStore) GetSentimentTrend(userID int, days int) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+type fakeJournalRepo struct {
+	repository.JournalRepository
+	journals map[int]model.Journal
+}
+
+func (r *fakeJournalRepo) FindByID(ctx context.Context, id int) (*model.Journal, error) {
+	journal, ok := r.journals[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &journal, nil
+}
+
+type fakeJobRepo struct {
+	repository.JournalAnalysisJobRepository
+	jobs     map[int]model.JournalAnalysisJob
+	finished []model.JournalAnalysisJobResult
+}
+
+func (r *fakeJobRepo) Finish(ctx context.Context, id, lease int, result model.JournalAnalysisJobResult) error {
+	r.finished = append(r.finished, result)
+	for journalID, job := range r.jobs {
+		if job.ID == id && job.Lease == lease {
+			job.Status = result.Status
+			job.Attempts = result.Attempts
+			job.LastError = result.Error
+			job.Crisis = result.Crisis
+			r.jobs[journalID] = job
+		}
+	}
+	return nil
+}
+
+func (r *fakeJobRepo) GetByJournalID(ctx context.Context, journalID int) (model.JournalAnalysisJob, error) {
+	job, ok := r.jobs[journalID]
+	if !ok {
+		return model.JournalAnalysisJob{}, sql.ErrNoRows
+	}
+	return job, nil
+}
+
+// fakeSafety menilai jawaban AI berisiko tinggi jika berisi riskyInsight
+type fakeSafety struct {
+	SafetyUsecase
+}
+
+func (fakeSafety) ScreenInput(ctx context.Context, check model.SafetyCheck) model.SafetyAssessment {
+	return model.SafetyAssessment{RiskLevel: model.SafetyRiskNone}
+}
+
+func (fakeSafety) ScreenOutput(ctx context.Context, check model.SafetyCheck) model.SafetyAssessment {
+	if strings.Contains(check.Text, riskyInsight) {
+		return model.SafetyAssessment{RiskLevel: model.SafetyRiskHigh}
+	}
+	return model.SafetyAssessment{RiskLevel: model.SafetyRiskNone}
+}
+
+func (fakeSafety) CrisisSupport() model.CrisisSupport {
+	return model.CrisisSupport{Message: "crisis support"}
+}
+
+func (fakeSafety) CrisisResponse() string {
+	return "crisis response"
+}
+
+type fakeLLM struct {
+	response string
+	err      error
+}
+
+func (l fakeLLM) Chat(ctx context.Context, messages []model.Message, opts service.LLMOptions) (string, error) {
+	return l.response, l.err
+}
+
+func analysisJSON(insights string) string {
+	return fmt.Sprintf(`{
+		"sentiment_score": -0.6,
+		"emotions": ["sadness", "exhaustion"],
+		"keywords": ["kerja", "tidur"],
+		"themes": ["pekerjaan"],
+		"insights": %q,
+		"recommendations": "Istirahat yang cukup."
+	}`, insights)
+}
+
+func TestProcessAnalysisJobStoresScreenedAnalysis(t *testing.T) {
+	tests := []struct {
+		name                string
+		insights            string
+		wantInsights        string
+		wantRecommendations string
+		wantCrisis          bool
+	}{
+		{
+			name:                "safe analysis is stored as is",
+			insights:            "Kamu tampak lelah karena pekerjaan.",
+			wantInsights:        "Kamu tampak lelah karena pekerjaan.",
+			wantRecommendations: "Istirahat yang cukup.",
+		},
+		{
+			name:                "high risk analysis is redacted before it is stored",
+			insights:            "Menurutku " + riskyInsight + ".",
+			wantInsights:        "",
+			wantRecommendations: "crisis response",
+			wantCrisis:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			const journalID, userID = 10, 7
+
+			store := newFakeAnalysisStore()
+			journalRepo := &fakeJournalRepo{journals: map[int]model.Journal{
+				journalID: {ID: journalID, UserID: userID, Judul: "Hari berat", Isi: "Capek sekali hari ini.", Perasaan: "sedih"},
+			}}
+			job := model.JournalAnalysisJob{
+				ID:          1,
+				JournalID:   journalID,
+				UserID:      userID,
+				Status:      model.JournalAnalysisRunning,
+				Attempts:    1,
+				MaxAttempts: 5,
+				Lease:       1,
+			}
+			jobRepo := &fakeJobRepo{jobs: map[int]model.JournalAnalysisJob{journalID: job}}
+			aiService := service.NewJournalAnalysisService(fakeLLM{response: analysisJSON(tt.insights)}, service.LLMOptions{}, store)
+			uc := NewJournalAIUsecase(store, journalRepo, jobRepo, aiService, fakeSafety{}, 5)
+
+			if err := uc.ProcessAnalysisJob(ctx, job); err != nil {
+				t.Fatalf("ProcessAnalysisJob() error = %v", err)
+			}
+
+			stored := store.analyses[journalID]
+			if strings.Contains(stored.Insights+stored.Recommendations, riskyInsight) {
+				t.Fatalf("stored analysis contains the unsafe AI text: %+v", stored)
+			}
+
+			status, err := uc.GetAnalysisStatus(ctx, journalID, userID)
+			if err != nil {
+				t.Fatalf("GetAnalysisStatus() error = %v", err)
+			}
+			if status.Status != model.JournalAnalysisDone {
+				t.Fatalf("status = %q, want %q", status.Status, model.JournalAnalysisDone)
+			}
+			if status.Analysis == nil {
+				t.Fatal("status has no analysis")
+			}
+			got := status.Analysis.JournalAnalysis
+			if got.Insights != tt.wantInsights {
+				t.Errorf("insights = %q, want %q", got.Insights, tt.wantInsights)
+			}
+			if got.Recommendations != tt.wantRecommendations {
+				t.Errorf("recommendations = %q, want %q", got.Recommendations, tt.wantRecommendations)
+			}
+			if got.Emotions != `["sadness","exhaustion"]` {
+				t.Errorf("emotions = %q, want the stored emotions", got.Emotions)
+			}
+			if (status.Analysis.CrisisSupport != nil) != tt.wantCrisis {
+				t.Errorf("crisis support = %v, want crisis %v", status.Analysis.CrisisSupport, tt.wantCrisis)
+			}
+			if tt.wantCrisis && len(status.Analysis.ActionItems) > 0 {
+				t.Errorf("action items = %v, want none for a crisis", status.Analysis.ActionItems)
+			}
+		})
+	}
+}
+
+func TestProcessAnalysisJobRetries(t *testing.T) {
+	const journalID, userID = 10, 7
+	unavailable := &service.LLMError{Provider: "test", StatusCode: 503, Kind: service.ErrLLMUnavailable, Err: errors.New("overloaded")}
+
+	tests := []struct {
+		name         string
+		attempts     int
+		llmErr       error
+		noJournal    bool
+		cancelled    bool
+		wantStatus   string
+		wantAttempts int
+		wantDelay    time.Duration
+		wantErr      bool
+	}{
+		{
+			name:         "first failure is retried after the base delay",
+			attempts:     1,
+			llmErr:       unavailable,
+			wantStatus:   model.JournalAnalysisQueued,
+			wantAttempts: 1,
+			wantDelay:    journalAnalysisRetryBaseDelay,
+			wantErr:      true,
+		},
+		{
+			name:         "third failure waits four times as long",
+			attempts:     3,
+			llmErr:       unavailable,
+			wantStatus:   model.JournalAnalysisQueued,
+			wantAttempts: 3,
+			wantDelay:    4 * journalAnalysisRetryBaseDelay,
+			wantErr:      true,
+		},
+		{
+			name:         "last attempt fails the job",
+			attempts:     5,
+			llmErr:       unavailable,
+			wantStatus:   model.JournalAnalysisFailed,
+			wantAttempts: 5,
+			wantErr:      true,
+		},
+		{
+			name:         "job left behind past its attempts fails without calling the AI",
+			attempts:     6,
+			wantStatus:   model.JournalAnalysisFailed,
+			wantAttempts: 6,
+			wantErr:      true,
+		},
+		{
+			name:         "deleted journal fails at once",
+			attempts:     1,
+			noJournal:    true,
+			wantStatus:   model.JournalAnalysisFailed,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "shutdown returns the job without using an attempt",
+			attempts:     2,
+			llmErr:       context.Canceled,
+			cancelled:    true,
+			wantStatus:   model.JournalAnalysisQueued,
+			wantAttempts: 1,
+		},
+		{
+			name:         "success",
+			attempts:     2,
+			wantStatus:   model.JournalAnalysisDone,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			journals := map[int]model.Journal{
+				journalID: {ID: journalID, UserID: userID, Judul: "Hari berat", Isi: "Capek sekali hari ini.", Perasaan: "sedih"},
+			}
+			if tt.noJournal {
+				delete(journals, journalID)
+			}
+			job := model.JournalAnalysisJob{
+				ID:          1,
+				JournalID:   journalID,
+				UserID:      userID,
+				Status:      model.JournalAnalysisRunning,
+				Attempts:    tt.attempts,
+				MaxAttempts: 5,
+				Lease:       3,
+			}
+			store := newFakeAnalysisStore()
+			jobRepo := &fakeJobRepo{jobs: map[int]model.JournalAnalysisJob{journalID: job}}
+			llm := fakeLLM{response: analysisJSON("Kamu tampak lelah karena pekerjaan."), err: tt.llmErr}
+			aiService := service.NewJournalAnalysisService(llm, service.LLMOptions{}, store)
+			uc := NewJournalAIUsecase(store, &fakeJournalRepo{journals: journals}, jobRepo, aiService, fakeSafety{}, 5)
+
+			err := uc.ProcessAnalysisJob(ctx, job)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessAnalysisJob() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(jobRepo.finished) != 1 {
+				t.Fatalf("Finish called %d times, want once", len(jobRepo.finished))
+			}
+
+			result := jobRepo.finished[0]
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", result.Status, tt.wantStatus)
+			}
+			if result.Attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", result.Attempts, tt.wantAttempts)
+			}
+			if result.RetryDelay != tt.wantDelay {
+				t.Errorf("retry delay = %s, want %s", result.RetryDelay, tt.wantDelay)
+			}
+			if tt.wantErr && result.Error == "" {
+				t.Error("failed attempt has no error recorded")
+			}
+		})
+	}
+}
+
+func TestJournalAnalysisRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{name: "first attempt", attempt: 1, err: errors.New("boom"), want: 30 * time.Second},
+		{name: "second attempt doubles", attempt: 2, err: errors.New("boom"), want: time.Minute},
+		{name: "capped at the max delay", attempt: 10, err: errors.New("boom"), want: journalAnalysisRetryMaxDelay},
+		{name: "shift overflow is capped", attempt: 100, err: errors.New("boom"), want: journalAnalysisRetryMaxDelay},
+		{
+			name:    "longer Retry-After wins",
+			attempt: 1,
+			err:     &service.LLMError{Provider: "test", RetryAfter: 5 * time.Minute, Kind: service.ErrLLMRateLimited, Err: errors.New("429")},
+			want:    5 * time.Minute,
+		},
+		{
+			name:    "shorter Retry-After is ignored",
+			attempt: 3,
+			err:     &service.LLMError{Provider: "test", RetryAfter: 10 * time.Second, Kind: service.ErrLLMRateLimited, Err: errors.New("429")},
+			want:    2 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := journalAnalysisRetryDelay(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("journalAnalysisRetryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
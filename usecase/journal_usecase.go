@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"log"
 	"pijar/model"
 	"pijar/repository"
 )
@@ -16,15 +17,25 @@ type JournalUsecase interface {
 }
 
 type journalUsecase struct {
-	repo repository.JournalRepository
+	repo           repository.JournalRepository
+	analysisQueue  JournalAnalysisQueue
+	subscriptionUC SubscriptionUsecase
 }
 
-func NewJournalUsecase(repo repository.JournalRepository) JournalUsecase {
-	return &journalUsecase{repo: repo}
+func NewJournalUsecase(repo repository.JournalRepository, analysisQueue JournalAnalysisQueue, subscriptionUC SubscriptionUsecase) JournalUsecase {
+	return &journalUsecase{
+		repo:           repo,
+		analysisQueue:  analysisQueue,
+		subscriptionUC: subscriptionUC,
+	}
 }
 
 func (u *journalUsecase) Create(ctx context.Context, journal *model.Journal) error {
-	return u.repo.Create(ctx, journal)
+	if err := u.repo.Create(ctx, journal); err != nil {
+		return err
+	}
+	u.enqueueAnalysis(ctx, journal)
+	return nil
 }
 
 func (u *journalUsecase) FindAll(ctx context.Context) ([]model.Journal, error) {
@@ -40,9 +51,29 @@ func (u *journalUsecase) FindByID(ctx context.Context, id int) (*model.Journal,
 }
 
 func (u *journalUsecase) Update(ctx context.Context, journal *model.Journal) error {
-	return u.repo.Update(ctx, journal)
+	if err := u.repo.Update(ctx, journal); err != nil {
+		return err
+	}
+	u.enqueueAnalysis(ctx, journal)
+	return nil
 }
 
 func (u *journalUsecase) Delete(ctx context.Context, id int) error {
 	return u.repo.Delete(ctx, id)
 }
+
+// enqueueAnalysis memasukkan jurnal ke antrean analisis AI untuk user yang berlangganan journal AI.
+// Jurnal sudah tersimpan, jadi kegagalan di sini hanya dicatat; analisis bisa diminta lagi lewat /journals-ai.
+func (u *journalUsecase) enqueueAnalysis(ctx context.Context, journal *model.Journal) {
+	allowed, err := u.subscriptionUC.HasEntitlement(journal.UserID, model.EntitlementJournalAI)
+	if err != nil {
+		log.Printf("Failed to check journal AI entitlement for user %d: %v", journal.UserID, err)
+		return
+	}
+	if !allowed {
+		return
+	}
+	if _, err := u.analysisQueue.EnqueueAnalysis(ctx, journal.ID, journal.UserID); err != nil {
+		log.Printf("Failed to enqueue analysis for journal %d: %v", journal.ID, err)
+	}
+}
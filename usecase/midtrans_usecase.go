@@ -1,8 +1,11 @@
 package usecase
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"pijar/model"
 	"pijar/repository"
 	"pijar/utils/service"
@@ -11,13 +14,31 @@ import (
 	"time"
 )
 
+// ErrProductUnavailable dikembalikan jika produk diarsipkan atau tidak bisa dibeli
+var ErrProductUnavailable = errors.New("product is not available for purchase")
+
+// ErrReceiptUnavailable dikembalikan jika transaksi belum dibayar sehingga belum punya bukti pembayaran
+var ErrReceiptUnavailable = errors.New("receipt is only available for paid transactions")
+
+const (
+	defaultTransactionPageLimit = 10
+	maxTransactionPageLimit     = 100
+)
+
 // PaymentUsecase interface for payment operations
 type PaymentUsecase interface {
 	CreatePayment(req model.PaymentRequest) (model.Transaction, error)
 	GetPaymentStatus(id int) (model.Transaction, error)
+	GetTransaction(id int) (model.Transaction, error)
+	ListTransactions(filter model.TransactionFilter) (model.TransactionListResponse, error)
+	GetReceipt(id int) (model.Receipt, error)
 	ProcessCallback(callback model.MidtransCallbackRequest) error
 	RollbackPayment(id int) (model.Transaction, error)
+	RefundPayment(id int, amount int, reason string) (model.Refund, error)
+	GetRefunds(id int) ([]model.Refund, error)
 	ForceCheckAndUpdateStatus(id int) (model.Transaction, error)
+	GetStalePendingTransactions(updatedBefore time.Time, limit int, excludeIDs []int) ([]model.Transaction, error)
+	ReconcilePendingTransaction(transaction model.Transaction, expireBefore time.Time) (model.Transaction, error)
 	GetProductByID(id int) (model.Product, error)
 	GetUserByID(id int) (model.Users, error)
 }
@@ -28,6 +49,9 @@ type paymentUsecase struct {
 	productRepo     repository.ProductRepository
 	transactionRepo repository.TransactionRepository
 	userRepo        repository.UserRepoInterface
+	subscriptionUC  SubscriptionUsecase
+	callbackRepo    repository.MidtransCallbackRepository
+	refundRepo      repository.RefundRepository
 }
 
 // NewPaymentUsecase creates a new PaymentUsecase instance
@@ -36,12 +60,18 @@ func NewPaymentUsecase(
 	productRepo repository.ProductRepository,
 	transactionRepo repository.TransactionRepository,
 	userRepo repository.UserRepoInterface,
+	subscriptionUC SubscriptionUsecase,
+	callbackRepo repository.MidtransCallbackRepository,
+	refundRepo repository.RefundRepository,
 ) PaymentUsecase {
 	return &paymentUsecase{
 		midtransService: midtransService,
 		productRepo:     productRepo,
 		transactionRepo: transactionRepo,
 		userRepo:        userRepo,
+		subscriptionUC:  subscriptionUC,
+		callbackRepo:    callbackRepo,
+		refundRepo:      refundRepo,
 	}
 }
 
@@ -53,16 +83,26 @@ func (p *paymentUsecase) CreatePayment(req model.PaymentRequest) (model.Transact
 		return model.Transaction{}, fmt.Errorf("product not found: %w", err)
 	}
 
+	if product.Status != model.ProductStatusActive {
+		return model.Transaction{}, ErrProductUnavailable
+	}
+
+	// Midtrans Snap hanya memproses pembayaran dalam Rupiah
+	if product.Currency != "IDR" {
+		return model.Transaction{}, fmt.Errorf("%w: currency %s is not supported", ErrProductUnavailable, product.Currency)
+	}
+
 	// Generate order ID and set default quantity
 	orderID := p.midtransService.GenerateOrderID()
 
 	// Create item for Midtrans
 	items := []model.Item{
 		{
-			ID:        strconv.Itoa(product.ID),
-			Name:      product.Name,
-			Price:     product.Price,
-			Quantity:  1,
+			ID:                  strconv.Itoa(product.ID),
+			Name:                product.Name,
+			Price:               product.Price,
+			Quantity:            1,
+			MonthlySubscription: product.MonthlySubscription,
 		},
 	}
 
@@ -137,7 +177,8 @@ func (p *paymentUsecase) GetPaymentStatus(id int) (model.Transaction, error) {
 	return updatedTransaction, nil
 }
 
-// ProcessCallback handles Midtrans callback and updates transaction status
+// ProcessCallback handles Midtrans callback and updates transaction status.
+// Notifikasi yang terkirim ulang atau datang tidak berurutan aman untuk diproses.
 func (p *paymentUsecase) ProcessCallback(callback model.MidtransCallbackRequest) error {
 	err := p.midtransService.VerifyCallback(callback)
 	if err != nil {
@@ -145,77 +186,150 @@ func (p *paymentUsecase) ProcessCallback(callback model.MidtransCallbackRequest)
 		return fmt.Errorf("error verifying callback: %w", err)
 	}
 
-	// Determine transaction status based on Midtrans status
-	var status string
-	switch callback.TransactionStatus {
-	case "capture", "settlement":
-		status = "success"
-	case "pending":
-		status = "pending"
-	case "deny", "cancel", "expire", "failure":
-		status = "failed"
-	default:
-		// For any other status, treat as pending to allow rollback
-		status = "pending"
+	payload, err := json.Marshal(callback)
+	if err != nil {
+		return fmt.Errorf("error encoding callback: %w", err)
 	}
 
-	// Update transaction status in database
-	err = p.transactionRepo.UpdateTransactionStatusByOrderID(
-		callback.OrderID,
-		status,
-		callback.TransactionID,
-	)
+	entry, err := p.callbackRepo.SaveCallback(model.MidtransCallbackLog{
+		TransactionID:     callback.TransactionID,
+		OrderID:           callback.OrderID,
+		TransactionStatus: callback.TransactionStatus,
+		StatusCode:        callback.StatusCode,
+		FraudStatus:       callback.FraudStatus,
+		GrossAmount:       callback.GrossAmount,
+		RefundKey:         callback.LatestRefundKey(),
+		Payload:           payload,
+	})
+	if err != nil {
+		return err
+	}
+	if entry.ProcessedAt != nil {
+		log.Printf("Duplicate callback for transaction %s (%s), already processed", callback.TransactionID, callback.TransactionStatus)
+		return nil
+	}
 
+	transaction, err := p.transactionRepo.GetTransactionByOrderID(callback.OrderID)
 	if err != nil {
-		log.Printf("Error updating transaction status: %v", err)
-		return fmt.Errorf("error updating transaction status: %w", err)
+		return fmt.Errorf("transaction not found: %w", err)
 	}
 
-	return nil
-}
+	// gross_amount dari Midtrans berformat "10000.00"
+	grossAmount, err := strconv.ParseFloat(callback.GrossAmount, 64)
+	if err != nil || int(math.Round(grossAmount)) != transaction.Amount {
+		log.Printf("Gross amount mismatch for order %s: %s vs %d", callback.OrderID, callback.GrossAmount, transaction.Amount)
+		return fmt.Errorf("gross amount mismatch for order %s", callback.OrderID)
+	}
 
-// ForceCheckAndUpdateStatus forces a status check from Midtrans and updates the database
-func (p *paymentUsecase) ForceCheckAndUpdateStatus(id int) (model.Transaction, error) {
-	transaction, err := p.transactionRepo.GetTransactionByID(id)
-	if err != nil {
-		return model.Transaction{}, fmt.Errorf("transaction not found: %w", err)
+	status := mapMidtransStatus(callback.TransactionStatus, callback.FraudStatus)
+	if _, err := p.applyStatus(transaction, status, callback.TransactionID); err != nil {
+		log.Printf("Error updating transaction status: %v", err)
+		return fmt.Errorf("error updating transaction status: %w", err)
 	}
 
-	// Check transaction status from Midtrans API
-	midtransStatus, err := p.midtransService.CheckTransactionStatus(transaction.OrderID)
-	if err != nil {
-		return transaction, fmt.Errorf("error checking transaction status: %w", err)
+	if err := p.callbackRepo.MarkCallbackProcessed(entry.ID); err != nil {
+		log.Printf("Error marking callback %d as processed: %v", entry.ID, err)
 	}
 
-	// Determine transaction status based on Midtrans status
-	var newStatus string
-	switch midtransStatus {
-	case "capture", "settlement":
-		newStatus = "success"
+	return nil
+}
+
+// mapMidtransStatus menerjemahkan transaction_status Midtrans ke status transaksi lokal
+func mapMidtransStatus(transactionStatus, fraudStatus string) string {
+	switch transactionStatus {
+	case "capture":
+		// capture kartu kredit yang masih ditahan fraud detection belum dianggap lunas
+		if fraudStatus == "challenge" {
+			return model.TransactionStatusPending
+		}
+		return model.TransactionStatusSuccess
+	case "settlement":
+		return model.TransactionStatusSuccess
 	case "pending":
-		newStatus = "pending"
+		return model.TransactionStatusPending
 	case "deny", "cancel", "expire", "failure":
-		newStatus = "failed"
+		return model.TransactionStatusFailed
+	case "partial_refund":
+		return model.TransactionStatusPartiallyRefunded
+	case "refund":
+		return model.TransactionStatusRefunded
 	default:
 		// For any other status, treat as pending to allow rollback
-		newStatus = "pending"
+		return model.TransactionStatusPending
 	}
+}
+
+// applyStatus memindahkan transaksi ke status baru jika perpindahannya diperbolehkan.
+// Perpindahan mundur (misalnya success ke pending) diabaikan.
+func (p *paymentUsecase) applyStatus(transaction model.Transaction, newStatus string, midtransID string) (model.Transaction, error) {
 	if newStatus != transaction.Status {
-		log.Printf("Updating transaction %d status from %s to %s", id, transaction.Status, newStatus)
+		if !model.CanTransitionTo(transaction.Status, newStatus) {
+			log.Printf("Ignoring status change of transaction %d from %s to %s", transaction.ID, transaction.Status, newStatus)
+			return transaction, nil
+		}
 
-		// Update transaksi di database
+		log.Printf("Updating transaction %d status from %s to %s", transaction.ID, transaction.Status, newStatus)
+		if err := p.transactionRepo.TransitionTransactionStatus(transaction.ID, transaction.Status, newStatus, midtransID); err != nil {
+			return transaction, err
+		}
 		transaction.Status = newStatus
 		transaction.UpdatedAt = time.Now()
+		if midtransID != "" {
+			transaction.MidtransID = midtransID
+		}
+	}
+
+	// Aktivasi bersifat idempotent, jadi tetap dipanggil untuk notifikasi success yang diulang
+	// agar aktivasi yang sebelumnya gagal bisa dicoba lagi
+	if transaction.Status == model.TransactionStatusSuccess {
+		if err := p.subscriptionUC.ActivateFromTransaction(transaction); err != nil {
+			return transaction, fmt.Errorf("error activating subscription: %w", err)
+		}
+	}
+
+	// Refund penuh (dari API maupun dashboard Midtrans) mencabut langganan yang dibeli
+	if transaction.Status == model.TransactionStatusRefunded {
+		if err := p.subscriptionUC.RevokeForTransaction(transaction.ID); err != nil {
+			return transaction, err
+		}
+	}
 
-		err = p.transactionRepo.UpdateTransactionStatus(transaction.ID, newStatus)
+	// Refund sebagian memotong langganan sebanding dengan dana yang dikembalikan. Yang dihitung
+	// adalah refund yang tercatat di tabel refunds, yaitu refund lewat API.
+	if transaction.Status == model.TransactionStatusPartiallyRefunded {
+		refunded, err := p.refundRepo.GetRefundedAmount(transaction.ID)
 		if err != nil {
-			return transaction, fmt.Errorf("error updating transaction status: %w", err)
+			return transaction, fmt.Errorf("failed to calculate refunded amount: %w", err)
+		}
+		if err := p.subscriptionUC.ShortenForPartialRefund(transaction.ID, refunded, transaction.Amount); err != nil {
+			return transaction, err
 		}
 	}
 
 	return transaction, nil
 }
 
+// ForceCheckAndUpdateStatus forces a status check from Midtrans and updates the database
+func (p *paymentUsecase) ForceCheckAndUpdateStatus(id int) (model.Transaction, error) {
+	transaction, err := p.transactionRepo.GetTransactionByID(id)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("transaction not found: %w", err)
+	}
+
+	// Transaksi yang sudah final tidak perlu dicek lagi ke Midtrans
+	if transaction.Status != model.TransactionStatusPending {
+		return transaction, nil
+	}
+
+	// Check transaction status from Midtrans API
+	midtransStatus, err := p.midtransService.CheckTransactionStatus(transaction.OrderID)
+	if err != nil {
+		return transaction, fmt.Errorf("error checking transaction status: %w", err)
+	}
+
+	return p.applyStatus(transaction, mapMidtransStatus(midtransStatus.TransactionStatus, midtransStatus.FraudStatus), "")
+}
+
 // GetProductByID mendapatkan detail produk berdasarkan ID
 func (p *paymentUsecase) GetProductByID(id int) (model.Product, error) {
 	return p.productRepo.GetProductByID(id)
@@ -235,7 +349,7 @@ func (p *paymentUsecase) RollbackPayment(id int) (model.Transaction, error) {
 	}
 
 	// Hanya boleh rollback transaksi yang belum selesai
-	if transaction.Status == "success" || transaction.Status == "cancelled" {
+	if transaction.Status != model.TransactionStatusPending && transaction.Status != model.TransactionStatusFailed {
 		return transaction, fmt.Errorf("cannot rollback transaction with status: %s", transaction.Status)
 	}
 
@@ -251,14 +365,222 @@ func (p *paymentUsecase) RollbackPayment(id int) (model.Transaction, error) {
 		}
 	}
 
-	// Update status transaksi di database
-	transaction.Status = "cancelled"
+	// Hanya berhasil jika status belum diubah oleh callback Midtrans di antaranya
+	err = p.transactionRepo.TransitionTransactionStatus(transaction.ID, transaction.Status, model.TransactionStatusCancelled, "")
+	if err != nil {
+		return transaction, fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	transaction.Status = model.TransactionStatusCancelled
 	transaction.UpdatedAt = time.Now()
 
-	err = p.transactionRepo.UpdateTransactionStatus(transaction.ID, "cancelled")
+	return transaction, nil
+}
+
+// RefundPayment mengembalikan dana transaksi yang sudah sukses, penuh atau sebagian.
+// Refund dicatat dulu sebagai pending sebelum memanggil Midtrans, sehingga jumlah yang
+// sedang diproses ikut dihitung jika ada refund lain yang berjalan bersamaan.
+func (p *paymentUsecase) RefundPayment(id int, amount int, reason string) (model.Refund, error) {
+	if amount <= 0 {
+		return model.Refund{}, errors.New("refund amount must be greater than zero")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return model.Refund{}, errors.New("refund reason is required")
+	}
+
+	transaction, err := p.transactionRepo.GetTransactionByID(id)
 	if err != nil {
-		return transaction, fmt.Errorf("failed to update transaction status: %w", err)
+		return model.Refund{}, fmt.Errorf("transaction not found: %w", err)
+	}
+
+	if transaction.Status != model.TransactionStatusSuccess && transaction.Status != model.TransactionStatusPartiallyRefunded {
+		return model.Refund{}, fmt.Errorf("cannot refund transaction with status: %s", transaction.Status)
 	}
 
+	refund, err := p.refundRepo.CreatePendingRefund(model.Refund{
+		TransactionID: transaction.ID,
+		RefundKey:     fmt.Sprintf("%s-R%d", transaction.OrderID, time.Now().UnixNano()),
+		Amount:        amount,
+		Reason:        reason,
+	})
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	if err := p.midtransService.RefundTransaction(transaction.OrderID, refund.RefundKey, refund.Amount, refund.Reason); err != nil {
+		if updateErr := p.refundRepo.UpdateRefundStatus(refund.ID, model.RefundStatusFailed); updateErr != nil {
+			log.Printf("Error marking refund %d as failed: %v", refund.ID, updateErr)
+		}
+		return model.Refund{}, fmt.Errorf("failed to refund transaction: %w", err)
+	}
+
+	if err := p.refundRepo.UpdateRefundStatus(refund.ID, model.RefundStatusSucceeded); err != nil {
+		return model.Refund{}, fmt.Errorf("failed to record refund: %w", err)
+	}
+	refund.Status = model.RefundStatusSucceeded
+
+	refunded, err := p.refundRepo.GetRefundedAmount(transaction.ID)
+	if err != nil {
+		return refund, fmt.Errorf("failed to calculate refunded amount: %w", err)
+	}
+
+	newStatus := model.TransactionStatusPartiallyRefunded
+	if refunded >= transaction.Amount {
+		newStatus = model.TransactionStatusRefunded
+	}
+
+	// Notifikasi refund dari Midtrans bisa saja sudah mengubah status lebih dulu,
+	// jadi status dibaca ulang jika terjadi konflik
+	for attempt := 0; attempt < 3; attempt++ {
+		transaction, err = p.transactionRepo.GetTransactionByID(id)
+		if err != nil {
+			return refund, fmt.Errorf("transaction not found: %w", err)
+		}
+		if _, err = p.applyStatus(transaction, newStatus, ""); err == nil {
+			return refund, nil
+		}
+	}
+
+	return refund, fmt.Errorf("error updating transaction status: %w", err)
+}
+
+// GetRefunds mengembalikan daftar refund untuk sebuah transaksi
+func (p *paymentUsecase) GetRefunds(id int) ([]model.Refund, error) {
+	if _, err := p.transactionRepo.GetTransactionByID(id); err != nil {
+		return nil, fmt.Errorf("transaction not found: %w", err)
+	}
+	return p.refundRepo.GetRefundsByTransactionID(id)
+}
+
+// GetTransaction mengambil transaksi dari database tanpa menghubungi Midtrans,
+// dipakai untuk pengecekan kepemilikan sebelum aksi lain dijalankan
+func (p *paymentUsecase) GetTransaction(id int) (model.Transaction, error) {
+	transaction, err := p.transactionRepo.GetTransactionByID(id)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("transaction not found: %w", err)
+	}
 	return transaction, nil
 }
+
+// ListTransactions mengembalikan riwayat transaksi dengan paginasi
+func (p *paymentUsecase) ListTransactions(filter model.TransactionFilter) (model.TransactionListResponse, error) {
+	if filter.Status != "" && !model.IsValidTransactionStatus(filter.Status) {
+		return model.TransactionListResponse{}, fmt.Errorf("invalid transaction status: %s", filter.Status)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return model.TransactionListResponse{}, errors.New("invalid date range: from must be before to")
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultTransactionPageLimit
+	}
+	if filter.Limit > maxTransactionPageLimit {
+		filter.Limit = maxTransactionPageLimit
+	}
+
+	transactions, total, err := p.transactionRepo.ListTransactions(filter)
+	if err != nil {
+		return model.TransactionListResponse{}, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	return model.TransactionListResponse{
+		Transactions: transactions,
+		Pagination: model.Pagination{
+			CurrentPage: filter.Page,
+			TotalPages:  int(math.Ceil(float64(total) / float64(filter.Limit))),
+			TotalItems:  total,
+			Limit:       filter.Limit,
+		},
+	}, nil
+}
+
+// GetReceipt mengumpulkan data bukti pembayaran. Transaksi yang sudah direfund tetap
+// punya bukti pembayaran, dengan rincian refund ikut dicantumkan.
+func (p *paymentUsecase) GetReceipt(id int) (model.Receipt, error) {
+	transaction, err := p.GetTransaction(id)
+	if err != nil {
+		return model.Receipt{}, err
+	}
+
+	switch transaction.Status {
+	case model.TransactionStatusSuccess, model.TransactionStatusPartiallyRefunded, model.TransactionStatusRefunded:
+	default:
+		return model.Receipt{}, ErrReceiptUnavailable
+	}
+
+	product, err := p.productRepo.GetProductByID(transaction.ProductID)
+	if err != nil {
+		return model.Receipt{}, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	user, err := p.userRepo.GetUserByID(transaction.UserID)
+	if err != nil {
+		return model.Receipt{}, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	refunds, err := p.refundRepo.GetRefundsByTransactionID(id)
+	if err != nil {
+		return model.Receipt{}, fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	receipt := model.Receipt{
+		Transaction: transaction,
+		Product:     product,
+		Customer:    user,
+		Refunds:     refunds,
+	}
+	for _, refund := range refunds {
+		if refund.Status == model.RefundStatusSucceeded {
+			receipt.RefundedAmount += refund.Amount
+		}
+	}
+
+	return receipt, nil
+}
+
+// GetStalePendingTransactions mengembalikan transaksi pending yang belum berubah sejak updatedBefore,
+// selain transaksi di excludeIDs
+func (p *paymentUsecase) GetStalePendingTransactions(updatedBefore time.Time, limit int, excludeIDs []int) ([]model.Transaction, error) {
+	return p.transactionRepo.GetStalePendingTransactions(updatedBefore, limit, excludeIDs)
+}
+
+// ReconcilePendingTransaction mencocokkan transaksi pending dengan status terbaru di Midtrans,
+// untuk transaksi yang callback-nya tidak pernah sampai. Transaksi yang masih pending dan
+// dibuat sebelum expireBefore dibatalkan di Midtrans lalu ditandai failed.
+func (p *paymentUsecase) ReconcilePendingTransaction(transaction model.Transaction, expireBefore time.Time) (model.Transaction, error) {
+	if transaction.Status != model.TransactionStatusPending {
+		return transaction, nil
+	}
+
+	newStatus := model.TransactionStatusPending
+	existsInMidtrans := true
+	midtransStatus, err := p.midtransService.CheckTransactionStatus(transaction.OrderID)
+	switch {
+	case errors.Is(err, service.ErrMidtransTransactionNotFound):
+		existsInMidtrans = false
+	case err != nil:
+		return transaction, fmt.Errorf("error checking transaction status: %w", err)
+	default:
+		newStatus = mapMidtransStatus(midtransStatus.TransactionStatus, midtransStatus.FraudStatus)
+	}
+
+	if newStatus != model.TransactionStatusPending {
+		return p.applyStatus(transaction, newStatus, "")
+	}
+
+	if !transaction.CreatedAt.Before(expireBefore) {
+		return transaction, nil
+	}
+
+	// Batalkan dulu di Midtrans agar user tidak bisa membayar transaksi yang sudah kedaluwarsa
+	if existsInMidtrans {
+		if err := p.midtransService.CancelTransaction(transaction.OrderID); err != nil {
+			return transaction, fmt.Errorf("failed to cancel expired transaction: %w", err)
+		}
+	}
+
+	log.Printf("Expiring transaction %d (order %s), pending since %s", transaction.ID, transaction.OrderID, transaction.CreatedAt.Format(time.RFC3339))
+	return p.applyStatus(transaction, model.TransactionStatusFailed, "")
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"pijar/model"
+	"pijar/repository"
+	"pijar/utils/service"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Batas profil personal context agar prompt tetap ringkas dan hanya berisi data yang relevan
+const (
+	personalContextMaxEmotions   = 3
+	personalContextMaxGoals      = 5
+	personalContextMaxTitle      = 80
+	personalContextMaxEmotionLen = 30
+	personalContextRecentEntries = 10
+	personalContextMaxAge        = 45 * 24 * time.Hour // trend atau analisis yang lebih lama tidak dibagikan
+)
+
+// PersonalContextUsecase mengelola mode opt-in yang membagikan ringkasan mood dan goal user ke AI coach
+type PersonalContextUsecase interface {
+	GetSettings(c context.Context, userID int) (model.PersonalContextSettings, error)
+	SetEnabled(c context.Context, userID int, enabled bool) (model.PersonalContextSettings, error)
+	// ProfileFor mengembalikan profil untuk prompt coach, nil jika user tidak mengaktifkannya
+	ProfileFor(c context.Context, userID int) (*model.PersonalContext, error)
+	// GetSharedContext mengembalikan profil terakhir yang dikirim ke AI pada sebuah sesi, nil jika belum pernah
+	GetSharedContext(c context.Context, userID int, sessionID string) (*model.PersonalContext, error)
+}
+
+type personalContextUsecase struct {
+	prefRepo     repository.CoachPreferenceRepository
+	analysisRepo service.JournalAnalysisRepository
+	goalRepo     repository.DailyGoalRepository
+	sessionRepo  repository.CoachSessionRepository
+}
+
+func NewPersonalContextUsecase(prefRepo repository.CoachPreferenceRepository, analysisRepo service.JournalAnalysisRepository, goalRepo repository.DailyGoalRepository, sessionRepo repository.CoachSessionRepository) PersonalContextUsecase {
+	return &personalContextUsecase{
+		prefRepo:     prefRepo,
+		analysisRepo: analysisRepo,
+		goalRepo:     goalRepo,
+		sessionRepo:  sessionRepo,
+	}
+}
+
+func (u *personalContextUsecase) GetSettings(c context.Context, userID int) (model.PersonalContextSettings, error) {
+	enabled, err := u.prefRepo.GetPersonalContextEnabled(c, userID)
+	if err != nil {
+		return model.PersonalContextSettings{}, err
+	}
+
+	// Preview selalu ditampilkan supaya user tahu apa yang akan dibagikan sebelum mengaktifkannya
+	profile, err := u.buildProfile(c, userID)
+	if err != nil {
+		return model.PersonalContextSettings{}, err
+	}
+
+	return model.PersonalContextSettings{
+		Enabled: enabled,
+		Preview: &profile,
+	}, nil
+}
+
+func (u *personalContextUsecase) SetEnabled(c context.Context, userID int, enabled bool) (model.PersonalContextSettings, error) {
+	if err := u.prefRepo.SetPersonalContextEnabled(c, userID, enabled); err != nil {
+		return model.PersonalContextSettings{}, err
+	}
+	return u.GetSettings(c, userID)
+}
+
+func (u *personalContextUsecase) ProfileFor(c context.Context, userID int) (*model.PersonalContext, error) {
+	enabled, err := u.prefRepo.GetPersonalContextEnabled(c, userID)
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	profile, err := u.buildProfile(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (u *personalContextUsecase) GetSharedContext(c context.Context, userID int, sessionID string) (*model.PersonalContext, error) {
+	ctx, err := u.sessionRepo.GetOrCreateConversationContext(c, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, ok := ctx.Metadata[model.MetadataPersonalContext]
+	if !ok {
+		return nil, nil
+	}
+
+	// Setelah dibaca dari JSON nilainya berupa map, jadi dikonversi ulang ke struct
+	raw, err := json.Marshal(shared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared context: %w", err)
+	}
+	var profile model.PersonalContext
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		return nil, fmt.Errorf("failed to read shared context: %w", err)
+	}
+	return &profile, nil
+}
+
+// buildProfile menyusun profil dari trend mood terbaru dan goal yang belum selesai.
+// Hanya data agregat yang diambil: isi jurnal, kata kunci, tema dan task goal tidak ikut.
+func (u *personalContextUsecase) buildProfile(c context.Context, userID int) (model.PersonalContext, error) {
+	profile := model.PersonalContext{
+		TopEmotions: []string{},
+		ActiveGoals: []model.PersonalContextGoal{},
+		GeneratedAt: time.Now(),
+	}
+
+	if err := u.addMood(&profile, userID); err != nil {
+		return model.PersonalContext{}, err
+	}
+
+	goals, err := u.goalRepo.GetGoalsByUserID(c, userID)
+	if err != nil {
+		return model.PersonalContext{}, err
+	}
+	for _, goal := range goals {
+		if goal.Completed {
+			continue
+		}
+		completed, err := u.goalRepo.CountCompletedProgress(c, goal.ID, userID)
+		if err != nil {
+			return model.PersonalContext{}, err
+		}
+		profile.ActiveGoals = append(profile.ActiveGoals, model.PersonalContextGoal{
+			Title:             truncateRunes(strings.TrimSpace(goal.Title), personalContextMaxTitle),
+			CompletedArticles: completed,
+			TotalArticles:     len(goal.ArticlesToRead),
+		})
+		if len(profile.ActiveGoals) >= personalContextMaxGoals {
+			break
+		}
+	}
+
+	return profile, nil
+}
+
+// addMood memakai trend mingguan atau bulanan terbaru; jika belum ada, rata-rata sentimen analisis jurnal terakhir
+func (u *personalContextUsecase) addMood(profile *model.PersonalContext, userID int) error {
+	for _, periodType := range []string{"weekly", "monthly"} {
+		trends, err := u.analysisRepo.GetTrendsByUserID(userID, periodType)
+		if err != nil {
+			return fmt.Errorf("failed to get mood trend: %w", err)
+		}
+		if len(trends) == 0 || time.Since(trends[0].PeriodEnd) > personalContextMaxAge {
+			continue
+		}
+
+		trend := trends[0]
+		profile.MoodTrend = trend.MoodTrend
+		sentiment := roundSentiment(trend.AverageSentiment)
+		profile.AverageSentiment = &sentiment
+
+		var emotions []string
+		_ = json.Unmarshal([]byte(trend.TopEmotions), &emotions)
+		for _, emotion := range emotions {
+			// Emosi berupa label pendek; teks panjang dibuang agar tidak ada isi jurnal yang ikut
+			emotion = strings.ToLower(strings.TrimSpace(emotion))
+			if emotion == "" || utf8.RuneCountInString(emotion) > personalContextMaxEmotionLen {
+				continue
+			}
+			profile.TopEmotions = append(profile.TopEmotions, emotion)
+			if len(profile.TopEmotions) >= personalContextMaxEmotions {
+				break
+			}
+		}
+		return nil
+	}
+
+	analyses, err := u.analysisRepo.GetByUserID(userID, personalContextRecentEntries)
+	if err != nil {
+		return fmt.Errorf("failed to get journal analyses: %w", err)
+	}
+	var total float64
+	var count int
+	for _, analysis := range analyses {
+		if time.Since(analysis.AnalyzedAt) > personalContextMaxAge {
+			continue
+		}
+		total += analysis.SentimentScore
+		count++
+	}
+	if count > 0 {
+		sentiment := roundSentiment(total / float64(count))
+		profile.AverageSentiment = &sentiment
+	}
+	return nil
+}
+
+// personalContextPrompt menulis profil sebagai tambahan system prompt coach
+func personalContextPrompt(profile model.PersonalContext) string {
+	var b strings.Builder
+	b.WriteString("The user has chosen to share this summary of their recent journaling and goals. Use it only when it is relevant, do not recite it, and do not assume more than it says:\n")
+	if profile.MoodTrend != "" {
+		fmt.Fprintf(&b, "- Mood trend: %s\n", profile.MoodTrend)
+	}
+	if profile.AverageSentiment != nil {
+		fmt.Fprintf(&b, "- Average journal sentiment (-1 to 1): %.2f\n", *profile.AverageSentiment)
+	}
+	if len(profile.TopEmotions) > 0 {
+		fmt.Fprintf(&b, "- Most frequent emotions: %s\n", strings.Join(profile.TopEmotions, ", "))
+	}
+	for _, goal := range profile.ActiveGoals {
+		fmt.Fprintf(&b, "- Active goal: %q (%d of %d articles done)\n", goal.Title, goal.CompletedArticles, goal.TotalArticles)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func roundSentiment(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
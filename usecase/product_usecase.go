@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"pijar/model"
+	"pijar/repository"
+	"regexp"
+	"strings"
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ProductUsecase interface for product catalog operations
+type ProductUsecase interface {
+	GetActiveProducts() ([]model.Product, error)
+	GetAllProducts() ([]model.Product, error)
+	GetProductByID(id int) (model.Product, error)
+	CreateProduct(req model.ProductRequest) (model.Product, error)
+	UpdateProduct(id int, req model.ProductRequest) (model.Product, error)
+	ArchiveProduct(id int) error
+}
+
+// productUsecase implements ProductUsecase interface
+type productUsecase struct {
+	productRepo repository.ProductRepository
+}
+
+// NewProductUsecase creates a new ProductUsecase instance
+func NewProductUsecase(productRepo repository.ProductRepository) ProductUsecase {
+	return &productUsecase{
+		productRepo: productRepo,
+	}
+}
+
+// GetActiveProducts mengembalikan produk yang masih dijual, untuk halaman paywall
+func (p *productUsecase) GetActiveProducts() ([]model.Product, error) {
+	return p.productRepo.GetProducts(false)
+}
+
+// GetAllProducts mengembalikan semua produk termasuk yang sudah diarsipkan
+func (p *productUsecase) GetAllProducts() ([]model.Product, error) {
+	return p.productRepo.GetProducts(true)
+}
+
+func (p *productUsecase) GetProductByID(id int) (model.Product, error) {
+	return p.productRepo.GetProductByID(id)
+}
+
+func (p *productUsecase) CreateProduct(req model.ProductRequest) (model.Product, error) {
+	product, err := productFromRequest(req, model.ProductStatusActive)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return p.productRepo.CreateProduct(product)
+}
+
+func (p *productUsecase) UpdateProduct(id int, req model.ProductRequest) (model.Product, error) {
+	existing, err := p.productRepo.GetProductByID(id)
+	if err != nil {
+		return model.Product{}, err
+	}
+
+	// Status yang tidak dikirim tidak diubah, agar produk yang diarsipkan tidak kembali dijual
+	product, err := productFromRequest(req, existing.Status)
+	if err != nil {
+		return model.Product{}, err
+	}
+	product.ID = id
+
+	return p.productRepo.UpdateProduct(product)
+}
+
+// ArchiveProduct menyembunyikan produk dari katalog. Produk tidak dihapus karena
+// masih direferensikan oleh transaksi dan plan langganan.
+func (p *productUsecase) ArchiveProduct(id int) error {
+	return p.productRepo.UpdateProductStatus(id, model.ProductStatusArchived)
+}
+
+// productFromRequest memvalidasi input dan mengisi nilai default.
+// defaultStatus dipakai jika status kosong.
+func productFromRequest(req model.ProductRequest, defaultStatus string) (model.Product, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return model.Product{}, errors.New("product name is required")
+	}
+	if req.Price <= 0 {
+		return model.Product{}, errors.New("price must be greater than zero")
+	}
+	if req.MonthlySubscription < 0 {
+		return model.Product{}, errors.New("monthly_subscription cannot be negative")
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = "IDR"
+	}
+	if !currencyCodePattern.MatchString(currency) {
+		return model.Product{}, fmt.Errorf("invalid currency code: %s", req.Currency)
+	}
+
+	status := req.Status
+	if status == "" {
+		status = defaultStatus
+	}
+	if status != model.ProductStatusActive && status != model.ProductStatusArchived {
+		return model.Product{}, fmt.Errorf("invalid product status: %s", req.Status)
+	}
+
+	return model.Product{
+		Name:                name,
+		Description:         req.Description,
+		Price:               req.Price,
+		Currency:            currency,
+		MonthlySubscription: req.MonthlySubscription,
+		Status:              status,
+	}, nil
+}
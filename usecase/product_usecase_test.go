@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"testing"
+
+	"pijar/model"
+	"pijar/repository"
+)
+
+type fakeProductRepo struct {
+	repository.ProductRepository
+	product model.Product
+}
+
+func (r *fakeProductRepo) GetProductByID(id int) (model.Product, error) {
+	return r.product, nil
+}
+
+func (r *fakeProductRepo) UpdateProduct(product model.Product) (model.Product, error) {
+	r.product = product
+	return product, nil
+}
+
+func TestUpdateProductStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		status   string
+		want     string
+	}{
+		{name: "omitted status keeps an archived product archived", existing: model.ProductStatusArchived, want: model.ProductStatusArchived},
+		{name: "omitted status keeps an active product active", existing: model.ProductStatusActive, want: model.ProductStatusActive},
+		{name: "explicit status reactivates", existing: model.ProductStatusArchived, status: model.ProductStatusActive, want: model.ProductStatusActive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeProductRepo{product: model.Product{ID: 1, Name: "Premium", Price: 50000, Status: tt.existing}}
+			uc := NewProductUsecase(repo)
+
+			got, err := uc.UpdateProduct(1, model.ProductRequest{Name: "Premium", Price: 45000, Status: tt.status})
+			if err != nil {
+				t.Fatalf("UpdateProduct() error = %v", err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("UpdateProduct() status = %s, want %s", got.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"math"
+	"pijar/model"
+	"pijar/repository"
+	"pijar/utils/service"
+	"strings"
+)
+
+const (
+	safetyExcerptLength      = 500
+	defaultSafetyFlagLimit   = 20
+	maxSafetyFlagLimit       = 100
+	crisisSupportMessage     = "Terima kasih sudah mau bercerita. Sepertinya kamu sedang melalui masa yang sangat berat, dan kamu tidak harus menghadapinya sendirian. Jika kamu berpikir untuk menyakiti diri sendiri atau merasa tidak aman saat ini, segera hubungi layanan darurat atau orang yang kamu percaya. Kamu juga bisa menghubungi layanan berikut:"
+	elevatedRiskSystemPrompt = "The user may be in emotional distress. Respond with extra warmth and care, validate their feelings, avoid giving medical advice, and gently encourage them to reach out to people they trust or a mental health professional."
+)
+
+// SafetyUsecase menjalankan pemeriksaan krisis dan mengelola antrean review flag
+type SafetyUsecase interface {
+	// ScreenInput memeriksa tulisan user dan mencatat flag jika berisiko
+	ScreenInput(ctx context.Context, check model.SafetyCheck) model.SafetyAssessment
+	// ScreenOutput memeriksa jawaban AI dan mencatat flag jika berisiko
+	ScreenOutput(ctx context.Context, check model.SafetyCheck) model.SafetyAssessment
+	// HasCrisisKeywords memeriksa kata kunci krisis saja tanpa mencatat flag, untuk potongan jawaban AI
+	// yang akan di-stream sebelum jawaban lengkapnya bisa diperiksa ScreenOutput
+	HasCrisisKeywords(text string) bool
+	CrisisSupport() model.CrisisSupport
+	// CrisisResponse adalah pengganti jawaban AI untuk percakapan berisiko tinggi
+	CrisisResponse() string
+	GetFlags(status string, page int, limit int) (model.SafetyFlagListResponse, error)
+	GetFlagByID(id int) (model.SafetyFlag, error)
+	ReviewFlag(id int, reviewerID int, req model.SafetyFlagReviewRequest) (model.SafetyFlag, error)
+}
+
+type safetyUsecase struct {
+	repo       repository.SafetyRepository
+	classifier service.SafetyClassifier
+	hotlines   []model.Hotline
+}
+
+func NewSafetyUsecase(repo repository.SafetyRepository, classifier service.SafetyClassifier, hotlines []model.Hotline) SafetyUsecase {
+	if len(hotlines) == 0 {
+		hotlines = service.DefaultCrisisHotlines
+	}
+	return &safetyUsecase{
+		repo:       repo,
+		classifier: classifier,
+		hotlines:   hotlines,
+	}
+}
+
+func (u *safetyUsecase) ScreenInput(ctx context.Context, check model.SafetyCheck) model.SafetyAssessment {
+	assessment := u.classifier.AssessInput(ctx, check.Text)
+	u.recordFlag(check, assessment)
+	return assessment
+}
+
+func (u *safetyUsecase) ScreenOutput(ctx context.Context, check model.SafetyCheck) model.SafetyAssessment {
+	assessment := u.classifier.AssessOutput(ctx, check.Text)
+	u.recordFlag(check, assessment)
+	return assessment
+}
+
+func (u *safetyUsecase) HasCrisisKeywords(text string) bool {
+	return len(u.classifier.MatchKeywords(text)) > 0
+}
+
+// recordFlag menyimpan flag untuk ditinjau admin. Kegagalan hanya dicatat di log
+// agar user yang sedang dalam krisis tetap mendapat respons.
+func (u *safetyUsecase) recordFlag(check model.SafetyCheck, assessment model.SafetyAssessment) {
+	if !assessment.Flagged() {
+		return
+	}
+
+	_, err := u.repo.CreateFlag(model.SafetyFlag{
+		UserID:          check.UserID,
+		Source:          check.Source,
+		SessionID:       check.SessionID,
+		JournalID:       check.JournalID,
+		RiskLevel:       assessment.RiskLevel,
+		Categories:      assessment.Categories,
+		MatchedKeywords: assessment.MatchedKeywords,
+		Method:          assessment.Method,
+		Excerpt:         safetyExcerpt(check.Text),
+	})
+	if err != nil {
+		log.Printf("Failed to record safety flag for user %d (%s): %v", check.UserID, check.Source, err)
+	}
+}
+
+func (u *safetyUsecase) CrisisSupport() model.CrisisSupport {
+	return model.CrisisSupport{
+		Message:  crisisSupportMessage,
+		Hotlines: u.hotlines,
+	}
+}
+
+func (u *safetyUsecase) CrisisResponse() string {
+	var b strings.Builder
+	b.WriteString(crisisSupportMessage)
+	for _, hotline := range u.hotlines {
+		b.WriteString("\n- ")
+		b.WriteString(hotline.Name)
+		b.WriteString(": ")
+		b.WriteString(hotline.Contact)
+	}
+	return b.String()
+}
+
+func (u *safetyUsecase) GetFlags(status string, page int, limit int) (model.SafetyFlagListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = defaultSafetyFlagLimit
+	}
+	if limit > maxSafetyFlagLimit {
+		limit = maxSafetyFlagLimit
+	}
+
+	flags, total, err := u.repo.GetFlags(status, page, limit)
+	if err != nil {
+		return model.SafetyFlagListResponse{}, err
+	}
+
+	return model.SafetyFlagListResponse{
+		Flags: flags,
+		Pagination: model.Pagination{
+			CurrentPage: page,
+			TotalPages:  int(math.Ceil(float64(total) / float64(limit))),
+			TotalItems:  total,
+			Limit:       limit,
+		},
+	}, nil
+}
+
+func (u *safetyUsecase) GetFlagByID(id int) (model.SafetyFlag, error) {
+	return u.repo.GetFlagByID(id)
+}
+
+func (u *safetyUsecase) ReviewFlag(id int, reviewerID int, req model.SafetyFlagReviewRequest) (model.SafetyFlag, error) {
+	return u.repo.ReviewFlag(id, req.Status, reviewerID, strings.TrimSpace(req.Note))
+}
+
+// safetyExcerpt memotong teks agar flag tidak menyimpan seluruh isi jurnal atau percakapan
+func safetyExcerpt(text string) string {
+	return truncateRunes(strings.TrimSpace(text), safetyExcerptLength)
+}
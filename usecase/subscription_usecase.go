@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"pijar/model"
+	"pijar/repository"
+	"time"
+)
+
+// SubscriptionUsecase interface for subscription and entitlement operations
+type SubscriptionUsecase interface {
+	ActivateFromTransaction(transaction model.Transaction) error
+	RevokeForTransaction(transactionID int) error
+	ShortenForPartialRefund(transactionID int, refunded int, amount int) error
+	HasEntitlement(userID int, entitlement string) (bool, error)
+	GetActiveEntitlements(userID int) ([]model.UserEntitlement, error)
+	GetUserSubscriptions(userID int) ([]model.SubscriptionPeriod, error)
+}
+
+// subscriptionUsecase implements SubscriptionUsecase interface
+type subscriptionUsecase struct {
+	subscriptionRepo repository.SubscriptionRepository
+}
+
+// NewSubscriptionUsecase creates a new SubscriptionUsecase instance
+func NewSubscriptionUsecase(subscriptionRepo repository.SubscriptionRepository) SubscriptionUsecase {
+	return &subscriptionUsecase{
+		subscriptionRepo: subscriptionRepo,
+	}
+}
+
+// ActivateFromTransaction membuat periode langganan dari transaksi yang sukses.
+// Produk tanpa plan bukan produk langganan, jadi tidak ada yang dibuat.
+// Aman dipanggil berulang kali untuk transaksi yang sama.
+func (s *subscriptionUsecase) ActivateFromTransaction(transaction model.Transaction) error {
+	if transaction.Status != "success" {
+		return fmt.Errorf("cannot activate subscription for transaction with status: %s", transaction.Status)
+	}
+
+	plan, err := s.subscriptionRepo.GetPlanByProductID(transaction.ProductID)
+	if errors.Is(err, repository.ErrPlanNotFound) {
+		log.Printf("No subscription plan for product %d, skipping activation", transaction.ProductID)
+		return nil
+	}
+	if err != nil {
+		// Dikembalikan agar callback atau pengecekan status gagal dan dicoba lagi
+		return fmt.Errorf("failed to get subscription plan: %w", err)
+	}
+
+	// Perpanjangan ditumpuk di akhir periode sebelumnya agar sisa hari tidak hilang
+	startsAt := time.Now()
+	latestEnd, err := s.subscriptionRepo.GetLatestPeriodEnd(transaction.UserID, plan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get current subscription: %w", err)
+	}
+	if latestEnd.After(startsAt) {
+		startsAt = latestEnd
+	}
+
+	endsAt := startsAt.AddDate(0, 0, plan.DurationDays)
+	period := model.SubscriptionPeriod{
+		UserID:        transaction.UserID,
+		PlanID:        plan.ID,
+		TransactionID: transaction.ID,
+		StartsAt:      startsAt,
+		EndsAt:        endsAt,
+		GraceUntil:    endsAt.AddDate(0, 0, plan.GraceDays),
+	}
+
+	created, inserted, err := s.subscriptionRepo.CreatePeriod(period)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		log.Printf("Subscription period for transaction %d already exists", transaction.ID)
+		return nil
+	}
+
+	log.Printf("Activated plan %s for user %d until %s", plan.Code, created.UserID, created.EndsAt.Format(time.RFC3339))
+	return nil
+}
+
+// RevokeForTransaction mencabut entitlement yang diberikan oleh transaksi, dipakai saat refund.
+// Periode lain yang ditumpuk setelahnya tidak digeser.
+func (s *subscriptionUsecase) RevokeForTransaction(transactionID int) error {
+	revoked, err := s.subscriptionRepo.RevokePeriodByTransactionID(transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke subscription: %w", err)
+	}
+	if revoked {
+		log.Printf("Revoked subscription period for transaction %d", transactionID)
+	}
+	return nil
+}
+
+// ShortenForPartialRefund memotong periode langganan sebanding dengan dana yang dikembalikan:
+// refund 40% dari harga memotong 40% durasi plan dari akhir periode. Dihitung dari total refund,
+// jadi aman dipanggil berulang untuk refund yang sama.
+func (s *subscriptionUsecase) ShortenForPartialRefund(transactionID int, refunded int, amount int) error {
+	shortened, err := s.subscriptionRepo.ShortenPeriodByTransactionID(transactionID, remainingShare(refunded, amount))
+	if err != nil {
+		return fmt.Errorf("failed to shorten subscription: %w", err)
+	}
+	if shortened {
+		log.Printf("Shortened subscription period for transaction %d after a refund of %d of %d", transactionID, refunded, amount)
+	}
+	return nil
+}
+
+// remainingShare adalah bagian harga yang tidak direfund, antara 0 dan 1
+func remainingShare(refunded int, amount int) float64 {
+	if amount <= 0 || refunded >= amount {
+		return 0
+	}
+	if refunded <= 0 {
+		return 1
+	}
+	return float64(amount-refunded) / float64(amount)
+}
+
+// HasEntitlement mengecek apakah user punya entitlement yang masih berlaku, termasuk masa tenggang
+func (s *subscriptionUsecase) HasEntitlement(userID int, entitlement string) (bool, error) {
+	return s.subscriptionRepo.HasEntitlement(userID, entitlement, time.Now())
+}
+
+func (s *subscriptionUsecase) GetActiveEntitlements(userID int) ([]model.UserEntitlement, error) {
+	return s.subscriptionRepo.GetActiveEntitlements(userID, time.Now())
+}
+
+func (s *subscriptionUsecase) GetUserSubscriptions(userID int) ([]model.SubscriptionPeriod, error) {
+	return s.subscriptionRepo.GetPeriodsByUserID(userID)
+}
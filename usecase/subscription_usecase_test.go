@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"pijar/model"
+	"pijar/repository"
+)
+
+func TestRemainingShare(t *testing.T) {
+	tests := []struct {
+		name     string
+		refunded int
+		amount   int
+		want     float64
+	}{
+		{name: "nothing refunded", refunded: 0, amount: 10000, want: 1},
+		{name: "forty percent refunded", refunded: 4000, amount: 10000, want: 0.6},
+		{name: "fully refunded", refunded: 10000, amount: 10000, want: 0},
+		{name: "refunded more than the amount", refunded: 12000, amount: 10000, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := remainingShare(tt.refunded, tt.amount); got != tt.want {
+				t.Errorf("remainingShare(%d, %d) = %v, want %v", tt.refunded, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+type fakePlanRepo struct {
+	repository.SubscriptionRepository
+	err error
+}
+
+func (r *fakePlanRepo) GetPlanByProductID(productID int) (model.Plan, error) {
+	return model.Plan{}, r.err
+}
+
+func TestActivateFromTransactionPlanLookup(t *testing.T) {
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{name: "product without a plan is skipped", err: repository.ErrPlanNotFound, wantErr: nil},
+		{name: "database failure is returned", err: dbErr, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewSubscriptionUsecase(&fakePlanRepo{err: tt.err})
+			err := uc.ActivateFromTransaction(model.Transaction{ID: 1, UserID: 1, ProductID: 1, Status: model.TransactionStatusSuccess})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ActivateFromTransaction() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ActivateFromTransaction() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"pijar/model"
+	"pijar/repository"
+	"pijar/utils/service"
+	"time"
+)
+
+// trendReportLookback cukup panjang untuk mencakup bulan sebelumnya di zona waktu mana pun
+const trendReportLookback = 62 * 24 * time.Hour
+
+var ErrInvalidTimezone = errors.New("unknown timezone")
+
+// TrendReportUsecase membuat laporan trend mingguan dan bulanan otomatis, lengkap dengan
+// ringkasan AI dan email opsional
+type TrendReportUsecase interface {
+	GetSettings(c context.Context, userID int) (model.TrendReportSettings, error)
+	UpdateSettings(c context.Context, userID int, req model.TrendReportSettingsRequest) (model.TrendReportSettings, error)
+	// GenerateDueReports membuat laporan untuk minggu dan bulan terakhir yang sudah selesai bagi
+	// setiap user aktif. Aman dijalankan berulang kali: laporan yang sudah ada tidak dibuat ulang.
+	GenerateDueReports(ctx context.Context) error
+}
+
+type trendReportUsecase struct {
+	analysisRepo   *repository.JournalAnalysisRepository
+	prefRepo       repository.TrendReportPreferenceRepository
+	userRepo       repository.UserRepoInterface
+	aiService      *service.JournalAnalysisService
+	subscriptionUC SubscriptionUsecase
+	mailer         service.Mailer
+	emailRenderer  *service.EmailRenderer
+}
+
+func NewTrendReportUsecase(
+	analysisRepo *repository.JournalAnalysisRepository,
+	prefRepo repository.TrendReportPreferenceRepository,
+	userRepo repository.UserRepoInterface,
+	aiService *service.JournalAnalysisService,
+	subscriptionUC SubscriptionUsecase,
+	mailer service.Mailer,
+	emailRenderer *service.EmailRenderer,
+) TrendReportUsecase {
+	return &trendReportUsecase{
+		analysisRepo:   analysisRepo,
+		prefRepo:       prefRepo,
+		userRepo:       userRepo,
+		aiService:      aiService,
+		subscriptionUC: subscriptionUC,
+		mailer:         mailer,
+		emailRenderer:  emailRenderer,
+	}
+}
+
+func (u *trendReportUsecase) GetSettings(c context.Context, userID int) (model.TrendReportSettings, error) {
+	return u.prefRepo.GetSettings(c, userID, service.DefaultTrendTimezone)
+}
+
+func (u *trendReportUsecase) UpdateSettings(c context.Context, userID int, req model.TrendReportSettingsRequest) (model.TrendReportSettings, error) {
+	settings, err := u.GetSettings(c, userID)
+	if err != nil {
+		return model.TrendReportSettings{}, err
+	}
+
+	settings.EmailEnabled = *req.EmailEnabled
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return model.TrendReportSettings{}, fmt.Errorf("%w: %q", ErrInvalidTimezone, req.Timezone)
+		}
+		settings.Timezone = req.Timezone
+	}
+
+	if err := u.prefRepo.SaveSettings(c, userID, settings); err != nil {
+		return model.TrendReportSettings{}, err
+	}
+	return settings, nil
+}
+
+func (u *trendReportUsecase) GenerateDueReports(ctx context.Context) error {
+	now := time.Now()
+	userIDs, err := u.analysisRepo.GetActiveUserIDs(now.Add(-trendReportLookback))
+	if err != nil {
+		return fmt.Errorf("failed to get active users: %w", err)
+	}
+
+	failed := 0
+	for _, userID := range userIDs {
+		// User berikutnya diproses pada jalannya job berikut setelah server hidup lagi
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := u.generateUserReports(ctx, userID, now); err != nil {
+			failed++
+			log.Printf("Trend report for user %d failed: %v", userID, err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("trend reports failed for %d of %d users", failed, len(userIDs))
+	}
+	return nil
+}
+
+func (u *trendReportUsecase) generateUserReports(ctx context.Context, userID int, now time.Time) error {
+	// Laporan otomatis bagian dari fitur journal AI premium
+	allowed, err := u.subscriptionUC.HasEntitlement(userID, model.EntitlementJournalAI)
+	if err != nil || !allowed {
+		return err
+	}
+
+	settings, err := u.GetSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, periodType := range []string{service.TrendPeriodWeekly, service.TrendPeriodMonthly} {
+		if err := u.generateReport(ctx, userID, periodType, settings, now); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", periodType, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// generateReport membuat laporan untuk periode terakhir yang sudah selesai di zona waktu user,
+// lalu mengirimkannya lewat email jika user mengaktifkannya
+func (u *trendReportUsecase) generateReport(ctx context.Context, userID int, periodType string, settings model.TrendReportSettings, now time.Time) error {
+	running, _, err := service.ResolveTrendPeriod(&model.TrendRequest{PeriodType: periodType, Timezone: settings.Timezone}, now)
+	if err != nil {
+		return err
+	}
+	req := &model.TrendRequest{
+		UserID:     userID,
+		PeriodType: periodType,
+		Timezone:   settings.Timezone,
+		Date:       running.Start.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	period, _, err := service.ResolveTrendPeriod(req, now)
+	if err != nil {
+		return err
+	}
+
+	trend, err := u.analysisRepo.GetTrendByPeriod(userID, periodType, period.Start, period.LastDay())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check for existing trend: %w", err)
+	}
+
+	// Trend yang dibuat user lewat POST /trend-analysis belum punya digest dan dilengkapi di sini
+	if trend == nil || trend.Digest == "" {
+		report, err := u.aiService.GenerateTrendAnalysis(req)
+		if errors.Is(err, service.ErrNoAnalysesInPeriod) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		digest, err := u.aiService.GenerateTrendDigest(ctx, report)
+		if err != nil {
+			return err
+		}
+		if err := u.analysisRepo.SaveTrendDigest(report.TrendAnalysis.ID, digest); err != nil {
+			return fmt.Errorf("failed to save trend digest: %w", err)
+		}
+		trend = report.TrendAnalysis
+		trend.Digest = digest
+	}
+
+	if !settings.EmailEnabled || trend.EmailedAt != nil {
+		return nil
+	}
+	return u.emailReport(trend)
+}
+
+func (u *trendReportUsecase) emailReport(trend *model.TrendAnalysis) error {
+	claimed, err := u.analysisRepo.ClaimTrendEmail(trend.ID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	if err := u.sendReportEmail(trend); err != nil {
+		// Dicoba lagi pada jalannya job berikutnya
+		if releaseErr := u.analysisRepo.ReleaseTrendEmail(trend.ID); releaseErr != nil {
+			log.Printf("Failed to release trend report email %d: %v", trend.ID, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *trendReportUsecase) sendReportEmail(trend *model.TrendAnalysis) error {
+	user, err := u.userRepo.GetUserByID(trend.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	msg, err := u.emailRenderer.TrendReportEmail(user.Email, user.Name, trend)
+	if err != nil {
+		return err
+	}
+	if err := u.mailer.Send(msg); err != nil {
+		return fmt.Errorf("failed to send trend report email: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"pijar/model"
+	"pijar/model/dto"
 	"pijar/repository"
 	"pijar/utils/service"
 )
@@ -15,17 +16,27 @@ type UserUsecase interface {
 	GetUserByEmail(email string) (model.Users, error)
 	UpdateUserUsecase(id int, user model.Users) (model.Users, error)
 	DeleteUserUsecase(id int) error
-	GenerateOTP(email string) (string, error)
+	StartRegistration(req dto.RegisterRequest) error
+	GenerateOTP(email string) error
 	VerifyOTP(email string, otp string) (model.Users, error)
+	CleanupExpiredOTPs() error
+	ForgotPassword(email string) error
+	ResetPassword(email string, code string, newPassword string) error
 }
 
 type userUsecase struct {
-	UserRepo repository.UserRepoInterface
+	UserRepo      repository.UserRepoInterface
+	SessionRepo   repository.AuthSessionRepository
+	Mailer        service.Mailer
+	EmailRenderer *service.EmailRenderer
 }
 
-func NewUserUsecase(repo repository.UserRepoInterface) *userUsecase {
+func NewUserUsecase(repo repository.UserRepoInterface, sessionRepo repository.AuthSessionRepository, mailer service.Mailer, emailRenderer *service.EmailRenderer) *userUsecase {
 	return &userUsecase{
-		UserRepo: repo,
+		UserRepo:      repo,
+		SessionRepo:   sessionRepo,
+		Mailer:        mailer,
+		EmailRenderer: emailRenderer,
 	}
 }
 
@@ -86,6 +97,13 @@ func (u *userUsecase) UpdateUserUsecase(id int, user model.Users) (model.Users,
 		return model.Users{}, fmt.Errorf("failed to update user: %v", err)
 	}
 
+	// Password berubah: cabut semua sesi agar token lama tidak bisa dipakai lagi
+	if user.PasswordHash != "" && user.PasswordHash != existingUser.PasswordHash {
+		if err := u.SessionRepo.RevokeAllUserSessions(id); err != nil {
+			return model.Users{}, fmt.Errorf("failed to revoke sessions: %v", err)
+		}
+	}
+
 	return updatedUser, nil
 }
 
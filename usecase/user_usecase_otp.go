@@ -4,92 +4,208 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"pijar/model"
+	"pijar/model/dto"
 	"pijar/utils/service"
+	"strings"
 	"time"
-	"golang.org/x/crypto/bcrypt"
 )
 
-func (u *userUsecase) GenerateOTP(email string) (string, error) {
-	// Validate email
+const (
+	otpTTL                 = 10 * time.Minute
+	otpMaxAttempts         = 3
+	pendingRegistrationTTL = 24 * time.Hour
+)
+
+var ErrInvalidOTP = errors.New("invalid or expired OTP")
+
+// StartRegistration menyimpan data registrasi (dengan password yang sudah di-hash) sebagai
+// pending registration, lalu mengirim OTP ke email. User baru dibuat setelah OTP diverifikasi.
+func (u *userUsecase) StartRegistration(req dto.RegisterRequest) error {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
 	if !service.IsValidEmail(email) {
-		return "", errors.New("invalid email format")
+		return errors.New("invalid email format")
+	}
+
+	if err := service.IsValidPassword(req.Password); err != nil {
+		return err
 	}
 
-	// Check if email exists
 	exists, err := u.UserRepo.IsEmailExists(email)
 	if err != nil {
-		return "", err
+		return err
 	}
 	if exists {
-		return "", errors.New("email already registered")
+		return errors.New("email already registered")
+	}
+
+	passwordHash, err := service.HashPassword(req.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	reg := model.PendingRegistration{
+		Email:        email,
+		Name:         req.Name,
+		PasswordHash: passwordHash,
+		BirthYear:    req.BirthYear,
+		Phone:        req.Phone,
+		ExpiresAt:    time.Now().Add(pendingRegistrationTTL),
+	}
+	if err := u.UserRepo.SavePendingRegistration(&reg); err != nil {
+		return fmt.Errorf("failed to save pending registration: %w", err)
+	}
+
+	return u.GenerateOTP(email)
+}
+
+// GenerateOTP mengirim OTP registrasi baru untuk pending registration yang sudah ada.
+// Jumlah percobaan dari OTP sebelumnya yang belum kedaluwarsa ikut dibawa, sehingga kirim ulang
+// OTP tidak bisa dipakai untuk mereset batas percobaan. Setelah batas tercapai, kirim ulang
+// ditolak sampai OTP terakhir kedaluwarsa (otpTTL); setelah itu percobaan mulai dari nol lagi.
+func (u *userUsecase) GenerateOTP(email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !service.IsValidEmail(email) {
+		return errors.New("invalid email format")
+	}
+
+	if _, err := u.UserRepo.GetPendingRegistration(email); err != nil {
+		return errors.New("no pending registration for this email")
+	}
+
+	attempts := 0
+	if previous, err := u.UserRepo.GetLatestOTP(email, model.OTPPurposeRegistration); err == nil && time.Now().Before(previous.ExpiresAt) {
+		attempts = previous.Attempts
+	}
+	if attempts >= otpMaxAttempts {
+		return errors.New("too many attempts, please try again later")
+	}
+
+	// Hanya satu OTP registrasi yang aktif per email
+	if err := u.UserRepo.DeleteOTPsByEmail(email, model.OTPPurposeRegistration); err != nil {
+		return fmt.Errorf("failed to clear previous OTPs: %w", err)
 	}
 
 	// Generate 6 digit OTP
-	rand.Seed(time.Now().UnixNano())
-	optCode := fmt.Sprintf("%06d", rand.Intn(999999))
-	
-	// Create OTP record
-	opt := model.OTP{
+	otpCode, err := service.GenerateNumericCode(6)
+	if err != nil {
+		return err
+	}
+
+	// OTP disimpan dalam bentuk hash, sama seperti password
+	otpHash, err := service.HashPassword(otpCode)
+	if err != nil {
+		return fmt.Errorf("failed to hash OTP: %w", err)
+	}
+
+	otp := model.OTP{
 		Email:     email,
-		Code:      optCode,
-		ExpiresAt: time.Now().Add(10 * time.Minute),
-		Attempts:  0,
-	}
-	
-	// Save OTP to database
-	if err := u.UserRepo.SaveOTP(&opt); err != nil {
-		return "", err
-	}
-	
-	// Send OTP via email (implement email service)
-	// For now, we'll just log it
-	log.Printf("OTP sent to %s: %s", email, optCode)
-	
-	return optCode, nil
+		Code:      otpHash,
+		Purpose:   model.OTPPurposeRegistration,
+		ExpiresAt: time.Now().Add(otpTTL),
+		Attempts:  attempts,
+	}
+	if err := u.UserRepo.SaveOTP(&otp); err != nil {
+		return err
+	}
+
+	// Send OTP via email, kode OTP tidak pernah ditulis ke log
+	msg, err := u.EmailRenderer.OTPEmail(email, otpCode, otpTTL)
+	if err != nil {
+		return err
+	}
+	if err := u.Mailer.Send(msg); err != nil {
+		return fmt.Errorf("failed to send OTP email: %w", err)
+	}
+	log.Printf("OTP email sent to %s", email)
+
+	return nil
 }
 
+// VerifyOTP mencocokkan OTP untuk email tertentu lalu mengaktifkan pending registration menjadi user
 func (u *userUsecase) VerifyOTP(email string, otp string) (model.Users, error) {
-	// Get OTP from database
-	storedOTP, err := u.UserRepo.GetOTPByCode(otp)
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	storedOTP, err := u.UserRepo.GetActiveOTP(email, model.OTPPurposeRegistration)
 	if err != nil {
-		return model.Users{}, err
+		return model.Users{}, ErrInvalidOTP
 	}
-	
-	// Check if OTP expired
-	if time.Now().After(storedOTP.ExpiresAt) {
-		return model.Users{}, errors.New("OTP has expired")
+
+	if storedOTP.Attempts >= otpMaxAttempts {
+		return model.Users{}, errors.New("too many attempts, please try again later")
 	}
-	
-	// Check if too many attempts
-	if storedOTP.Attempts >= 3 {
-		return model.Users{}, errors.New("too many attempts")
+
+	if !service.CheckPasswordHash(otp, storedOTP.Code) {
+		if err := u.UserRepo.IncrementOTPAttempts(storedOTP.ID); err != nil {
+			return model.Users{}, fmt.Errorf("failed to record attempt: %w", err)
+		}
+		return model.Users{}, ErrInvalidOTP
 	}
-	
-	// Create user
-	user := model.Users{
-		Email:        email,
-		PasswordHash: storedOTP.Code, // Changed to use PasswordHash
+
+	reg, err := u.UserRepo.GetPendingRegistration(email)
+	if err != nil {
+		return model.Users{}, ErrInvalidOTP
+	}
+
+	// OTP hanya boleh dipakai sekali
+	if err := u.UserRepo.DeleteOTPByID(storedOTP.ID); err != nil {
+		return model.Users{}, fmt.Errorf("failed to consume OTP: %w", err)
 	}
-	
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
+
+	// Email bisa saja sudah terdaftar lewat /register biasa selama OTP menunggu
+	exists, err := u.UserRepo.IsEmailExists(email)
 	if err != nil {
 		return model.Users{}, err
 	}
-	user.PasswordHash = string(hashedPassword)
-	
-	// Save user
-	createdUser, err := u.UserRepo.CreateUser(user)
+	if exists {
+		return model.Users{}, errors.New("email already registered")
+	}
+
+	createdUser, err := u.UserRepo.CreateUser(model.Users{
+		Name:         reg.Name,
+		Email:        reg.Email,
+		PasswordHash: reg.PasswordHash,
+		BirthYear:    reg.BirthYear,
+		Phone:        reg.Phone,
+	})
 	if err != nil {
 		return model.Users{}, err
 	}
-	
-	// Delete used OTP
-	if err := u.UserRepo.DeleteOTP(otp); err != nil {
-		log.Printf("Failed to delete OTP: %v", err)
+
+	if err := u.UserRepo.DeletePendingRegistration(email); err != nil {
+		log.Printf("Failed to delete pending registration: %v", err)
+	}
+	if err := u.UserRepo.DeleteOTPsByEmail(email, model.OTPPurposeRegistration); err != nil {
+		log.Printf("Failed to delete registration OTPs: %v", err)
 	}
-	
+
+	sendWelcomeEmail(u.EmailRenderer, u.Mailer, createdUser)
+
 	return createdUser, nil
 }
+
+// CleanupExpiredOTPs menghapus OTP dan pending registration yang sudah kedaluwarsa
+func (u *userUsecase) CleanupExpiredOTPs() error {
+	if err := u.UserRepo.DeleteExpiredOTPs(); err != nil {
+		return fmt.Errorf("failed to delete expired OTPs: %w", err)
+	}
+	if err := u.UserRepo.DeleteExpiredPendingRegistrations(); err != nil {
+		return fmt.Errorf("failed to delete expired pending registrations: %w", err)
+	}
+	return nil
+}
+
+// sendWelcomeEmail mengirim email sambutan di background, dipakai userUsecase dan AuthUsecase.
+// Kegagalan hanya dicatat di log dan tidak menggagalkan registrasi.
+func sendWelcomeEmail(renderer *service.EmailRenderer, mailer service.Mailer, user model.Users) {
+	go func() {
+		msg, err := renderer.WelcomeEmail(user.Email, user.Name)
+		if err != nil {
+			log.Printf("Failed to render welcome email: %v", err)
+			return
+		}
+		if err := mailer.Send(msg); err != nil {
+			log.Printf("Failed to send welcome email to %s: %v", user.Email, err)
+		}
+	}()
+}
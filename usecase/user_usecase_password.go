@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"pijar/model"
+	"pijar/utils/service"
+	"strings"
+	"time"
+)
+
+const (
+	passwordResetTTL         = 15 * time.Minute
+	passwordResetMaxAttempts = 5
+)
+
+var ErrInvalidResetCode = errors.New("invalid or expired reset code")
+
+// ForgotPassword mengirim kode reset password ke email user. Untuk email yang tidak
+// terdaftar tidak ada error yang dikembalikan, dan kode untuk email yang terdaftar dibuat
+// dan dikirim di background, agar status response maupun waktu responsnya tidak bisa
+// dipakai untuk menebak email yang terdaftar.
+func (u *userUsecase) ForgotPassword(email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !service.IsValidEmail(email) {
+		return errors.New("invalid email format")
+	}
+
+	user, err := u.UserRepo.GetUserByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Password reset requested for unknown email")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	go func() {
+		if err := u.sendPasswordResetCode(user.Email); err != nil {
+			log.Printf("Failed to send password reset code: %v", err)
+		}
+	}()
+	return nil
+}
+
+// sendPasswordResetCode membuat kode reset baru untuk email lalu mengirimnya.
+// Jumlah percobaan dari kode yang belum kedaluwarsa dibawa ke kode baru, agar meminta
+// kode baru tidak mereset batas percobaan.
+func (u *userUsecase) sendPasswordResetCode(email string) error {
+	attempts := 0
+	if previous, err := u.UserRepo.GetLatestOTP(email, model.OTPPurposePasswordReset); err == nil && time.Now().Before(previous.ExpiresAt) {
+		attempts = previous.Attempts
+	}
+	// Kode yang sudah habis jatahnya dibiarkan sampai kedaluwarsa
+	if attempts >= passwordResetMaxAttempts {
+		return errors.New("too many attempts, reset code not sent")
+	}
+
+	// Hanya satu kode reset yang aktif per email
+	if err := u.UserRepo.DeleteOTPsByEmail(email, model.OTPPurposePasswordReset); err != nil {
+		return fmt.Errorf("failed to clear previous reset codes: %w", err)
+	}
+
+	code, err := service.GenerateNumericCode(6)
+	if err != nil {
+		return err
+	}
+
+	// Kode disimpan dalam bentuk hash, sama seperti password
+	codeHash, err := service.HashPassword(code)
+	if err != nil {
+		return fmt.Errorf("failed to hash reset code: %w", err)
+	}
+
+	otp := model.OTP{
+		Email:     email,
+		Code:      codeHash,
+		Purpose:   model.OTPPurposePasswordReset,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+		Attempts:  attempts,
+	}
+	if err := u.UserRepo.SaveOTP(&otp); err != nil {
+		return fmt.Errorf("failed to save reset code: %w", err)
+	}
+
+	msg, err := u.EmailRenderer.PasswordResetEmail(email, code, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	if err := u.Mailer.Send(msg); err != nil {
+		return fmt.Errorf("failed to send reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword memverifikasi kode reset lalu mengganti password dan mencabut semua sesi user
+func (u *userUsecase) ResetPassword(email string, code string, newPassword string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// Validasi password dulu agar password yang lemah tidak menghabiskan jatah percobaan
+	if err := service.IsValidPassword(newPassword); err != nil {
+		return err
+	}
+
+	otp, err := u.UserRepo.GetActiveOTP(email, model.OTPPurposePasswordReset)
+	if err != nil {
+		return ErrInvalidResetCode
+	}
+
+	// Kode tidak dihapus agar jumlah percobaannya tetap terbawa sampai kedaluwarsa
+	if otp.Attempts >= passwordResetMaxAttempts {
+		return errors.New("too many attempts, please try again later")
+	}
+
+	if !service.CheckPasswordHash(code, otp.Code) {
+		if err := u.UserRepo.IncrementOTPAttempts(otp.ID); err != nil {
+			return fmt.Errorf("failed to record attempt: %w", err)
+		}
+		return ErrInvalidResetCode
+	}
+
+	user, err := u.UserRepo.GetUserByEmail(email)
+	if err != nil {
+		return ErrInvalidResetCode
+	}
+
+	// Kode hanya boleh dipakai sekali
+	if err := u.UserRepo.DeleteOTPByID(otp.ID); err != nil {
+		return fmt.Errorf("failed to consume reset code: %w", err)
+	}
+
+	hashedPassword, err := service.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := u.UserRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+
+	// Semua token yang beredar tidak berlaku lagi setelah password diganti
+	if err := u.SessionRepo.RevokeAllUserSessions(user.ID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"pijar/model"
+	"pijar/repository"
+	"pijar/utils/service"
+)
+
+type fakeEmailLookupRepo struct {
+	repository.UserRepoInterface
+	err error
+}
+
+func (r *fakeEmailLookupRepo) GetUserByEmail(email string) (model.Users, error) {
+	return model.Users{}, r.err
+}
+
+func TestForgotPasswordLookup(t *testing.T) {
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{name: "unknown email looks like success", err: sql.ErrNoRows, wantErr: nil},
+		{name: "database failure is returned", err: dbErr, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewUserUsecase(&fakeEmailLookupRepo{err: tt.err}, nil, nil, nil)
+			err := uc.ForgotPassword("someone@example.com")
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ForgotPassword() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ForgotPassword() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeResetRepo menyimpan user dan kode reset di memori
+type fakeResetRepo struct {
+	repository.UserRepoInterface
+	user         model.Users
+	otps         []*model.OTP
+	passwordHash string
+}
+
+func (r *fakeResetRepo) GetUserByEmail(email string) (model.Users, error) {
+	if email != r.user.Email {
+		return model.Users{}, sql.ErrNoRows
+	}
+	return r.user, nil
+}
+
+func (r *fakeResetRepo) GetLatestOTP(email string, purpose string) (*model.OTP, error) {
+	if len(r.otps) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	otp := *r.otps[len(r.otps)-1]
+	return &otp, nil
+}
+
+func (r *fakeResetRepo) GetActiveOTP(email string, purpose string) (*model.OTP, error) {
+	return r.GetLatestOTP(email, purpose)
+}
+
+func (r *fakeResetRepo) DeleteOTPsByEmail(email string, purpose string) error {
+	r.otps = nil
+	return nil
+}
+
+func (r *fakeResetRepo) DeleteOTPByID(id int) error {
+	r.otps = nil
+	return nil
+}
+
+func (r *fakeResetRepo) SaveOTP(otp *model.OTP) error {
+	otp.ID = len(r.otps) + 1
+	saved := *otp
+	r.otps = append(r.otps, &saved)
+	return nil
+}
+
+func (r *fakeResetRepo) IncrementOTPAttempts(id int) error {
+	for _, otp := range r.otps {
+		if otp.ID == id {
+			otp.Attempts++
+		}
+	}
+	return nil
+}
+
+func (r *fakeResetRepo) UpdatePassword(id int, passwordHash string) error {
+	r.passwordHash = passwordHash
+	return nil
+}
+
+type fakeRevokeSessionRepo struct {
+	repository.AuthSessionRepository
+}
+
+func (fakeRevokeSessionRepo) RevokeAllUserSessions(userID int) error {
+	return nil
+}
+
+// fakeCodeMailer menyimpan kode dari email terakhir yang dikirim
+type fakeCodeMailer struct {
+	code string
+}
+
+var resetCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func (m *fakeCodeMailer) Send(msg service.EmailMessage) error {
+	m.code = resetCodePattern.FindString(msg.TextBody)
+	return nil
+}
+
+func TestResetPasswordAttemptsSurviveNewCode(t *testing.T) {
+	const email = "user@example.com"
+	const newPassword = "NewPassw0rd!"
+
+	tests := []struct {
+		name        string
+		wrongBefore int
+		wantLocked  bool
+	}{
+		{name: "exhausted code stays locked after a new request", wrongBefore: passwordResetMaxAttempts, wantLocked: true},
+		{name: "attempts left carry over to the new code", wrongBefore: passwordResetMaxAttempts - 1, wantLocked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeResetRepo{user: model.Users{ID: 1, Email: email}}
+			mailer := &fakeCodeMailer{}
+			uc := NewUserUsecase(repo, fakeRevokeSessionRepo{}, mailer, service.NewEmailRenderer("Pijar"))
+
+			// ForgotPassword mengirim kode di background, jadi pengiriman dipanggil langsung
+			if err := uc.sendPasswordResetCode(email); err != nil {
+				t.Fatalf("first reset code: %v", err)
+			}
+			for i := 0; i < tt.wrongBefore; i++ {
+				if err := uc.ResetPassword(email, "wrong", newPassword); !errors.Is(err, ErrInvalidResetCode) {
+					t.Fatalf("wrong code %d: error = %v, want ErrInvalidResetCode", i+1, err)
+				}
+			}
+
+			err := uc.sendPasswordResetCode(email)
+			if tt.wantLocked != (err != nil) {
+				t.Fatalf("second reset code: error = %v, want locked %v", err, tt.wantLocked)
+			}
+
+			err = uc.ResetPassword(" USER@example.com ", mailer.code, newPassword)
+			if tt.wantLocked {
+				if err == nil || repo.passwordHash != "" {
+					t.Fatalf("ResetPassword() after %d wrong codes succeeded, want locked", tt.wrongBefore)
+				}
+				return
+			}
+			if err != nil || repo.passwordHash == "" {
+				t.Fatalf("ResetPassword() error = %v, want the password to be changed", err)
+			}
+		})
+	}
+}
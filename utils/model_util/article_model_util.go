@@ -6,22 +6,3 @@ type GeneratedArticle struct {
 	Source  string `json:"source"`
 	TopicID int    `json:"topic_id"`
 }
-
-type DeepseekChatRequest struct {
-	Model    string                `json:"model"`
-	Messages []DeepseekChatMessage `json:"messages"`
-}
-
-type DeepseekChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type DeepseekChatResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-	Error map[string]interface{} `json:"error"`
-}
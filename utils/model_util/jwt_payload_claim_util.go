@@ -4,8 +4,7 @@ import "github.com/golang-jwt/jwt/v5"
 
 type JwtPayloadClaim struct {
 	jwt.RegisteredClaims
-	UserId string
-	Role   string
+	UserId    string
+	Role      string
+	SessionID string
 }
-
-
@@ -1,12 +1,9 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"strings"
 
 	"pijar/utils/model_util"
@@ -14,8 +11,25 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// ArticleGenerator membuat artikel dengan LLM provider yang dikonfigurasi untuk fitur artikel
+type ArticleGenerator interface {
+	GenerateArticles(c *gin.Context, preferences []string) ([]*model_util.GeneratedArticle, error)
+}
+
+type articleGenerator struct {
+	llm  LLMProvider
+	opts LLMOptions
+}
+
+func NewArticleGenerator(llm LLMProvider, opts LLMOptions) ArticleGenerator {
+	return &articleGenerator{
+		llm:  llm,
+		opts: opts,
+	}
+}
+
 // GenerateArticles generates articles based on provided preferences
-func GenerateArticles(c *gin.Context, preferences []string) ([]*model_util.GeneratedArticle, error) {
+func (g *articleGenerator) GenerateArticles(c *gin.Context, preferences []string) ([]*model_util.GeneratedArticle, error) {
 	var result []*model_util.GeneratedArticle
 
 	// Default to at least one preference if none provided
@@ -30,6 +44,12 @@ func GenerateArticles(c *gin.Context, preferences []string) ([]*model_util.Gener
 		topicID = 1
 	}
 
+	// Context request dipakai supaya generate berhenti jika client terputus
+	var ctx context.Context = c
+	if c.Request != nil {
+		ctx = c.Request.Context()
+	}
+
 	// Limit to max 2 articles for performance
 	limit := len(preferences)
 	if limit > 2 {
@@ -42,8 +62,12 @@ func GenerateArticles(c *gin.Context, preferences []string) ([]*model_util.Gener
 		fmt.Printf("🔍 Memproses preferensi: %s\n", preference)
 
 		// Use the preference as both topic and preference, with the actual topicID
-		article, err := GenerateArticleFromDeepseek(c, preference, preference, topicID.(int))
+		article, err := g.generateArticle(ctx, preference, preference, topicID.(int))
 		if err != nil {
+			// Provider sedang tidak tersedia, preferensi berikutnya juga akan gagal
+			if errors.Is(err, ErrLLMUnavailable) || errors.Is(err, ErrLLMRateLimited) || ctx.Err() != nil {
+				return nil, err
+			}
 			fmt.Printf("❌ Gagal generate artikel untuk preferensi '%s': %v\n", preference, err)
 			continue
 		}
@@ -54,14 +78,8 @@ func GenerateArticles(c *gin.Context, preferences []string) ([]*model_util.Gener
 	return result, nil
 }
 
-// GenerateArticleFromDeepseek generates a single article using the Deepseek API
-func GenerateArticleFromDeepseek(c *gin.Context, topic string, preference string, topicID int) (*model_util.GeneratedArticle, error) {
-	// Get API key from environment variable
-	apiKey := os.Getenv("AI_API")
-	if apiKey == "" {
-		return nil, fmt.Errorf("AI_API environment variable is not set")
-	}
-
+// generateArticle generates a single article using the configured LLM provider
+func (g *articleGenerator) generateArticle(ctx context.Context, topic string, preference string, topicID int) (*model_util.GeneratedArticle, error) {
 	prompt := fmt.Sprintf(`Buat artikel tentang %s dengan format ketat:
 
 		1. **Judul:** [1 judul informatif]
@@ -71,51 +89,11 @@ func GenerateArticleFromDeepseek(c *gin.Context, topic string, preference string
 		3. **Sumber:** [1 referensi/sumber]
 		4. **Preferensi:** [%s]`, topic, preference)
 
-	reqBody := model_util.DeepseekChatRequest{
-		Model: "deepseek-chat",
-		Messages: []model_util.DeepseekChatMessage{
-			{Role: "user", Content: prompt},
-		},
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
+	content, err := AskLLM(ctx, g.llm, prompt, g.opts)
 	if err != nil {
-		return nil, fmt.Errorf("gagal encode JSON: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", "https://api.deepseek.com/v1/chat/completions", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("gagal buat request: %w", err)
-	}
-
-	// Use API key from environment variable
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("gagal kirim request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("gagal baca response: %w", err)
-	}
-
-	var result model_util.DeepseekChatResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		fmt.Println("Respon mentah:\n", string(respBody))
-		return nil, fmt.Errorf("gagal decode response JSON: %w", err)
-	}
-
-	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
-		fmt.Println("Respon mentah:\n", string(respBody))
-		return nil, fmt.Errorf("tidak ada hasil dari Deepseek")
+		return nil, err
 	}
 
-	content := result.Choices[0].Message.Content
 	article := parseGeneratedArticle(content, topicID)
 
 	return article, nil
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"pijar/model"
+)
+
+// CoachTranscriptMarkdown menulis transkrip sesi coach sebagai dokumen Markdown
+func CoachTranscriptMarkdown(transcript model.CoachTranscript) string {
+	var b strings.Builder
+
+	title := transcript.Title
+	if title == "" {
+		title = "Sesi AI Coach"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "- **ID Sesi:** %s\n", transcript.SessionID)
+	if transcript.Persona != "" {
+		fmt.Fprintf(&b, "- **Persona:** %s\n", transcript.Persona)
+	}
+	if !transcript.StartedAt.IsZero() {
+		fmt.Fprintf(&b, "- **Dimulai:** %s\n", transcript.StartedAt.Format("02 January 2006 15:04"))
+	}
+	fmt.Fprintf(&b, "- **Diekspor:** %s\n\n---\n", transcript.ExportedAt.Format("02 January 2006 15:04"))
+
+	for _, msg := range transcript.Messages {
+		b.WriteString("\n### " + msg.Speaker)
+		if msg.Timestamp != nil {
+			b.WriteString(" - " + msg.Timestamp.Format("02 Jan 2006 15:04"))
+		}
+		b.WriteString("\n\n")
+		b.WriteString(strings.TrimSpace(strings.ReplaceAll(msg.Content, "\r\n", "\n")))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"pijar/model"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/email/*
+var emailTemplateFS embed.FS
+
+// EmailData adalah data yang tersedia untuk semua template email
+type EmailData struct {
+	AppName          string
+	Subject          string
+	Name             string
+	Code             string
+	ExpiresInMinutes int
+
+	// Laporan trend
+	PeriodName       string // "minggu" atau "bulan"
+	PeriodLabel      string // rentang tanggal periode
+	MoodTrend        string // "membaik", "menurun" atau "stabil"
+	AverageSentiment string
+	Digest           []string // ringkasan AI, satu item per paragraf
+}
+
+// EmailRenderer merender template email (HTML + teks) menjadi EmailMessage
+type EmailRenderer struct {
+	appName string
+}
+
+func NewEmailRenderer(appName string) *EmailRenderer {
+	if appName == "" {
+		appName = "Pijar"
+	}
+	return &EmailRenderer{appName: appName}
+}
+
+// OTPEmail membuat email berisi kode OTP untuk registrasi
+func (r *EmailRenderer) OTPEmail(to, code string, expiresIn time.Duration) (EmailMessage, error) {
+	return r.render("otp", to, "Kode verifikasi "+r.appName, EmailData{
+		Code:             code,
+		ExpiresInMinutes: int(expiresIn.Minutes()),
+	})
+}
+
+// WelcomeEmail membuat email sambutan setelah akun aktif
+func (r *EmailRenderer) WelcomeEmail(to, name string) (EmailMessage, error) {
+	return r.render("welcome", to, "Selamat datang di "+r.appName, EmailData{
+		Name: name,
+	})
+}
+
+// PasswordResetEmail membuat email berisi kode reset password
+func (r *EmailRenderer) PasswordResetEmail(to, code string, expiresIn time.Duration) (EmailMessage, error) {
+	return r.render("password_reset", to, "Reset password "+r.appName, EmailData{
+		Code:             code,
+		ExpiresInMinutes: int(expiresIn.Minutes()),
+	})
+}
+
+// TrendReportEmail membuat email laporan trend mingguan atau bulanan beserta ringkasan AI
+func (r *EmailRenderer) TrendReportEmail(to, name string, trend *model.TrendAnalysis) (EmailMessage, error) {
+	periodName := "minggu"
+	if trend.PeriodType == TrendPeriodMonthly {
+		periodName = "bulan"
+	}
+	moodTrend := map[string]string{"improving": "membaik", "declining": "menurun"}[trend.MoodTrend]
+	if moodTrend == "" {
+		moodTrend = "stabil"
+	}
+
+	var digest []string
+	for _, paragraph := range strings.Split(trend.Digest, "\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			digest = append(digest, paragraph)
+		}
+	}
+
+	return r.render("trend_report", to, fmt.Sprintf("Ringkasan %s kamu di %s", periodName, r.appName), EmailData{
+		Name:             name,
+		PeriodName:       periodName,
+		PeriodLabel:      trend.PeriodStart.Format("02/01/2006") + " - " + trend.PeriodEnd.Format("02/01/2006"),
+		MoodTrend:        moodTrend,
+		AverageSentiment: fmt.Sprintf("%.2f", trend.AverageSentiment),
+		Digest:           digest,
+	})
+}
+
+func (r *EmailRenderer) render(name, to, subject string, data EmailData) (EmailMessage, error) {
+	data.AppName = r.appName
+	data.Subject = subject
+
+	htmlTmpl, err := htmltemplate.ParseFS(emailTemplateFS, "templates/email/layout.html", "templates/email/"+name+".html")
+	if err != nil {
+		return EmailMessage{}, fmt.Errorf("failed to parse %s html template: %w", name, err)
+	}
+	var htmlBody bytes.Buffer
+	if err := htmlTmpl.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return EmailMessage{}, fmt.Errorf("failed to render %s html template: %w", name, err)
+	}
+
+	textTmpl, err := texttemplate.ParseFS(emailTemplateFS, "templates/email/"+name+".txt")
+	if err != nil {
+		return EmailMessage{}, fmt.Errorf("failed to parse %s text template: %w", name, err)
+	}
+	var textBody bytes.Buffer
+	if err := textTmpl.Execute(&textBody, data); err != nil {
+		return EmailMessage{}, fmt.Errorf("failed to render %s text template: %w", name, err)
+	}
+
+	return EmailMessage{
+		To:       to,
+		Subject:  subject,
+		TextBody: textBody.String(),
+		HTMLBody: htmlBody.String(),
+	}, nil
+}
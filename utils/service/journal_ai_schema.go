@@ -0,0 +1,245 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error bertipe untuk jawaban analisis jurnal dari AI yang tidak bisa dipakai. Analisis seperti ini
+// tidak disimpan agar statistik trend tidak tercemar.
+var (
+	ErrAnalysisMalformed = errors.New("AI analysis is not valid JSON")
+	ErrAnalysisInvalid   = errors.New("AI analysis does not match the expected schema")
+)
+
+// AnalysisValidationError menjelaskan kenapa jawaban AI ditolak. errors.Is(err, ErrAnalysisMalformed)
+// atau errors.Is(err, ErrAnalysisInvalid) bernilai true sesuai jenisnya.
+type AnalysisValidationError struct {
+	Kind     error
+	Problems []string // satu masalah per field, misalnya "sentiment_score: must be between -1 and 1"
+}
+
+func (e *AnalysisValidationError) Error() string {
+	if len(e.Problems) == 0 {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, strings.Join(e.Problems, "; "))
+}
+
+func (e *AnalysisValidationError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Batas jumlah item per daftar pada hasil analisis
+const (
+	analysisMaxEmotions = 3
+	analysisMaxKeywords = 5
+	analysisMaxThemes   = 3
+)
+
+// EmotionTaxonomy adalah daftar emosi yang boleh muncul di hasil analisis. Emosi lain dari AI
+// dipetakan lewat emotionSynonyms atau dibuang.
+var EmotionTaxonomy = []string{
+	"joy", "gratitude", "calm", "hope", "pride", "love", "excitement", "relief",
+	"sadness", "loneliness", "anxiety", "fear", "stress", "anger", "frustration",
+	"guilt", "shame", "disappointment", "confusion", "exhaustion", "boredom",
+}
+
+// emotionSynonyms memetakan label yang sering dipakai AI (termasuk bahasa Indonesia) ke taksonomi
+var emotionSynonyms = map[string]string{
+	"happy": "joy", "happiness": "joy", "senang": "joy", "bahagia": "joy", "gembira": "joy",
+	"grateful": "gratitude", "thankful": "gratitude", "bersyukur": "gratitude", "syukur": "gratitude",
+	"peaceful": "calm", "relaxed": "calm", "content": "calm", "contentment": "calm", "tenang": "calm", "damai": "calm",
+	"hopeful": "hope", "optimism": "hope", "optimistic": "hope", "harapan": "hope", "berharap": "hope",
+	"proud": "pride", "bangga": "pride",
+	"affection": "love", "cinta": "love", "sayang": "love",
+	"excited": "excitement", "enthusiasm": "excitement", "antusias": "excitement", "semangat": "excitement",
+	"relieved": "relief", "lega": "relief",
+	"sad": "sadness", "sorrow": "sadness", "grief": "sadness", "depression": "sadness", "sedih": "sadness",
+	"lonely": "loneliness", "isolation": "loneliness", "kesepian": "loneliness",
+	"anxious": "anxiety", "worry": "anxiety", "worried": "anxiety", "nervous": "anxiety", "nervousness": "anxiety",
+	"cemas": "anxiety", "khawatir": "anxiety", "gelisah": "anxiety",
+	"afraid": "fear", "scared": "fear", "takut": "fear",
+	"stressed": "stress", "overwhelmed": "stress", "tension": "stress", "pressure": "stress", "stres": "stress", "tertekan": "stress",
+	"angry": "anger", "rage": "anger", "marah": "anger",
+	"frustrated": "frustration", "annoyed": "frustration", "irritation": "frustration", "kesal": "frustration", "jengkel": "frustration",
+	"guilty": "guilt", "bersalah": "guilt",
+	"ashamed": "shame", "embarrassment": "shame", "malu": "shame",
+	"disappointed": "disappointment", "kecewa": "disappointment",
+	"confused": "confusion", "uncertainty": "confusion", "bingung": "confusion",
+	"tired": "exhaustion", "fatigue": "exhaustion", "exhausted": "exhaustion", "lelah": "exhaustion", "capek": "exhaustion",
+	"bored": "boredom", "bosan": "boredom",
+}
+
+// journalAnalysisSchema adalah structured output yang diminta dari AI untuk analisis jurnal
+var journalAnalysisSchema = func() *JSONSchema {
+	minScore, maxScore := -1.0, 1.0
+	one := 1
+	maxEmotions, maxKeywords, maxThemes := analysisMaxEmotions, analysisMaxKeywords, analysisMaxThemes
+	stringList := func(description string, max *int) *JSONSchema {
+		return &JSONSchema{
+			Type:        "array",
+			Description: description,
+			Items:       &JSONSchema{Type: "string"},
+			MinItems:    &one,
+			MaxItems:    max,
+		}
+	}
+	emotions := stringList("Dominant emotions, most dominant first", &maxEmotions)
+	emotions.Items.Enum = EmotionTaxonomy
+
+	return &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"sentiment_score": {
+				Type:        "number",
+				Description: "Overall sentiment from -1.0 (very negative) to 1.0 (very positive)",
+				Minimum:     &minScore,
+				Maximum:     &maxScore,
+			},
+			"emotions":        emotions,
+			"keywords":        stringList("Short keywords from the entry", &maxKeywords),
+			"themes":          stringList("Main themes or topics", &maxThemes),
+			"insights":        {Type: "string", Description: "Brief psychological insight about this entry"},
+			"recommendations": {Type: "string", Description: "Specific actionable suggestions for the user"},
+		},
+		Required: []string{"sentiment_score", "emotions", "keywords", "themes", "insights", "recommendations"},
+	}
+}()
+
+// journalAnalysisResult adalah hasil analisis AI yang sudah divalidasi dan dinormalisasi
+type journalAnalysisResult struct {
+	SentimentScore  float64
+	Emotions        []string
+	Keywords        []string
+	Themes          []string
+	Insights        string
+	Recommendations string
+}
+
+// parseJournalAnalysis memvalidasi jawaban AI terhadap journalAnalysisSchema. Emosi dipetakan ke
+// EmotionTaxonomy, daftar dirapikan dan dipotong sesuai batas.
+func parseJournalAnalysis(response string) (journalAnalysisResult, error) {
+	// Provider tanpa structured output kadang membungkus JSON dengan teks atau code fence
+	jsonStart := strings.Index(response, "{")
+	jsonEnd := strings.LastIndex(response, "}")
+	if jsonStart == -1 || jsonEnd < jsonStart {
+		return journalAnalysisResult{}, &AnalysisValidationError{Kind: ErrAnalysisMalformed, Problems: []string{"no JSON object found"}}
+	}
+
+	var raw struct {
+		SentimentScore  *float64 `json:"sentiment_score"`
+		Emotions        []string `json:"emotions"`
+		Keywords        []string `json:"keywords"`
+		Themes          []string `json:"themes"`
+		Insights        string   `json:"insights"`
+		Recommendations string   `json:"recommendations"`
+	}
+	if err := json.Unmarshal([]byte(response[jsonStart:jsonEnd+1]), &raw); err != nil {
+		return journalAnalysisResult{}, &AnalysisValidationError{Kind: ErrAnalysisMalformed, Problems: []string{err.Error()}}
+	}
+
+	var problems []string
+	result := journalAnalysisResult{
+		Emotions:        normalizeEmotions(raw.Emotions),
+		Keywords:        cleanList(raw.Keywords, analysisMaxKeywords),
+		Themes:          cleanList(raw.Themes, analysisMaxThemes),
+		Insights:        strings.TrimSpace(raw.Insights),
+		Recommendations: strings.TrimSpace(raw.Recommendations),
+	}
+
+	switch {
+	case raw.SentimentScore == nil:
+		problems = append(problems, "sentiment_score: is required")
+	case *raw.SentimentScore < -1 || *raw.SentimentScore > 1:
+		problems = append(problems, fmt.Sprintf("sentiment_score: must be between -1 and 1, got %v", *raw.SentimentScore))
+	default:
+		result.SentimentScore = *raw.SentimentScore
+	}
+	if len(result.Emotions) == 0 {
+		problems = append(problems, "emotions: must contain at least one of "+strings.Join(EmotionTaxonomy, ", "))
+	}
+	if len(result.Keywords) == 0 {
+		problems = append(problems, "keywords: must not be empty")
+	}
+	if len(result.Themes) == 0 {
+		problems = append(problems, "themes: must not be empty")
+	}
+	if result.Insights == "" {
+		problems = append(problems, "insights: must not be empty")
+	}
+	if result.Recommendations == "" {
+		problems = append(problems, "recommendations: must not be empty")
+	}
+
+	if len(problems) > 0 {
+		return journalAnalysisResult{}, &AnalysisValidationError{Kind: ErrAnalysisInvalid, Problems: problems}
+	}
+	return result, nil
+}
+
+// normalizeEmotion memetakan label emosi ke EmotionTaxonomy, string kosong jika tidak dikenal
+func normalizeEmotion(emotion string) string {
+	emotion = strings.ToLower(strings.Trim(strings.TrimSpace(emotion), ".!"))
+	if mapped, ok := emotionSynonyms[emotion]; ok {
+		return mapped
+	}
+	for _, known := range EmotionTaxonomy {
+		if emotion == known {
+			return known
+		}
+	}
+	return ""
+}
+
+func normalizeEmotions(emotions []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, emotion := range emotions {
+		emotion = normalizeEmotion(emotion)
+		if emotion == "" || seen[emotion] {
+			continue
+		}
+		seen[emotion] = true
+		normalized = append(normalized, emotion)
+		if len(normalized) == analysisMaxEmotions {
+			break
+		}
+	}
+	return normalized
+}
+
+// cleanList membuang item kosong dan duplikat, lalu memotong daftar sampai max item
+func cleanList(items []string, max int) []string {
+	var cleaned []string
+	seen := map[string]bool{}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		key := strings.ToLower(item)
+		if item == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, item)
+		if len(cleaned) == max {
+			break
+		}
+	}
+	return cleaned
+}
+
+// analysisRepairPrompt meminta AI mengirim ulang jawabannya setelah gagal validasi
+func analysisRepairPrompt(err error) string {
+	return fmt.Sprintf(`Your previous answer could not be used: %v
+
+Reply again with only the JSON object, no other text. It must contain:
+- "sentiment_score": a number from -1.0 to 1.0
+- "emotions": 1 to %d values, each one of: %s
+- "keywords": 1 to %d short strings
+- "themes": 1 to %d short strings
+- "insights": a non-empty string
+- "recommendations": a non-empty string`,
+		err, analysisMaxEmotions, strings.Join(EmotionTaxonomy, ", "), analysisMaxKeywords, analysisMaxThemes)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJournalAnalysis(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     string
+		want         journalAnalysisResult
+		wantKind     error
+		wantProblems []string
+	}{
+		{
+			name: "valid answer",
+			response: `{"sentiment_score": -0.4, "emotions": ["sadness", "exhaustion"], "keywords": ["kerja"],
+				"themes": ["pekerjaan"], "insights": "Kamu lelah.", "recommendations": "Istirahat sejenak."}`,
+			want: journalAnalysisResult{
+				SentimentScore:  -0.4,
+				Emotions:        []string{"sadness", "exhaustion"},
+				Keywords:        []string{"kerja"},
+				Themes:          []string{"pekerjaan"},
+				Insights:        "Kamu lelah.",
+				Recommendations: "Istirahat sejenak.",
+			},
+		},
+		{
+			name: "code fence and synonyms are normalized",
+			response: "```json\n" + `{"sentiment_score": 0.5, "emotions": ["Senang", "happy", "lega", "unknown", "calm"],
+				"keywords": [" kopi ", "Kopi", "", "pagi"], "themes": ["rutinitas"],
+				"insights": " Pagi yang baik. ", "recommendations": "Pertahankan."}` + "\n```",
+			want: journalAnalysisResult{
+				SentimentScore:  0.5,
+				Emotions:        []string{"joy", "relief", "calm"},
+				Keywords:        []string{"kopi", "pagi"},
+				Themes:          []string{"rutinitas"},
+				Insights:        "Pagi yang baik.",
+				Recommendations: "Pertahankan.",
+			},
+		},
+		{
+			name: "lists are cut to their limits",
+			response: `{"sentiment_score": 0, "emotions": ["joy", "calm", "hope", "pride"],
+				"keywords": ["a", "b", "c", "d", "e", "f"], "themes": ["x", "y", "z", "w"],
+				"insights": "i", "recommendations": "r"}`,
+			want: journalAnalysisResult{
+				Emotions:        []string{"joy", "calm", "hope"},
+				Keywords:        []string{"a", "b", "c", "d", "e"},
+				Themes:          []string{"x", "y", "z"},
+				Insights:        "i",
+				Recommendations: "r",
+			},
+		},
+		{
+			name:         "no JSON object",
+			response:     "Maaf, saya tidak bisa menganalisis jurnal ini.",
+			wantKind:     ErrAnalysisMalformed,
+			wantProblems: []string{"no JSON object found"},
+		},
+		{
+			name:     "broken JSON",
+			response: `{"sentiment_score": 0.2, "emotions": ["joy",}`,
+			wantKind: ErrAnalysisMalformed,
+		},
+		{
+			name: "score out of range",
+			response: `{"sentiment_score": 1.5, "emotions": ["joy"], "keywords": ["a"], "themes": ["b"],
+				"insights": "i", "recommendations": "r"}`,
+			wantKind:     ErrAnalysisInvalid,
+			wantProblems: []string{"sentiment_score: must be between -1 and 1, got 1.5"},
+		},
+		{
+			name:     "missing fields",
+			response: `{"emotions": ["rindu"], "keywords": [" "], "themes": [], "insights": "", "recommendations": "  "}`,
+			wantKind: ErrAnalysisInvalid,
+			wantProblems: []string{
+				"sentiment_score: is required",
+				"emotions: must contain at least one of " + strings.Join(EmotionTaxonomy, ", "),
+				"keywords: must not be empty",
+				"themes: must not be empty",
+				"insights: must not be empty",
+				"recommendations: must not be empty",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJournalAnalysis(tt.response)
+			if tt.wantKind != nil {
+				if !errors.Is(err, tt.wantKind) {
+					t.Fatalf("parseJournalAnalysis() error = %v, want %v", err, tt.wantKind)
+				}
+				var validationErr *AnalysisValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("error %T is not an AnalysisValidationError", err)
+				}
+				if tt.wantProblems != nil && !reflect.DeepEqual(validationErr.Problems, tt.wantProblems) {
+					t.Errorf("problems = %q, want %q", validationErr.Problems, tt.wantProblems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJournalAnalysis() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJournalAnalysis() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"pijar/model"
 	"sort"
 	"strings"
@@ -10,13 +12,9 @@ import (
 )
 
 type JournalAnalysisService struct {
-	aiClient AIClient // Interface untuk AI client (Gemini/DeepSeek/etc)
-	repo     JournalAnalysisRepository
-}
-
-// Interface untuk AI client
-type AIClient interface {
-	GetAIResponse(prompt string) (string, error)
+	llm     LLMProvider // provider yang dikonfigurasi untuk analisis jurnal (Gemini/DeepSeek/etc)
+	llmOpts LLMOptions
+	repo    JournalAnalysisRepository
 }
 
 // Interface untuk repository
@@ -24,56 +22,87 @@ type JournalAnalysisRepository interface {
 	Save(analysis *model.JournalAnalysis) error
 	SaveTrend(trend *model.TrendAnalysis) error
 	GetByJournalID(journalID int) (*model.JournalAnalysis, error)
+	UpdateAnalysis(analysis *model.JournalAnalysis) error
 	GetByUserID(userID int, limit int) ([]*model.JournalAnalysis, error)
+	GetByUserIDBetween(userID int, start, end time.Time) ([]*model.JournalAnalysis, error)
 	GetTrendsByUserID(userID int, periodType string) ([]*model.TrendAnalysis, error)
 }
 
-func NewJournalAnalysisService(aiClient AIClient, repo JournalAnalysisRepository) *JournalAnalysisService {
+func NewJournalAnalysisService(llm LLMProvider, llmOpts LLMOptions, repo JournalAnalysisRepository) *JournalAnalysisService {
 	return &JournalAnalysisService{
-		aiClient: aiClient,
-		repo:     repo,
+		llm:     llm,
+		llmOpts: llmOpts,
+		repo:    repo,
+	}
+}
+
+// AnalyzeJournalEntry menganalisis single journal entry tanpa menyimpannya. Hasilnya diperiksa
+// safety screening dulu, lalu disimpan dengan SaveAnalysis.
+func (j *JournalAnalysisService) AnalyzeJournalEntry(ctx context.Context, req *model.AnalysisRequest) (*model.AnalysisResponse, error) {
+	analysis, err := j.runAnalysis(ctx, req)
+	if err != nil {
+		return nil, err
 	}
+	return j.BuildResponse(analysis), nil
 }
 
-// AnalyzeJournalEntry menganalisis single journal entry
-func (j *JournalAnalysisService) AnalyzeJournalEntry(req *model.AnalysisRequest) (*model.AnalysisResponse, error) {
-	// Cek apakah sudah pernah dianalisis
-	existing, _ := j.repo.GetByJournalID(req.JournalID)
-	if existing != nil {
-		return &model.AnalysisResponse{
-			JournalAnalysis: existing,
-			Summary:         "Analysis already exists",
-		}, nil
+// SaveAnalysis menyimpan analisis jurnal, atau mengganti analisis yang sudah ada setelah isi jurnal berubah
+func (j *JournalAnalysisService) SaveAnalysis(analysis *model.JournalAnalysis) error {
+	var err error
+	existing, _ := j.repo.GetByJournalID(analysis.JournalID)
+	if existing == nil {
+		err = j.repo.Save(analysis)
+	} else {
+		analysis.ID = existing.ID
+		analysis.CreatedAt = existing.CreatedAt
+		err = j.repo.UpdateAnalysis(analysis)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save analysis: %w", err)
 	}
+	return nil
+}
 
+// runAnalysis mengirim journal entry ke AI dan mem-parsing hasilnya
+func (j *JournalAnalysisService) runAnalysis(ctx context.Context, req *model.AnalysisRequest) (*model.JournalAnalysis, error) {
 	// Prompt untuk AI analysis
 	prompt := j.buildAnalysisPrompt(req)
+	opts := j.llmOpts
+	opts.ResponseSchema = journalAnalysisSchema
 
 	// Kirim ke AI
-	response, err := j.aiClient.GetAIResponse(prompt)
+	response, err := AskLLM(ctx, j.llm, prompt, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get AI analysis: %w", err)
 	}
 
-	// Parse AI response
-	analysis, err := j.parseAIResponse(response, req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
-	}
-
-	// Simpan ke database
-	if err := j.repo.Save(analysis); err != nil {
-		return nil, fmt.Errorf("failed to save analysis: %w", err)
+	// Validasi AI response; jawaban yang tidak valid diminta diperbaiki satu kali
+	result, validationErr := parseJournalAnalysis(response)
+	if validationErr != nil {
+		log.Printf("AI analysis for journal %d is invalid, asking for a repair: %v", req.JournalID, validationErr)
+		response, err = j.llm.Chat(ctx, []model.Message{
+			{Role: "user", Content: prompt},
+			{Role: "assistant", Content: response},
+			{Role: "user", Content: analysisRepairPrompt(validationErr)},
+		}, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get AI analysis: %w", err)
+		}
+		if result, validationErr = parseJournalAnalysis(response); validationErr != nil {
+			return nil, fmt.Errorf("failed to parse AI response: %w", validationErr)
+		}
 	}
 
-	// Generate action items
-	actionItems := j.generateActionItems(analysis)
+	return j.buildAnalysis(result, req), nil
+}
 
+// BuildResponse melengkapi analisis dengan ringkasan dan action item
+func (j *JournalAnalysisService) BuildResponse(analysis *model.JournalAnalysis) *model.AnalysisResponse {
 	return &model.AnalysisResponse{
 		JournalAnalysis: analysis,
 		Summary:         j.generateSummary(analysis),
-		ActionItems:     actionItems,
-	}, nil
+		ActionItems:     j.generateActionItems(analysis),
+	}
 }
 
 // buildAnalysisPrompt membuat prompt untuk AI analysis
@@ -87,7 +116,7 @@ User's Feeling: %s
 
 Please provide your analysis in the following JSON format:
 {
-  "sentiment_score": -0.5 to 1.0,
+  "sentiment_score": -1.0 to 1.0,
   "emotions": ["emotion1", "emotion2", "emotion3"],
   "keywords": ["keyword1", "keyword2", "keyword3"],
   "themes": ["theme1", "theme2"],
@@ -97,41 +126,17 @@ Please provide your analysis in the following JSON format:
 
 Focus on:
 1. Overall emotional tone and sentiment
-2. Dominant emotions (max 3)
+2. Dominant emotions (max %d), chosen only from: %s
 3. Key themes and topics
 4. Psychological patterns or concerns
 5. Constructive suggestions for mental wellness
 
 Keep insights empathetic, non-judgmental, and focused on growth.`,
-		req.Title, req.Content, req.Feeling)
+		req.Title, req.Content, req.Feeling, analysisMaxEmotions, strings.Join(EmotionTaxonomy, ", "))
 }
 
-// parseAIResponse parsing response dari AI menjadi JournalAnalysis
-func (j *JournalAnalysisService) parseAIResponse(response string, req *model.AnalysisRequest) (*model.JournalAnalysis, error) {
-	// Extract JSON dari response (AI mungkin menambahkan text lain)
-	jsonStart := strings.Index(response, "{")
-	jsonEnd := strings.LastIndex(response, "}") + 1
-
-	if jsonStart == -1 || jsonEnd == 0 {
-		return nil, fmt.Errorf("no valid JSON found in AI response")
-	}
-
-	jsonResponse := response[jsonStart:jsonEnd]
-
-	// Parse JSON
-	var aiResult struct {
-		SentimentScore  float64  `json:"sentiment_score"`
-		Emotions        []string `json:"emotions"`
-		Keywords        []string `json:"keywords"`
-		Themes          []string `json:"themes"`
-		Insights        string   `json:"insights"`
-		Recommendations string   `json:"recommendations"`
-	}
-
-	if err := json.Unmarshal([]byte(jsonResponse), &aiResult); err != nil {
-		return nil, fmt.Errorf("failed to parse AI JSON: %w", err)
-	}
-
+// buildAnalysis mengubah hasil AI yang sudah divalidasi menjadi JournalAnalysis
+func (j *JournalAnalysisService) buildAnalysis(aiResult journalAnalysisResult, req *model.AnalysisRequest) *model.JournalAnalysis {
 	// Convert arrays to JSON strings for storage
 	emotionsJSON, _ := json.Marshal(aiResult.Emotions)
 	keywordsJSON, _ := json.Marshal(aiResult.Keywords)
@@ -149,7 +154,7 @@ func (j *JournalAnalysisService) parseAIResponse(response string, req *model.Ana
 		AnalyzedAt:      time.Now(),
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
-	}, nil
+	}
 }
 
 // generateActionItems membuat action items berdasarkan analysis
@@ -171,9 +176,9 @@ func (j *JournalAnalysisService) generateActionItems(analysis *model.JournalAnal
 
 	for _, emotion := range emotions {
 		switch strings.ToLower(emotion) {
-		case "anxiety", "worry", "stress":
+		case "anxiety", "fear", "stress":
 			actions = append(actions, "Try deep breathing or meditation exercises")
-		case "sadness", "depression":
+		case "sadness", "loneliness":
 			actions = append(actions, "Engage in physical activity or creative expression")
 		case "anger", "frustration":
 			actions = append(actions, "Practice mindful observation of triggers")
@@ -200,26 +205,41 @@ func (j *JournalAnalysisService) generateSummary(analysis *model.JournalAnalysis
 		sentiment, analysis.SentimentScore, emotionStr)
 }
 
-// GenerateTrendAnalysis membuat analisis trend untuk periode tertentu
+// GenerateTrendAnalysis membuat analisis trend untuk periode kalender di zona waktu user dan
+// membandingkannya dengan periode sebelumnya
 func (j *JournalAnalysisService) GenerateTrendAnalysis(req *model.TrendRequest) (*model.TrendResponse, error) {
-	// Ambil analyses dalam periode yang diminta
-	endDate := time.Now()
-	startDate := endDate.AddDate(0, 0, -req.Days)
+	current, previous, err := ResolveTrendPeriod(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
 
-	analyses, err := j.repo.GetByUserID(req.UserID, req.Days)
+	// Satu query untuk periode sebelumnya dan periode yang diminta, lalu dipisah
+	analyses, err := j.repo.GetByUserIDBetween(req.UserID, previous.Start, current.End)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get analyses: %w", err)
 	}
+	var currentAnalyses, previousAnalyses []*model.JournalAnalysis
+	for _, analysis := range analyses {
+		if current.Contains(analysis.JournalCreatedAt) {
+			currentAnalyses = append(currentAnalyses, analysis)
+		} else if previous.Contains(analysis.JournalCreatedAt) {
+			previousAnalyses = append(previousAnalyses, analysis)
+		}
+	}
+	// Urutan waktu dipakai timeline chart, jangan bergantung pada ID
+	sort.SliceStable(currentAnalyses, func(a, b int) bool {
+		return currentAnalyses[a].JournalCreatedAt.Before(currentAnalyses[b].JournalCreatedAt)
+	})
 
-	if len(analyses) == 0 {
-		return nil, fmt.Errorf("no journal analyses found for trend analysis")
+	if len(currentAnalyses) == 0 {
+		return nil, ErrNoAnalysesInPeriod
 	}
 
 	// Calculate trend metrics
-	trendAnalysis := j.calculateTrendMetrics(analyses, req)
+	trendAnalysis := j.calculateTrendMetrics(currentAnalyses, current, req)
 	trendAnalysis.UserID = req.UserID
-	trendAnalysis.PeriodStart = startDate
-	trendAnalysis.PeriodEnd = endDate
+	trendAnalysis.PeriodStart = current.Start
+	trendAnalysis.PeriodEnd = current.LastDay()
 	trendAnalysis.CreatedAt = time.Now()
 	trendAnalysis.UpdatedAt = time.Now()
 
@@ -229,8 +249,8 @@ func (j *JournalAnalysisService) GenerateTrendAnalysis(req *model.TrendRequest)
 	}
 
 	// Generate comparison data dan charts
-	comparisonData := j.generateComparisonData(analyses)
-	charts := j.generateChartData(analyses)
+	comparisonData := j.generateComparisonData(currentAnalyses, previousAnalyses, current, previous)
+	charts := j.generateChartData(currentAnalyses, current.Start.Location())
 	recommendations := j.generateTrendRecommendations(trendAnalysis)
 
 	return &model.TrendResponse{
@@ -242,7 +262,7 @@ func (j *JournalAnalysisService) GenerateTrendAnalysis(req *model.TrendRequest)
 }
 
 // calculateTrendMetrics menghitung metrics untuk trend analysis
-func (j *JournalAnalysisService) calculateTrendMetrics(analyses []*model.JournalAnalysis, req *model.TrendRequest) *model.TrendAnalysis {
+func (j *JournalAnalysisService) calculateTrendMetrics(analyses []*model.JournalAnalysis, period TrendPeriod, req *model.TrendRequest) *model.TrendAnalysis {
 	// Calculate average sentiment
 	var totalSentiment float64
 	emotionCounts := make(map[string]int)
@@ -252,9 +272,7 @@ func (j *JournalAnalysisService) calculateTrendMetrics(analyses []*model.Journal
 		totalSentiment += analysis.SentimentScore
 
 		// Count emotions
-		var emotions []string
-		json.Unmarshal([]byte(analysis.Emotions), &emotions)
-		for _, emotion := range emotions {
+		for _, emotion := range storedEmotions(analysis) {
 			emotionCounts[emotion]++
 		}
 
@@ -273,7 +291,7 @@ func (j *JournalAnalysisService) calculateTrendMetrics(analyses []*model.Journal
 	topThemes := j.getTopItems(themeCounts, 3)
 
 	// Determine mood trend
-	moodTrend := j.calculateMoodTrend(analyses)
+	moodTrend := j.calculateMoodTrend(analyses, period)
 
 	// Generate insights
 	insights := j.generateTrendInsights(avgSentiment, moodTrend, topEmotions, topThemes)
@@ -303,8 +321,12 @@ func (j *JournalAnalysisService) getTopItems(counts map[string]int, limit int) [
 		sorted = append(sorted, kv{k, v})
 	}
 
+	// Jumlah yang sama diurutkan berdasarkan nama agar hasilnya tidak berubah-ubah
 	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].value > sorted[j].value
+		if sorted[i].value != sorted[j].value {
+			return sorted[i].value > sorted[j].value
+		}
+		return sorted[i].key < sorted[j].key
 	})
 
 	var result []string
@@ -315,37 +337,17 @@ func (j *JournalAnalysisService) getTopItems(counts map[string]int, limit int) [
 	return result
 }
 
-// calculateMoodTrend menentukan trend mood (improving/declining/stable)
-func (j *JournalAnalysisService) calculateMoodTrend(analyses []*model.JournalAnalysis) string {
-	if len(analyses) < 2 {
-		return "stable"
-	}
-
-	// Sort by date (assuming newer entries have higher ID)
-	sort.Slice(analyses, func(i, j int) bool {
-		return analyses[i].ID < analyses[j].ID
-	})
-
-	// Compare first half with second half
-	mid := len(analyses) / 2
-	firstHalf := analyses[:mid]
-	secondHalf := analyses[mid:]
+// moodTrendThreshold adalah perubahan sentimen minimal (menurut garis regresi) agar mood
+// dianggap membaik atau memburuk
+const moodTrendThreshold = 0.1
 
-	var firstAvg, secondAvg float64
-	for _, a := range firstHalf {
-		firstAvg += a.SentimentScore
-	}
-	firstAvg /= float64(len(firstHalf))
-
-	for _, a := range secondHalf {
-		secondAvg += a.SentimentScore
-	}
-	secondAvg /= float64(len(secondHalf))
-
-	diff := secondAvg - firstAvg
-	if diff > 0.1 {
+// calculateMoodTrend menentukan trend mood (improving/declining/stable) dari kemiringan regresi
+// linear skor sentimen terhadap waktu jurnal ditulis
+func (j *JournalAnalysisService) calculateMoodTrend(analyses []*model.JournalAnalysis, period TrendPeriod) string {
+	_, change := sentimentRegression(analyses, period.Start)
+	if change > moodTrendThreshold {
 		return "improving"
-	} else if diff < -0.1 {
+	} else if change < -moodTrendThreshold {
 		return "declining"
 	}
 	return "stable"
@@ -362,53 +364,106 @@ func (j *JournalAnalysisService) generateTrendInsights(avgSentiment float64, moo
 		avgSentiment, moodTrend, emotionStr, themeStr)
 }
 
-// generateComparisonData membuat data perbandingan
-func (j *JournalAnalysisService) generateComparisonData(analyses []*model.JournalAnalysis) map[string]interface{} {
-	if len(analyses) == 0 {
+// generateComparisonData membandingkan periode yang diminta dengan periode sebelumnya
+func (j *JournalAnalysisService) generateComparisonData(current, previous []*model.JournalAnalysis, currentPeriod, previousPeriod TrendPeriod) map[string]interface{} {
+	if len(current) == 0 {
 		return nil
 	}
 
+	slope, change := sentimentRegression(current, currentPeriod.Start)
+	currentStats := j.periodStats(current, currentPeriod)
+	previousStats := j.periodStats(previous, previousPeriod)
+
+	comparison := map[string]interface{}{
+		"timezone":                   currentPeriod.Start.Location().String(),
+		"total_entries":              currentStats["total_entries"],
+		"highest_sentiment":          currentStats["highest_sentiment"],
+		"lowest_sentiment":           currentStats["lowest_sentiment"],
+		"avg_sentiment":              currentStats["avg_sentiment"],
+		"sentiment_slope_per_day":    slope,
+		"sentiment_change_in_period": change,
+		"current_period":             currentStats,
+		"previous_period":            previousStats,
+		"entry_count_change":         len(current) - len(previous),
+		"avg_sentiment_change":       nil, // null jika periode sebelumnya tidak punya entri
+	}
+	if len(previous) > 0 {
+		comparison["avg_sentiment_change"] = currentStats["avg_sentiment"].(float64) - previousStats["avg_sentiment"].(float64)
+	}
+	return comparison
+}
+
+// periodStats merangkum analisis dalam satu periode
+func (j *JournalAnalysisService) periodStats(analyses []*model.JournalAnalysis, period TrendPeriod) map[string]interface{} {
+	stats := map[string]interface{}{
+		"start_date":    period.Start.Format(trendDateLayout),
+		"end_date":      period.LastDay().Format(trendDateLayout),
+		"total_entries": len(analyses),
+	}
+	if len(analyses) == 0 {
+		return stats
+	}
+
 	var sentiments []float64
 	for _, a := range analyses {
 		sentiments = append(sentiments, a.SentimentScore)
 	}
-
-	return map[string]interface{}{
-		"total_entries":     len(analyses),
-		"highest_sentiment": j.maxFloat64(sentiments),
-		"lowest_sentiment":  j.minFloat64(sentiments),
-		"avg_sentiment":     j.avgFloat64(sentiments),
-	}
+	stats["highest_sentiment"] = j.maxFloat64(sentiments)
+	stats["lowest_sentiment"] = j.minFloat64(sentiments)
+	stats["avg_sentiment"] = j.avgFloat64(sentiments)
+	return stats
 }
 
-// generateChartData membuat data untuk chart visualization
-func (j *JournalAnalysisService) generateChartData(analyses []*model.JournalAnalysis) map[string]interface{} {
+// generateChartData membuat data untuk chart visualization. Tanggal memakai zona waktu user.
+func (j *JournalAnalysisService) generateChartData(analyses []*model.JournalAnalysis, loc *time.Location) map[string]interface{} {
 	// Sentiment over time
 	var sentimentData []map[string]interface{}
+	var dailyData []map[string]interface{}
+	dailyIndex := make(map[string]int)
 	for i, a := range analyses {
+		date := a.JournalCreatedAt.In(loc).Format(trendDateLayout)
 		sentimentData = append(sentimentData, map[string]interface{}{
 			"entry":     i + 1,
 			"sentiment": a.SentimentScore,
-			"date":      a.AnalyzedAt.Format("2006-01-02"),
+			"date":      date,
 		})
+
+		// Rata-rata per hari, analyses sudah urut waktu
+		idx, ok := dailyIndex[date]
+		if !ok {
+			idx = len(dailyData)
+			dailyIndex[date] = idx
+			dailyData = append(dailyData, map[string]interface{}{"date": date, "avg_sentiment": 0.0, "entry_count": 0})
+		}
+		count := dailyData[idx]["entry_count"].(int)
+		avg := dailyData[idx]["avg_sentiment"].(float64)
+		dailyData[idx]["avg_sentiment"] = (avg*float64(count) + a.SentimentScore) / float64(count+1)
+		dailyData[idx]["entry_count"] = count + 1
 	}
 
 	// Emotion frequency
 	emotionCounts := make(map[string]int)
 	for _, a := range analyses {
-		var emotions []string
-		json.Unmarshal([]byte(a.Emotions), &emotions)
-		for _, emotion := range emotions {
+		for _, emotion := range storedEmotions(a) {
 			emotionCounts[emotion]++
 		}
 	}
 
 	return map[string]interface{}{
 		"sentiment_timeline": sentimentData,
+		"daily_sentiment":    dailyData,
 		"emotion_frequency":  emotionCounts,
 	}
 }
 
+// storedEmotions membaca emosi analisis tersimpan dan memetakannya ke EmotionTaxonomy, termasuk
+// analisis lama yang dibuat sebelum emosi divalidasi
+func storedEmotions(analysis *model.JournalAnalysis) []string {
+	var emotions []string
+	json.Unmarshal([]byte(analysis.Emotions), &emotions)
+	return normalizeEmotions(emotions)
+}
+
 // generateTrendRecommendations membuat rekomendasi berdasarkan trend
 func (j *JournalAnalysisService) generateTrendRecommendations(trend *model.TrendAnalysis) []string {
 	var recommendations []string
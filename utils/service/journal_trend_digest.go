@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"pijar/model"
+	"strings"
+)
+
+// trendDigestOptions dipakai untuk ringkasan laporan trend. Jawabannya teks biasa, bukan JSON
+// seperti analisis jurnal.
+var trendDigestOptions = LLMOptions{
+	SystemPrompt: "You are a warm, encouraging journaling companion for a mental wellness app. You write short periodic reviews of a user's mood. Never diagnose, never mention being an AI, and never invent details that are not in the data.",
+	Temperature:  0.7,
+	MaxTokens:    500,
+}
+
+// GenerateTrendDigest menulis ringkasan "your week in review" dari hasil trend. Hanya angka
+// agregat, emosi dan tema yang dikirim ke AI; isi jurnal tidak ikut.
+func (j *JournalAnalysisService) GenerateTrendDigest(ctx context.Context, report *model.TrendResponse) (string, error) {
+	digest, err := AskLLM(ctx, j.llm, trendDigestPrompt(report), trendDigestOptions)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate trend digest: %w", err)
+	}
+	digest = strings.TrimSpace(digest)
+	if digest == "" {
+		return "", errors.New("AI returned an empty trend digest")
+	}
+	return digest, nil
+}
+
+func trendDigestPrompt(report *model.TrendResponse) string {
+	trend := report.TrendAnalysis
+
+	var emotions, themes []string
+	json.Unmarshal([]byte(trend.TopEmotions), &emotions)
+	json.Unmarshal([]byte(trend.KeyThemes), &themes)
+
+	period := "week"
+	if trend.PeriodType == TrendPeriodMonthly {
+		period = "month"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write a short review of the user's %s (%s to %s) based on their journal analyses.\n\n",
+		period, trend.PeriodStart.Format(trendDateLayout), trend.PeriodEnd.Format(trendDateLayout))
+	fmt.Fprintf(&b, "- Journal entries analyzed: %v\n", report.ComparisonData["total_entries"])
+	fmt.Fprintf(&b, "- Average sentiment (-1 to 1): %.2f\n", trend.AverageSentiment)
+	fmt.Fprintf(&b, "- Mood trend during the %s: %s\n", period, trend.MoodTrend)
+	if change, ok := report.ComparisonData["avg_sentiment_change"].(float64); ok {
+		fmt.Fprintf(&b, "- Change in average sentiment from the previous %s: %+.2f\n", period, change)
+	} else {
+		fmt.Fprintf(&b, "- No journal entries in the previous %s to compare with\n", period)
+	}
+	if len(emotions) > 0 {
+		fmt.Fprintf(&b, "- Most common emotions: %s\n", strings.Join(emotions, ", "))
+	}
+	if len(themes) > 0 {
+		fmt.Fprintf(&b, "- Key themes: %s\n", strings.Join(themes, ", "))
+	}
+	b.WriteString(`
+Write 2 short paragraphs in Bahasa Indonesia, addressing the user as "kamu". Describe how the period went,
+acknowledge the feelings that came up, and end with one gentle, concrete suggestion for the next period.
+Use plain text without headings, lists or markdown.`)
+	return b.String()
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"pijar/model"
+	"time"
+	_ "time/tzdata" // zona waktu user tetap bisa dimuat di image tanpa /usr/share/zoneinfo
+)
+
+// Periode trend yang didukung
+const (
+	TrendPeriodWeekly  = "weekly"
+	TrendPeriodMonthly = "monthly"
+	TrendPeriodCustom  = "custom"
+)
+
+const (
+	// DefaultTrendTimezone dipakai jika request tidak menyebut zona waktu
+	DefaultTrendTimezone = "Asia/Jakarta"
+	trendMaxCustomDays   = 366
+	trendDateLayout      = "2006-01-02"
+)
+
+var (
+	ErrInvalidTrendPeriod = errors.New("invalid trend period")
+	ErrNoAnalysesInPeriod = errors.New("no journal analyses found in this period")
+)
+
+// TrendPeriod adalah jendela waktu trend dalam zona waktu user. Start inklusif, End eksklusif;
+// keduanya jam 00:00 waktu lokal user.
+type TrendPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// LastDay mengembalikan tanggal terakhir yang masih termasuk periode
+func (p TrendPeriod) LastDay() time.Time {
+	return p.End.AddDate(0, 0, -1)
+}
+
+// Contains mengecek apakah t berada di dalam periode
+func (p TrendPeriod) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// ResolveTrendPeriod menghitung periode yang diminta dan periode sebelumnya untuk perbandingan.
+//   - weekly: minggu kalender (Senin–Minggu) yang berisi req.Date, dibanding minggu sebelumnya
+//   - monthly: bulan kalender yang berisi req.Date, dibanding bulan sebelumnya
+//   - custom: req.StartDate sampai req.EndDate (inklusif), atau req.Days hari terakhir termasuk hari ini,
+//     dibanding jumlah hari yang sama tepat sebelumnya
+//
+// req.Date kosong berarti hari ini di zona waktu user.
+func ResolveTrendPeriod(req *model.TrendRequest, now time.Time) (current, previous TrendPeriod, err error) {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = DefaultTrendTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return TrendPeriod{}, TrendPeriod{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidTrendPeriod, timezone)
+	}
+
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	ref := today
+	if req.Date != "" {
+		if ref, err = parseTrendDate(req.Date, "date", loc); err != nil {
+			return TrendPeriod{}, TrendPeriod{}, err
+		}
+	}
+
+	switch req.PeriodType {
+	case TrendPeriodWeekly:
+		// time.Weekday dimulai dari Minggu, minggu kalender di sini dimulai hari Senin
+		start := ref.AddDate(0, 0, -((int(ref.Weekday()) + 6) % 7))
+		current = TrendPeriod{Start: start, End: start.AddDate(0, 0, 7)}
+		previous = TrendPeriod{Start: start.AddDate(0, 0, -7), End: start}
+
+	case TrendPeriodMonthly:
+		start := time.Date(ref.Year(), ref.Month(), 1, 0, 0, 0, 0, loc)
+		current = TrendPeriod{Start: start, End: start.AddDate(0, 1, 0)}
+		previous = TrendPeriod{Start: start.AddDate(0, -1, 0), End: start}
+
+	case TrendPeriodCustom:
+		var start, end time.Time
+		switch {
+		case req.StartDate != "" || req.EndDate != "":
+			if start, err = parseTrendDate(req.StartDate, "start_date", loc); err != nil {
+				return TrendPeriod{}, TrendPeriod{}, err
+			}
+			if end, err = parseTrendDate(req.EndDate, "end_date", loc); err != nil {
+				return TrendPeriod{}, TrendPeriod{}, err
+			}
+			end = end.AddDate(0, 0, 1)
+		case req.Days > 0:
+			end = today.AddDate(0, 0, 1)
+			start = end.AddDate(0, 0, -req.Days)
+		default:
+			return TrendPeriod{}, TrendPeriod{}, fmt.Errorf("%w: custom period needs start_date and end_date, or days", ErrInvalidTrendPeriod)
+		}
+
+		days := calendarDays(start, end)
+		if days <= 0 {
+			return TrendPeriod{}, TrendPeriod{}, fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidTrendPeriod)
+		}
+		if days > trendMaxCustomDays {
+			return TrendPeriod{}, TrendPeriod{}, fmt.Errorf("%w: custom period must not be longer than %d days", ErrInvalidTrendPeriod, trendMaxCustomDays)
+		}
+		current = TrendPeriod{Start: start, End: end}
+		previous = TrendPeriod{Start: start.AddDate(0, 0, -days), End: start}
+
+	default:
+		return TrendPeriod{}, TrendPeriod{}, fmt.Errorf("%w: period_type must be weekly, monthly or custom", ErrInvalidTrendPeriod)
+	}
+
+	return current, previous, nil
+}
+
+func parseTrendDate(value, field string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%w: %s is required", ErrInvalidTrendPeriod, field)
+	}
+	date, err := time.ParseInLocation(trendDateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must use the YYYY-MM-DD format", ErrInvalidTrendPeriod, field)
+	}
+	return date, nil
+}
+
+// calendarDays menghitung jumlah hari kalender di antara dua tanggal lokal. Tidak memakai
+// end.Sub(start) karena satu hari bisa 23 atau 25 jam saat pergantian daylight saving.
+func calendarDays(start, end time.Time) int {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+	return int(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// sentimentRegression mencocokkan garis lurus (least squares) pada skor sentimen terhadap waktu jurnal ditulis.
+// Mengembalikan kemiringan per hari dan perubahan skor menurut garis itu dari entri pertama
+// sampai entri terakhir. Kurang dari dua entri, atau semua entri di waktu yang sama, menghasilkan 0.
+func sentimentRegression(analyses []*model.JournalAnalysis, origin time.Time) (slopePerDay, change float64) {
+	n := float64(len(analyses))
+	if n < 2 {
+		return 0, 0
+	}
+
+	xs := make([]float64, len(analyses))
+	var sumX, sumY float64
+	minX, maxX := 0.0, 0.0
+	for i, a := range analyses {
+		xs[i] = a.JournalCreatedAt.Sub(origin).Hours() / 24
+		sumX += xs[i]
+		sumY += a.SentimentScore
+		if i == 0 || xs[i] < minX {
+			minX = xs[i]
+		}
+		if i == 0 || xs[i] > maxX {
+			maxX = xs[i]
+		}
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, variance float64
+	for i, a := range analyses {
+		dx := xs[i] - meanX
+		covariance += dx * (a.SentimentScore - meanY)
+		variance += dx * dx
+	}
+	if variance == 0 {
+		return 0, 0
+	}
+
+	slopePerDay = covariance / variance
+	return slopePerDay, slopePerDay * (maxX - minX)
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"pijar/model"
+)
+
+func TestResolveTrendPeriod(t *testing.T) {
+	// Rabu 18 Maret 2026 20:00 UTC, sudah Kamis 19 Maret di Jakarta
+	now := time.Date(2026, 3, 18, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		req          model.TrendRequest
+		wantCurrent  [2]string // start inklusif, end eksklusif
+		wantPrevious [2]string
+		wantErr      bool
+	}{
+		{
+			name:         "weekly defaults to this week in Jakarta",
+			req:          model.TrendRequest{PeriodType: TrendPeriodWeekly},
+			wantCurrent:  [2]string{"2026-03-16", "2026-03-23"},
+			wantPrevious: [2]string{"2026-03-09", "2026-03-16"},
+		},
+		{
+			name:         "weekly on a Sunday stays in the week that started Monday",
+			req:          model.TrendRequest{PeriodType: TrendPeriodWeekly, Date: "2026-03-22"},
+			wantCurrent:  [2]string{"2026-03-16", "2026-03-23"},
+			wantPrevious: [2]string{"2026-03-09", "2026-03-16"},
+		},
+		{
+			name:         "monthly compares with the previous month",
+			req:          model.TrendRequest{PeriodType: TrendPeriodMonthly, Date: "2026-03-31"},
+			wantCurrent:  [2]string{"2026-03-01", "2026-04-01"},
+			wantPrevious: [2]string{"2026-02-01", "2026-03-01"},
+		},
+		{
+			name:         "monthly in another timezone",
+			req:          model.TrendRequest{PeriodType: TrendPeriodMonthly, Timezone: "America/Los_Angeles"},
+			wantCurrent:  [2]string{"2026-03-01", "2026-04-01"},
+			wantPrevious: [2]string{"2026-02-01", "2026-03-01"},
+		},
+		{
+			name:         "custom range includes the end date",
+			req:          model.TrendRequest{PeriodType: TrendPeriodCustom, StartDate: "2026-03-01", EndDate: "2026-03-10"},
+			wantCurrent:  [2]string{"2026-03-01", "2026-03-11"},
+			wantPrevious: [2]string{"2026-02-19", "2026-03-01"},
+		},
+		{
+			name:         "custom range across a daylight saving change",
+			req:          model.TrendRequest{PeriodType: TrendPeriodCustom, Timezone: "America/New_York", StartDate: "2026-03-07", EndDate: "2026-03-09"},
+			wantCurrent:  [2]string{"2026-03-07", "2026-03-10"},
+			wantPrevious: [2]string{"2026-03-04", "2026-03-07"},
+		},
+		{
+			name:         "custom days count back from today in Jakarta",
+			req:          model.TrendRequest{PeriodType: TrendPeriodCustom, Days: 7},
+			wantCurrent:  [2]string{"2026-03-13", "2026-03-20"},
+			wantPrevious: [2]string{"2026-03-06", "2026-03-13"},
+		},
+		{
+			name:    "custom without dates or days",
+			req:     model.TrendRequest{PeriodType: TrendPeriodCustom},
+			wantErr: true,
+		},
+		{
+			name:    "custom end before start",
+			req:     model.TrendRequest{PeriodType: TrendPeriodCustom, StartDate: "2026-03-10", EndDate: "2026-03-01"},
+			wantErr: true,
+		},
+		{
+			name:    "custom longer than the limit",
+			req:     model.TrendRequest{PeriodType: TrendPeriodCustom, StartDate: "2025-01-01", EndDate: "2026-03-01"},
+			wantErr: true,
+		},
+		{
+			name:    "bad date format",
+			req:     model.TrendRequest{PeriodType: TrendPeriodWeekly, Date: "19/03/2026"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown timezone",
+			req:     model.TrendRequest{PeriodType: TrendPeriodWeekly, Timezone: "Mars/Olympus"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown period type",
+			req:     model.TrendRequest{PeriodType: "yearly"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, previous, err := ResolveTrendPeriod(&tt.req, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTrendPeriod) {
+					t.Fatalf("ResolveTrendPeriod() error = %v, want ErrInvalidTrendPeriod", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveTrendPeriod() error = %v", err)
+			}
+
+			if got := periodDates(current); got != tt.wantCurrent {
+				t.Errorf("current = %v, want %v", got, tt.wantCurrent)
+			}
+			if got := periodDates(previous); got != tt.wantPrevious {
+				t.Errorf("previous = %v, want %v", got, tt.wantPrevious)
+			}
+
+			// Batas periode harus jam 00:00 di zona waktu user
+			want := tt.req.Timezone
+			if want == "" {
+				want = DefaultTrendTimezone
+			}
+			for _, bound := range []time.Time{current.Start, current.End, previous.Start} {
+				if bound.Location().String() != want || bound.Hour() != 0 || bound.Minute() != 0 {
+					t.Errorf("bound %s is not midnight in %s", bound, want)
+				}
+			}
+		})
+	}
+}
+
+func periodDates(p TrendPeriod) [2]string {
+	return [2]string{p.Start.Format(trendDateLayout), p.End.Format(trendDateLayout)}
+}
+
+func TestSentimentRegression(t *testing.T) {
+	origin := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	// Analisis dibuat ulang sekaligus hari ini, jadi hanya waktu jurnal ditulis yang boleh dipakai
+	analyzedAt := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+	entry := func(day float64, score float64) *model.JournalAnalysis {
+		return &model.JournalAnalysis{
+			SentimentScore:   score,
+			CreatedAt:        analyzedAt,
+			JournalCreatedAt: origin.Add(time.Duration(day * 24 * float64(time.Hour))),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		analyses   []*model.JournalAnalysis
+		wantSlope  float64
+		wantChange float64
+	}{
+		{
+			name:     "no entries",
+			analyses: nil,
+		},
+		{
+			name:     "single entry",
+			analyses: []*model.JournalAnalysis{entry(0, 0.8)},
+		},
+		{
+			name:     "entries written at the same time",
+			analyses: []*model.JournalAnalysis{entry(1, -0.5), entry(1, 0.5)},
+		},
+		{
+			name:       "steady improvement",
+			analyses:   []*model.JournalAnalysis{entry(0, -0.5), entry(1, 0), entry(2, 0.5)},
+			wantSlope:  0.5,
+			wantChange: 1,
+		},
+		{
+			name:       "decline over uneven gaps in any order",
+			analyses:   []*model.JournalAnalysis{entry(6, -0.2), entry(0, 0.4), entry(3, 0.1)},
+			wantSlope:  -0.1,
+			wantChange: -0.6,
+		},
+		{
+			name:       "flat scores",
+			analyses:   []*model.JournalAnalysis{entry(0, 0.3), entry(2, 0.3), entry(5, 0.3)},
+			wantSlope:  0,
+			wantChange: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slope, change := sentimentRegression(tt.analyses, origin)
+			if math.Abs(slope-tt.wantSlope) > 1e-9 || math.Abs(change-tt.wantChange) > 1e-9 {
+				t.Errorf("sentimentRegression() = (%v, %v), want (%v, %v)", slope, change, tt.wantSlope, tt.wantChange)
+			}
+		})
+	}
+}
@@ -11,31 +11,36 @@ import (
 )
 
 type JwtService interface {
-	CreateToken(user model.Users) (string, error)
+	CreateToken(user model.Users, sessionID string) (string, error)
 	VerifyToken(token string) (modelutil.JwtPayloadClaim, error)
+	TokenDuration() time.Duration
 }
 
 type jwtService struct {
-	signingKey     []byte
+	signingKey      []byte
 	applicationName string
 	tokenDuration   time.Duration
 }
 
 func NewJwtService(key, appName string, duration time.Duration) JwtService {
 	return &jwtService{
-		signingKey:     []byte(key),
+		signingKey:      []byte(key),
 		applicationName: appName,
 		tokenDuration:   duration,
 	}
 }
 
-func (j *jwtService) CreateToken(user model.Users) (string, error) {
+// CreateToken membuat access token berumur pendek yang terikat pada satu sesi login
+func (j *jwtService) CreateToken(user model.Users, sessionID string) (string, error) {
+	now := time.Now()
 	claims := modelutil.JwtPayloadClaim{
-		UserId: strconv.Itoa(user.ID),
-		Role:   user.Role,
+		UserId:    strconv.Itoa(user.ID),
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    j.applicationName,
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.tokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.tokenDuration)),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -45,7 +50,7 @@ func (j *jwtService) CreateToken(user model.Users) (string, error) {
 func (j *jwtService) VerifyToken(tokenStr string) (modelutil.JwtPayloadClaim, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &modelutil.JwtPayloadClaim{}, func(token *jwt.Token) (interface{}, error) {
 		return j.signingKey, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return modelutil.JwtPayloadClaim{}, err
 	}
@@ -55,3 +60,8 @@ func (j *jwtService) VerifyToken(tokenStr string) (modelutil.JwtPayloadClaim, er
 	}
 	return modelutil.JwtPayloadClaim{}, fmt.Errorf("invalid token")
 }
+
+// TokenDuration mengembalikan masa berlaku access token
+func (j *jwtService) TokenDuration() time.Duration {
+	return j.tokenDuration
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pijar/model"
+	"strings"
+)
+
+const (
+	defaultGeminiModel = "gemini-2.0-flash"
+	geminiAPIBaseURL   = "https://generativelanguage.googleapis.com/v1beta"
+)
+
+type geminiProvider struct {
+	apiKey    string
+	model     string
+	transport *LLMTransport
+}
+
+// NewGeminiProvider membuat provider Google Gemini
+func NewGeminiProvider(apiKey string, modelName string, transport *LLMTransport) LLMProvider {
+	if modelName == "" {
+		modelName = defaultGeminiModel
+	}
+	return &geminiProvider{
+		apiKey:    apiKey,
+		model:     modelName,
+		transport: transport,
+	}
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	GenerationConfig  map[string]interface{} `json:"generationConfig,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+}
+
+// buildGeminiRequest mengonversi riwayat percakapan ke format Gemini API
+func buildGeminiRequest(messages []model.Message, opts LLMOptions) geminiRequest {
+	req := geminiRequest{}
+	if opts.SystemPrompt != "" {
+		req.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: opts.SystemPrompt}}}
+	}
+
+	for _, msg := range messages {
+		// Gemini memakai role "model" untuk balasan AI
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "model"
+		}
+		req.Contents = append(req.Contents, geminiContent{Role: role, Parts: []geminiPart{{Text: msg.Content}}})
+	}
+
+	// Gemini menolak request tanpa konten
+	if len(req.Contents) == 0 {
+		req.Contents = []geminiContent{{Role: "user", Parts: []geminiPart{{Text: "Hello"}}}}
+	}
+
+	generationConfig := map[string]interface{}{}
+	if opts.Temperature > 0 {
+		generationConfig["temperature"] = opts.Temperature
+	}
+	if opts.MaxTokens > 0 {
+		generationConfig["maxOutputTokens"] = opts.MaxTokens
+	}
+	if opts.ResponseSchema != nil {
+		generationConfig["responseMimeType"] = "application/json"
+		generationConfig["responseSchema"] = geminiSchema(opts.ResponseSchema)
+	}
+	if len(generationConfig) > 0 {
+		req.GenerationConfig = generationConfig
+	}
+
+	return req
+}
+
+// geminiSchema menyalin schema dengan nama tipe huruf besar (OBJECT, ARRAY, ...) sesuai format responseSchema Gemini
+func geminiSchema(schema *JSONSchema) *JSONSchema {
+	if schema == nil {
+		return nil
+	}
+	out := *schema
+	out.Type = strings.ToUpper(schema.Type)
+	out.Items = geminiSchema(schema.Items)
+	if schema.Properties != nil {
+		out.Properties = make(map[string]*JSONSchema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			out.Properties[name] = geminiSchema(property)
+		}
+	}
+	return &out
+}
+
+// geminiHeader mengirim API key lewat header agar tidak ikut tercatat di URL
+func (g *geminiProvider) geminiHeader() http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("x-goog-api-key", g.apiKey)
+	return header
+}
+
+func (g *geminiProvider) Chat(ctx context.Context, messages []model.Message, opts LLMOptions) (string, error) {
+	url := fmt.Sprintf("%s/models/%s:generateContent", geminiAPIBaseURL, g.model)
+
+	body, err := json.Marshal(buildGeminiRequest(messages, opts))
+	if err != nil {
+		return "", fmt.Errorf("gagal mengencode payload: %w", err)
+	}
+
+	respBody, err := g.transport.Post(ctx, url, g.geminiHeader(), body)
+	if err != nil {
+		return "", err
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("gagal mendecode respons: %w", err)
+	}
+
+	if len(result.Candidates) == 0 {
+		return "", fmt.Errorf("tidak ada respons yang diterima")
+	}
+	if len(result.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("tidak ada konten dalam respons")
+	}
+
+	return result.Candidates[0].Content.Parts[0].Text, nil
+}
+
+// ChatStream memakai endpoint streamGenerateContent dengan format SSE
+func (g *geminiProvider) ChatStream(ctx context.Context, messages []model.Message, opts LLMOptions, onChunk func(chunk string) error) (string, error) {
+	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", geminiAPIBaseURL, g.model)
+
+	body, err := json.Marshal(buildGeminiRequest(messages, opts))
+	if err != nil {
+		return "", fmt.Errorf("gagal mengencode payload: %w", err)
+	}
+
+	header := g.geminiHeader()
+	header.Set("Accept", "text/event-stream")
+
+	resp, err := g.transport.PostStream(ctx, url, header, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var event geminiResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return "", fmt.Errorf("gagal mendecode respons: %w", err)
+		}
+		if len(event.Candidates) == 0 {
+			continue
+		}
+
+		for _, part := range event.Candidates[0].Content.Parts {
+			if part.Text == "" {
+				continue
+			}
+			full.WriteString(part.Text)
+			if err := onChunk(part.Text); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("gagal membaca stream: %w", err)
+	}
+
+	if full.Len() == 0 {
+		return "", fmt.Errorf("tidak ada respons yang diterima")
+	}
+
+	return full.String(), nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pijar/model"
+	"strings"
+)
+
+const (
+	defaultOllamaURL   = "http://localhost:11434"
+	defaultOllamaModel = "llama3.1"
+)
+
+// ollamaProvider memanggil model lokal lewat HTTP API Ollama, berguna untuk
+// development tanpa API key
+type ollamaProvider struct {
+	baseURL   string
+	model     string
+	transport *LLMTransport
+}
+
+// NewOllamaProvider membuat provider untuk server Ollama
+func NewOllamaProvider(baseURL string, modelName string, transport *LLMTransport) LLMProvider {
+	if baseURL == "" {
+		baseURL = defaultOllamaURL
+	}
+	if modelName == "" {
+		modelName = defaultOllamaModel
+	}
+	return &ollamaProvider{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     modelName,
+		transport: transport,
+	}
+}
+
+type ollamaChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []model.Message        `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Format   *JSONSchema            `json:"format,omitempty"` // structured output, didukung Ollama 0.5 ke atas
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message model.Message `json:"message"`
+	Error   string        `json:"error"`
+}
+
+func (o *ollamaProvider) Chat(ctx context.Context, messages []model.Message, opts LLMOptions) (string, error) {
+	reqBody := ollamaChatRequest{
+		Model:  o.model,
+		Stream: false,
+		Format: opts.ResponseSchema,
+	}
+	if opts.SystemPrompt != "" {
+		reqBody.Messages = append(reqBody.Messages, model.Message{Role: "system", Content: opts.SystemPrompt})
+	}
+	reqBody.Messages = append(reqBody.Messages, messages...)
+
+	options := map[string]interface{}{}
+	if opts.Temperature > 0 {
+		options["temperature"] = opts.Temperature
+	}
+	if opts.MaxTokens > 0 {
+		options["num_predict"] = opts.MaxTokens
+	}
+	if len(options) > 0 {
+		reqBody.Options = options
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("gagal encode JSON: %w", err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	respBody, err := o.transport.Post(ctx, o.baseURL+"/api/chat", header, jsonBody)
+	if err != nil {
+		return "", err
+	}
+
+	var result ollamaChatResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("gagal decode response JSON: %w", err)
+	}
+
+	if result.Error != "" {
+		return "", fmt.Errorf("error dari Ollama: %s", result.Error)
+	}
+	if result.Message.Content == "" {
+		return "", fmt.Errorf("tidak ada hasil dari Ollama")
+	}
+
+	return result.Message.Content, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pijar/model"
+	"strings"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.deepseek.com/v1"
+	defaultOpenAIModel   = "deepseek-chat"
+)
+
+// openAICompatibleProvider memanggil API chat completions bergaya OpenAI,
+// dipakai untuk DeepSeek maupun layanan lain yang kompatibel
+type openAICompatibleProvider struct {
+	baseURL   string
+	apiKey    string
+	model     string
+	transport *LLMTransport
+}
+
+// NewOpenAICompatibleProvider membuat provider untuk DeepSeek atau API lain yang kompatibel dengan OpenAI
+func NewOpenAICompatibleProvider(baseURL string, apiKey string, modelName string, transport *LLMTransport) LLMProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	if modelName == "" {
+		modelName = defaultOpenAIModel
+	}
+	return &openAICompatibleProvider{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		apiKey:    apiKey,
+		model:     modelName,
+		transport: transport,
+	}
+}
+
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []model.Message       `json:"messages"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAIResponseFormat memakai mode JSON object karena DeepSeek belum mendukung json_schema
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message model.Message `json:"message"`
+	} `json:"choices"`
+}
+
+func (o *openAICompatibleProvider) Chat(ctx context.Context, messages []model.Message, opts LLMOptions) (string, error) {
+	reqBody := openAIChatRequest{
+		Model:     o.model,
+		MaxTokens: opts.MaxTokens,
+	}
+	if opts.Temperature > 0 {
+		reqBody.Temperature = &opts.Temperature
+	}
+	if opts.ResponseSchema != nil {
+		reqBody.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+	if opts.SystemPrompt != "" {
+		reqBody.Messages = append(reqBody.Messages, model.Message{Role: "system", Content: opts.SystemPrompt})
+	}
+	reqBody.Messages = append(reqBody.Messages, messages...)
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("gagal encode JSON: %w", err)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+o.apiKey)
+	header.Set("Content-Type", "application/json")
+
+	respBody, err := o.transport.Post(ctx, o.baseURL+"/chat/completions", header, jsonBody)
+	if err != nil {
+		return "", err
+	}
+
+	var result openAIChatResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("gagal decode response JSON: %w", err)
+	}
+
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("tidak ada hasil dari %s", o.model)
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"pijar/model"
+)
+
+// Nama provider LLM yang bisa dipilih lewat konfigurasi
+const (
+	LLMProviderGemini   = "gemini"
+	LLMProviderDeepseek = "deepseek"
+	LLMProviderOpenAI   = "openai"
+	LLMProviderOllama   = "ollama"
+)
+
+// LLMOptions adalah pengaturan generasi untuk satu fitur (coach, analisis jurnal, artikel).
+// Nilai nol berarti memakai default dari provider.
+type LLMOptions struct {
+	SystemPrompt string
+	Temperature  float64
+	MaxTokens    int
+
+	// ResponseSchema meminta jawaban berupa JSON dengan bentuk ini. Gemini dan Ollama memakainya
+	// sebagai structured output; API OpenAI-compatible hanya diminta menjawab dengan JSON object.
+	// Jawaban tetap harus divalidasi oleh pemanggil.
+	ResponseSchema *JSONSchema
+}
+
+// JSONSchema adalah subset JSON Schema yang dipahami semua provider untuk structured output
+type JSONSchema struct {
+	Type        string                 `json:"type"`
+	Description string                 `json:"description,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+	MinItems    *int                   `json:"minItems,omitempty"`
+	MaxItems    *int                   `json:"maxItems,omitempty"`
+}
+
+// LLMProvider adalah abstraksi model bahasa yang dipakai fitur AI.
+// Messages berisi riwayat percakapan dengan role "user" atau "assistant".
+type LLMProvider interface {
+	Chat(ctx context.Context, messages []model.Message, opts LLMOptions) (string, error)
+}
+
+// AskLLM adalah helper untuk prompt tunggal tanpa riwayat percakapan
+func AskLLM(ctx context.Context, llm LLMProvider, prompt string, opts LLMOptions) (string, error) {
+	return llm.Chat(ctx, []model.Message{{Role: "user", Content: prompt}}, opts)
+}
+
+// LLMProviderConfig berisi kredensial dan endpoint semua provider yang didukung
+type LLMProviderConfig struct {
+	GeminiAPIKey string
+	GeminiModel  string
+
+	OpenAIAPIKey  string // juga dipakai untuk DeepSeek
+	OpenAIBaseURL string
+	OpenAIModel   string
+
+	OllamaURL   string
+	OllamaModel string
+
+	// Transports dipakai bersama oleh provider dengan nama yang sama; nil berarti transport
+	// baru dengan DefaultLLMTransportConfig
+	Transports *LLMTransports
+}
+
+// NewLLMProvider membuat provider berdasarkan namanya
+func NewLLMProvider(name string, cfg LLMProviderConfig) (LLMProvider, error) {
+	transports := cfg.Transports
+	if transports == nil {
+		transports = NewLLMTransports(DefaultLLMTransportConfig())
+	}
+
+	switch name {
+	case LLMProviderGemini:
+		if cfg.GeminiAPIKey == "" {
+			return nil, fmt.Errorf("GEMINI_API environment variable is not set")
+		}
+		return NewGeminiProvider(cfg.GeminiAPIKey, cfg.GeminiModel, transports.For(name)), nil
+	case LLMProviderDeepseek, LLMProviderOpenAI:
+		if cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("AI_API environment variable is not set")
+		}
+		baseURL, modelName := cfg.OpenAIBaseURL, cfg.OpenAIModel
+		if name == LLMProviderOpenAI && baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+			if modelName == "" {
+				modelName = "gpt-4o-mini"
+			}
+		}
+		return NewOpenAICompatibleProvider(baseURL, cfg.OpenAIAPIKey, modelName, transports.For(name)), nil
+	case LLMProviderOllama:
+		return NewOllamaProvider(cfg.OllamaURL, cfg.OllamaModel, transports.For(name)), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", name)
+	}
+}
+
+// LLMStreamer diimplementasikan provider yang bisa mengirim jawaban sebagian demi sebagian.
+// onChunk dipanggil untuk setiap potongan teks; jika onChunk mengembalikan error, streaming
+// dihentikan. Nilai string yang dikembalikan adalah jawaban lengkap.
+type LLMStreamer interface {
+	ChatStream(ctx context.Context, messages []model.Message, opts LLMOptions, onChunk func(chunk string) error) (string, error)
+}
+
+// StreamLLM memakai streaming jika provider mendukungnya. Provider lain dipanggil biasa
+// dan jawabannya dikirim sebagai satu potongan.
+func StreamLLM(ctx context.Context, llm LLMProvider, messages []model.Message, opts LLMOptions, onChunk func(chunk string) error) (string, error) {
+	if streamer, ok := llm.(LLMStreamer); ok {
+		return streamer.ChatStream(ctx, messages, opts, onChunk)
+	}
+
+	resp, err := llm.Chat(ctx, messages, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := onChunk(resp); err != nil {
+		return "", err
+	}
+	return resp, nil
+}
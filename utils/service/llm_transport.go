@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error bertipe untuk panggilan AI. Controller memetakannya ke 429 dan 503.
+var (
+	ErrLLMRateLimited = errors.New("AI service is rate limited, please try again later")
+	ErrLLMUnavailable = errors.New("AI service is temporarily unavailable, please try again later")
+)
+
+// errCircuitOpen adalah penyebab ErrLLMUnavailable saat circuit breaker menolak request
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// LLMError adalah kegagalan panggilan ke provider. errors.Is(err, ErrLLMRateLimited) atau
+// errors.Is(err, ErrLLMUnavailable) bernilai true sesuai jenis kegagalannya.
+type LLMError struct {
+	Provider   string
+	StatusCode int           // 0 jika request tidak mendapat response
+	RetryAfter time.Duration // waktu tunggu yang disarankan, 0 jika tidak diketahui
+	Kind       error         // ErrLLMRateLimited, ErrLLMUnavailable, atau nil untuk error lain (misalnya 400)
+	Err        error
+}
+
+func (e *LLMError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s (status %d): %v", e.Provider, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+}
+
+func (e *LLMError) Unwrap() error {
+	return e.Err
+}
+
+func (e *LLMError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// LLMRetryAfter mengembalikan waktu tunggu yang disarankan dari error AI, 0 jika tidak ada
+func LLMRetryAfter(err error) time.Duration {
+	var llmErr *LLMError
+	if errors.As(err, &llmErr) {
+		return llmErr.RetryAfter
+	}
+	return 0
+}
+
+// LLMTransportConfig mengatur timeout, retry dan circuit breaker panggilan AI
+type LLMTransportConfig struct {
+	Timeout          time.Duration // batas satu percobaan; untuk streaming hanya sampai header response diterima
+	MaxRetries       int           // jumlah percobaan ulang untuk 429, 5xx, timeout dan error jaringan
+	RetryBaseDelay   time.Duration // jeda sebelum percobaan ulang pertama, lalu dilipatgandakan
+	RetryMaxDelay    time.Duration // jeda terpanjang; Retry-After yang lebih lama tidak ditunggu
+	BreakerThreshold int           // kegagalan berturut-turut sebelum circuit dibuka, 0 mematikan breaker
+	BreakerCooldown  time.Duration // lama circuit terbuka sebelum satu request percobaan diizinkan
+}
+
+// DefaultLLMTransportConfig dipakai jika konfigurasi tidak diisi
+func DefaultLLMTransportConfig() LLMTransportConfig {
+	return LLMTransportConfig{
+		Timeout:          60 * time.Second,
+		MaxRetries:       2,
+		RetryBaseDelay:   500 * time.Millisecond,
+		RetryMaxDelay:    8 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// LLMTransport adalah HTTP client bersama untuk semua provider LLM: setiap request mengikuti
+// context pemanggil, dibatasi timeout, diulang dengan exponential backoff jika upstream sedang
+// bermasalah, dan dihentikan sementara oleh circuit breaker jika kegagalan terus berulang.
+type LLMTransport struct {
+	name    string
+	cfg     LLMTransportConfig
+	client  *http.Client
+	clock   llmClock
+	breaker *circuitBreaker
+}
+
+// llmClock adalah sumber waktu transport, diganti dengan jam palsu di test
+type llmClock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func NewLLMTransport(name string, cfg LLMTransportConfig) *LLMTransport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = cfg.Timeout
+
+	return &LLMTransport{
+		name:   name,
+		cfg:    cfg,
+		client: &http.Client{Transport: transport},
+		clock:  realClock{},
+		breaker: &circuitBreaker{
+			name:      name,
+			threshold: cfg.BreakerThreshold,
+			cooldown:  cfg.BreakerCooldown,
+			clock:     realClock{},
+		},
+	}
+}
+
+// Post mengirim request dan mengembalikan body response 200
+func (t *LLMTransport) Post(ctx context.Context, url string, header http.Header, body []byte) ([]byte, error) {
+	_, respBody, err := t.send(ctx, url, header, body, false)
+	return respBody, err
+}
+
+// PostStream mengirim request streaming. Retry hanya dilakukan sebelum response diterima;
+// caller membaca dan menutup body response.
+func (t *LLMTransport) PostStream(ctx context.Context, url string, header http.Header, body []byte) (*http.Response, error) {
+	resp, _, err := t.send(ctx, url, header, body, true)
+	return resp, err
+}
+
+func (t *LLMTransport) send(ctx context.Context, url string, header http.Header, body []byte, stream bool) (*http.Response, []byte, error) {
+	probe, wait, ok := t.breaker.allow()
+	if !ok {
+		return nil, nil, &LLMError{Provider: t.name, RetryAfter: wait, Kind: ErrLLMUnavailable, Err: errCircuitOpen}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, respBody, err := t.attempt(ctx, url, header, body, stream)
+		if err == nil {
+			t.breaker.record(breakerSuccess, probe)
+			return resp, respBody, nil
+		}
+
+		// Request dibatalkan pemanggil (misalnya client terputus), bukan kesalahan upstream
+		if ctx.Err() != nil {
+			t.breaker.record(breakerIgnore, probe)
+			return nil, nil, fmt.Errorf("%s: %w", t.name, ctx.Err())
+		}
+
+		var llmErr *LLMError
+		if !errors.As(err, &llmErr) || llmErr.Kind == nil {
+			// Upstream menjawab tetapi menolak request (4xx), mengulang tidak akan membantu
+			t.breaker.record(breakerSuccess, probe)
+			return nil, nil, err
+		}
+
+		delay := t.backoff(attempt)
+		if llmErr.RetryAfter > delay {
+			delay = llmErr.RetryAfter
+		}
+		if attempt >= t.cfg.MaxRetries || delay > t.cfg.RetryMaxDelay {
+			if llmErr.Kind == ErrLLMUnavailable {
+				t.breaker.record(breakerFailure, probe)
+			} else {
+				t.breaker.record(breakerIgnore, probe)
+			}
+			return nil, nil, err
+		}
+
+		log.Printf("AI request to %s failed (attempt %d), retrying in %s: %v", t.name, attempt+1, delay, err)
+		select {
+		case <-ctx.Done():
+			t.breaker.record(breakerIgnore, probe)
+			return nil, nil, fmt.Errorf("%s: %w", t.name, ctx.Err())
+		case <-t.clock.After(delay):
+		}
+	}
+}
+
+// attempt menjalankan satu percobaan request
+func (t *LLMTransport) attempt(ctx context.Context, url string, header http.Header, body []byte, stream bool) (*http.Response, []byte, error) {
+	// Untuk streaming, timeout dibatasi lewat ResponseHeaderTimeout karena jawaban boleh mengalir lebih lama
+	attemptCtx, cancel := ctx, func() {}
+	if !stream && t.cfg.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		attemptCtx, cancelTimeout = context.WithTimeout(ctx, t.cfg.Timeout)
+		cancel = cancelTimeout
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("gagal membuat request: %w", err)
+	}
+	req.Header = header.Clone()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, &LLMError{Provider: t.name, Kind: ErrLLMUnavailable, Err: requestError(err)}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		cancel()
+		return nil, nil, t.statusError(resp, respBody)
+	}
+
+	if stream {
+		return resp, nil, nil
+	}
+
+	defer cancel()
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, &LLMError{Provider: t.name, Kind: ErrLLMUnavailable, Err: fmt.Errorf("gagal membaca response: %w", requestError(err))}
+	}
+	return nil, respBody, nil
+}
+
+// statusError mengubah status non-200 menjadi LLMError; 429, 408 dan 5xx bisa dicoba ulang
+func (t *LLMTransport) statusError(resp *http.Response, body []byte) error {
+	llmErr := &LLMError{
+		Provider:   t.name,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), t.clock.Now()),
+		Err:        fmt.Errorf("error dari API: %s", strings.TrimSpace(string(body))),
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		llmErr.Kind = ErrLLMRateLimited
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode >= 500:
+		llmErr.Kind = ErrLLMUnavailable
+	}
+	return llmErr
+}
+
+// backoff menghitung jeda exponential dengan jitter agar request yang gagal bersamaan tidak
+// mencoba ulang pada saat yang sama
+func (t *LLMTransport) backoff(attempt int) time.Duration {
+	delay := t.cfg.RetryBaseDelay << attempt
+	if delay <= 0 || delay > t.cfg.RetryMaxDelay {
+		delay = t.cfg.RetryMaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// requestError membuang URL dari error net/http, karena URL bisa berisi API key
+func requestError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// parseRetryAfter membaca header Retry-After dalam detik atau format tanggal HTTP
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// LLMTransports membagikan satu transport per provider, sehingga semua fitur yang memakai
+// provider yang sama juga berbagi circuit breaker
+type LLMTransports struct {
+	cfg        LLMTransportConfig
+	mu         sync.Mutex
+	transports map[string]*LLMTransport
+}
+
+func NewLLMTransports(cfg LLMTransportConfig) *LLMTransports {
+	return &LLMTransports{
+		cfg:        cfg,
+		transports: make(map[string]*LLMTransport),
+	}
+}
+
+// For mengembalikan transport untuk provider, dibuat saat pertama kali diminta
+func (p *LLMTransports) For(name string) *LLMTransport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	transport, ok := p.transports[name]
+	if !ok {
+		transport = NewLLMTransport(name, p.cfg)
+		p.transports[name] = transport
+	}
+	return transport
+}
+
+type breakerOutcome int
+
+const (
+	breakerSuccess breakerOutcome = iota
+	breakerFailure
+	breakerIgnore // request dibatalkan atau kena rate limit, tidak dihitung
+)
+
+// circuitBreaker membuka circuit setelah threshold kegagalan berturut-turut. Selama terbuka,
+// request langsung ditolak; setelah cooldown satu request percobaan diizinkan, dan circuit
+// tertutup lagi jika percobaan itu berhasil.
+type circuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	clock     llmClock
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow mengecek apakah request boleh dikirim; jika tidak, mengembalikan sisa waktu circuit
+// terbuka. probe bernilai true jika request ini adalah request percobaan setelah cooldown.
+func (b *circuitBreaker) allow() (probe bool, wait time.Duration, ok bool) {
+	if b.threshold <= 0 {
+		return false, 0, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return false, 0, true
+	}
+	if wait := b.openUntil.Sub(b.clock.Now()); wait > 0 {
+		return false, wait, false
+	}
+	if b.probing {
+		return false, b.cooldown, false
+	}
+	b.probing = true
+	return true, 0, true
+}
+
+// record mencatat hasil request. Hanya hasil request percobaan yang melepas status probing,
+// agar request lain yang selesai saat half-open tidak meloloskan percobaan tambahan.
+func (b *circuitBreaker) record(outcome breakerOutcome, probe bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	switch outcome {
+	case breakerSuccess:
+		if b.failures >= b.threshold {
+			log.Printf("AI circuit for %s closed", b.name)
+		}
+		b.failures = 0
+	case breakerFailure:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openUntil = b.clock.Now().Add(b.cooldown)
+			log.Printf("AI circuit for %s opened for %s after %d consecutive failures", b.name, b.cooldown, b.failures)
+		}
+	}
+}
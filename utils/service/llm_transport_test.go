@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock tidak pernah benar-benar tidur: setiap After mencatat jeda, memajukan waktu,
+// lalu langsung berbunyi kecuali onAfter mengembalikan channel lain
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waits   []time.Duration
+	onAfter func(d time.Duration) <-chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	onAfter := c.onAfter
+	c.mu.Unlock()
+
+	if onAfter != nil {
+		return onAfter(d)
+	}
+	c.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func newTestTransport(cfg LLMTransportConfig, clock *fakeClock) *LLMTransport {
+	transport := NewLLMTransport("test", cfg)
+	transport.clock = clock
+	transport.breaker.clock = clock
+	return transport
+}
+
+// statusServer menjawab dengan status berurutan dari statuses, lalu 200 untuk request berikutnya
+func statusServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n <= len(statuses) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[n-1])
+			w.Write([]byte(`{"error":"failed"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestLLMTransportRetry(t *testing.T) {
+	cfg := LLMTransportConfig{
+		Timeout:        time.Second,
+		MaxRetries:     2,
+		RetryBaseDelay: 100 * time.Millisecond,
+		RetryMaxDelay:  5 * time.Second,
+	}
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		statuses  []int
+		wantErr   error
+		wantCalls int32
+		wantWaits []time.Duration // nil berarti jeda dicek terhadap rentang backoff
+	}{
+		{
+			name:      "retries 5xx until success",
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after max retries",
+			statuses:  []int{500, 500, 500},
+			wantErr:   ErrLLMUnavailable,
+			wantCalls: 3,
+		},
+		{
+			name:      "retries 429",
+			statuses:  []int{http.StatusTooManyRequests},
+			wantCalls: 2,
+		},
+		{
+			name:      "waits for Retry-After longer than backoff",
+			header:    http.Header{"Retry-After": {"2"}},
+			statuses:  []int{http.StatusTooManyRequests},
+			wantCalls: 2,
+			wantWaits: []time.Duration{2 * time.Second},
+		},
+		{
+			name:      "does not wait for Retry-After beyond the max delay",
+			header:    http.Header{"Retry-After": {"60"}},
+			statuses:  []int{http.StatusTooManyRequests},
+			wantErr:   ErrLLMRateLimited,
+			wantCalls: 1,
+			wantWaits: []time.Duration{},
+		},
+		{
+			name:      "does not retry 4xx",
+			statuses:  []int{http.StatusBadRequest},
+			wantCalls: 1,
+			wantWaits: []time.Duration{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := statusServer(t, tt.header, tt.statuses...)
+			clock := newFakeClock()
+			transport := newTestTransport(cfg, clock)
+
+			body, err := transport.Post(context.Background(), server.URL, nil, []byte(`{}`))
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Post() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.statuses[len(tt.statuses)-1] == http.StatusBadRequest:
+				if err == nil || errors.Is(err, ErrLLMUnavailable) || errors.Is(err, ErrLLMRateLimited) {
+					t.Fatalf("Post() error = %v, want a non-retryable error", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Post() error = %v", err)
+				}
+				if string(body) != `{"ok":true}` {
+					t.Errorf("Post() body = %s", body)
+				}
+			}
+
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			if tt.wantWaits != nil {
+				if len(clock.waits) != len(tt.wantWaits) {
+					t.Fatalf("waits = %v, want %v", clock.waits, tt.wantWaits)
+				}
+				for i := range tt.wantWaits {
+					if clock.waits[i] != tt.wantWaits[i] {
+						t.Errorf("waits = %v, want %v", clock.waits, tt.wantWaits)
+					}
+				}
+				return
+			}
+			if len(clock.waits) != int(tt.wantCalls)-1 {
+				t.Fatalf("waits = %v, want %d", clock.waits, tt.wantCalls-1)
+			}
+			for attempt, wait := range clock.waits {
+				full := cfg.RetryBaseDelay << attempt
+				if wait < full/2 || wait > full {
+					t.Errorf("wait %d = %s, want between %s and %s", attempt, wait, full/2, full)
+				}
+			}
+		})
+	}
+}
+
+func TestLLMTransportBackoff(t *testing.T) {
+	transport := NewLLMTransport("test", LLMTransportConfig{
+		RetryBaseDelay: 500 * time.Millisecond,
+		RetryMaxDelay:  3 * time.Second,
+	})
+
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{attempt: 0, full: 500 * time.Millisecond},
+		{attempt: 1, full: time.Second},
+		{attempt: 2, full: 2 * time.Second},
+		{attempt: 3, full: 3 * time.Second},
+		{attempt: 40, full: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if got := transport.backoff(tt.attempt); got < tt.full/2 || got > tt.full {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempt, got, tt.full/2, tt.full)
+			}
+		}
+	}
+}
+
+func TestLLMTransportContextCancelledDuringBackoff(t *testing.T) {
+	server, calls := statusServer(t, nil, 503, 503, 503)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newFakeClock()
+	clock.onAfter = func(time.Duration) <-chan time.Time {
+		cancel()
+		return nil
+	}
+	transport := newTestTransport(LLMTransportConfig{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		RetryBaseDelay:   100 * time.Millisecond,
+		RetryMaxDelay:    time.Second,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	}, clock)
+
+	_, err := transport.Post(ctx, server.URL, nil, []byte(`{}`))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Post() error = %v, want context.Canceled", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+	// Pembatalan oleh pemanggil tidak boleh dihitung sebagai kegagalan upstream
+	if _, _, ok := transport.breaker.allow(); !ok {
+		t.Error("breaker opened after a cancelled request")
+	}
+}
+
+func TestLLMTransportCircuitBreaker(t *testing.T) {
+	server, calls := statusServer(t, nil, 503, 503, 503)
+	clock := newFakeClock()
+	transport := newTestTransport(LLMTransportConfig{
+		Timeout:          time.Second,
+		BreakerThreshold: 2,
+		BreakerCooldown:  30 * time.Second,
+	}, clock)
+	post := func() error {
+		_, err := transport.Post(context.Background(), server.URL, nil, []byte(`{}`))
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := post(); !errors.Is(err, ErrLLMUnavailable) {
+			t.Fatalf("request %d error = %v, want ErrLLMUnavailable", i+1, err)
+		}
+	}
+
+	// Circuit terbuka: request ditolak tanpa menghubungi upstream
+	err := post()
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("open circuit error = %v, want errCircuitOpen", err)
+	}
+	if llmErr.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %s, want 30s", llmErr.RetryAfter)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("calls while open = %d, want 2", got)
+	}
+
+	// Setelah cooldown percobaan yang gagal membuka circuit lagi
+	clock.Advance(30 * time.Second)
+	if err := post(); !errors.Is(err, ErrLLMUnavailable) || errors.Is(err, errCircuitOpen) {
+		t.Fatalf("failed probe error = %v, want upstream ErrLLMUnavailable", err)
+	}
+	if err := post(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("after failed probe error = %v, want errCircuitOpen", err)
+	}
+
+	// Percobaan yang berhasil menutup circuit
+	clock.Advance(30 * time.Second)
+	for i := 0; i < 3; i++ {
+		if err := post(); err != nil {
+			t.Fatalf("request %d after recovery error = %v", i+1, err)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 6 {
+		t.Errorf("calls = %d, want 6", got)
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	clock := newFakeClock()
+	breaker := &circuitBreaker{name: "test", threshold: 1, cooldown: time.Minute, clock: clock}
+
+	// Request yang sudah berjalan sebelum circuit terbuka
+	inFlight, _, ok := breaker.allow()
+	if !ok || inFlight {
+		t.Fatalf("allow() = probe %v, ok %v; want a regular request", inFlight, ok)
+	}
+
+	breaker.record(breakerFailure, false)
+	if _, wait, ok := breaker.allow(); ok || wait != time.Minute {
+		t.Fatalf("allow() while open = wait %s, ok %v; want rejected for 1m", wait, ok)
+	}
+
+	clock.Advance(time.Minute)
+	probe, _, ok := breaker.allow()
+	if !ok || !probe {
+		t.Fatalf("allow() after cooldown = probe %v, ok %v; want the probe", probe, ok)
+	}
+	if _, _, ok := breaker.allow(); ok {
+		t.Fatal("second request allowed while the probe is running")
+	}
+
+	// Hasil request lain saat half-open tidak boleh meloloskan percobaan kedua
+	breaker.record(breakerIgnore, inFlight)
+	if _, _, ok := breaker.allow(); ok {
+		t.Fatal("request allowed after a non-probe result while half-open")
+	}
+
+	breaker.record(breakerIgnore, probe)
+	if probe, _, ok := breaker.allow(); !ok || !probe {
+		t.Fatalf("allow() after cancelled probe = probe %v, ok %v; want a new probe", probe, ok)
+	}
+	breaker.record(breakerSuccess, true)
+	if probe, _, ok := breaker.allow(); !ok || probe {
+		t.Fatalf("allow() after successful probe = probe %v, ok %v; want closed circuit", probe, ok)
+	}
+}
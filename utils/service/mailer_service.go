@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// EmailMessage adalah email yang siap dikirim, berisi versi teks dan HTML
+type EmailMessage struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Mailer adalah interface untuk pengiriman email (SMTP, file outbox, dll)
+type Mailer interface {
+	Send(msg EmailMessage) error
+}
+
+// smtpMailer mengirim email melalui server SMTP
+type smtpMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer membuat Mailer berbasis SMTP. Jika username kosong, email dikirim tanpa AUTH
+// (berguna untuk fake SMTP server lokal seperti MailHog).
+func NewSMTPMailer(host, port, username, password, from string) Mailer {
+	return &smtpMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *smtpMailer) Send(msg EmailMessage) error {
+	if m.host == "" {
+		return errors.New("SMTP_HOST tidak ditemukan")
+	}
+
+	raw, err := buildMIMEMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	addr := fmt.Sprintf("%s:%s", m.host, m.port)
+	if err := smtp.SendMail(addr, auth, m.from, []string{msg.To}, raw); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// fileMailer menyimpan email sebagai file .eml di direktori outbox, untuk development lokal
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer membuat Mailer yang menulis setiap email ke direktori outbox
+func NewFileMailer(dir, from string) Mailer {
+	return &fileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func (m *fileMailer) Send(msg EmailMessage) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	raw, err := buildMIMEMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s_%s.eml",
+		time.Now().Format("20060102_150405.000000000"),
+		unsafeFilenameChars.ReplaceAllString(msg.To, "_"))
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), raw, 0o600); err != nil {
+		return fmt.Errorf("failed to write email to outbox: %w", err)
+	}
+	return nil
+}
+
+// buildMIMEMessage menyusun email multipart/alternative (text + HTML)
+func buildMIMEMessage(from string, msg EmailMessage) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	}
+
+	for _, p := range parts {
+		if p.content == "" {
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType)
+		header.Set("Content-Transfer-Encoding", "8bit")
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		if _, err := w.Write([]byte(p.content)); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+
+	var raw bytes.Buffer
+	fmt.Fprintf(&raw, "From: %s\r\n", from)
+	fmt.Fprintf(&raw, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&raw, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&raw, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&raw, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&raw, "Content-Type: multipart/alternative; boundary=%q\r\n", writer.Boundary())
+	fmt.Fprintf(&raw, "\r\n")
+	raw.Write(body.Bytes())
+
+	return raw.Bytes(), nil
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"pijar/model"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeMidtrans adalah server Midtrans palsu yang berjalan di dalam proses. Server ini
+// meniru endpoint Snap dan Core API yang dipakai midtransService, sehingga alur pembayaran
+// bisa dicoba end-to-end tanpa akses jaringan. Perubahan status dilakukan lewat SetStatus
+// (atau POST /fake/{orderID}/{status}) dan dikirim sebagai notifikasi bertanda tangan
+// ke NotificationURL, persis seperti notifikasi Midtrans asli.
+type FakeMidtrans struct {
+	ServerKey       string
+	NotificationURL string
+
+	mu       sync.Mutex
+	orders   map[string]*fakeMidtransOrder
+	listener net.Listener
+	server   *http.Server
+	client   *http.Client
+}
+
+type fakeMidtransOrder struct {
+	OrderID           string
+	TransactionID     string
+	GrossAmount       int
+	TransactionStatus string
+	Token             string
+	RefundedAmount    int
+	RefundKeys        map[string]int
+	Refunds           []model.MidtransRefundNotification
+	CreatedAt         time.Time
+}
+
+func NewFakeMidtrans(serverKey string, notificationURL string) *FakeMidtrans {
+	return &FakeMidtrans{
+		ServerKey:       serverKey,
+		NotificationURL: notificationURL,
+		orders:          make(map[string]*fakeMidtransOrder),
+		client:          &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Start menjalankan server pada addr, gunakan "127.0.0.1:0" untuk port acak
+func (f *FakeMidtrans) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start fake midtrans: %w", err)
+	}
+	f.listener = listener
+	f.server = &http.Server{Handler: f.Handler()}
+
+	go func() {
+		if err := f.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Fake midtrans stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Fake midtrans running on %s", f.URL())
+	return nil
+}
+
+// URL mengembalikan base URL server palsu
+func (f *FakeMidtrans) URL() string {
+	if f.listener == nil {
+		return ""
+	}
+	return "http://" + f.listener.Addr().String()
+}
+
+// Endpoint mengembalikan MidtransEndpoint yang mengarah ke server palsu
+func (f *FakeMidtrans) Endpoint() MidtransEndpoint {
+	return MidtransEndpoint{SnapBaseURL: f.URL(), APIBaseURL: f.URL()}
+}
+
+func (f *FakeMidtrans) Close(ctx context.Context) error {
+	if f.server == nil {
+		return nil
+	}
+	return f.server.Shutdown(ctx)
+}
+
+// Handler mengembalikan http.Handler server palsu, bisa juga dipasang di httptest.Server
+func (f *FakeMidtrans) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /snap/v1/transactions", f.handleCreateTransaction)
+	mux.HandleFunc("GET /v2/{orderID}/status", f.handleStatus)
+	mux.HandleFunc("POST /v2/{orderID}/cancel", f.handleCancel)
+	mux.HandleFunc("POST /v2/{orderID}/refund", f.handleRefund)
+	mux.HandleFunc("GET /snap/v2/vtweb/{token}", f.handleRedirect)
+	mux.HandleFunc("POST /fake/{orderID}/{status}", f.handleSetStatus)
+	return mux
+}
+
+// SetStatus mengubah status order lalu mengirim notifikasi ke NotificationURL
+func (f *FakeMidtrans) SetStatus(orderID string, transactionStatus string) error {
+	f.mu.Lock()
+	order, ok := f.orders[orderID]
+	if !ok {
+		f.mu.Unlock()
+		return errors.New("transaction doesn't exist")
+	}
+	order.TransactionStatus = transactionStatus
+	notification := f.notification(order)
+	f.mu.Unlock()
+
+	return f.notify(notification)
+}
+
+// notify mengirim notifikasi ke NotificationURL
+func (f *FakeMidtrans) notify(notification model.MidtransCallbackRequest) error {
+	if f.NotificationURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	resp, err := f.client.Post(f.NotificationURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (f *FakeMidtrans) authorized(r *http.Request) bool {
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte(f.ServerKey+":"))
+	return r.Header.Get("Authorization") == expected
+}
+
+func (f *FakeMidtrans) handleCreateTransaction(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		writeFakeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"error_messages": []string{"Access denied due to unauthorized transaction, please check client or server key"},
+		})
+		return
+	}
+
+	var req model.MidtransSnapReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error_messages": []string{"invalid request body"},
+		})
+		return
+	}
+
+	itemTotal := 0
+	for _, item := range req.ItemDetails {
+		itemTotal += item.Price * item.Quantity
+	}
+	if len(req.ItemDetails) > 0 && itemTotal != req.TransactionDetails.GrossAmt {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error_messages": []string{"transaction_details.gross_amount is not equal to the sum of item_details"},
+		})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.orders[req.TransactionDetails.OrderID]; exists {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error_messages": []string{"transaction_details.order_id has already been taken"},
+		})
+		return
+	}
+
+	order := &fakeMidtransOrder{
+		OrderID:           req.TransactionDetails.OrderID,
+		TransactionID:     uuid.NewString(),
+		GrossAmount:       req.TransactionDetails.GrossAmt,
+		TransactionStatus: "pending",
+		Token:             uuid.NewString(),
+		RefundKeys:        make(map[string]int),
+		CreatedAt:         time.Now(),
+	}
+	f.orders[order.OrderID] = order
+
+	writeFakeJSON(w, http.StatusCreated, model.MidtransSnapResp{
+		Token:       order.Token,
+		RedirectUrl: fmt.Sprintf("%s/snap/v2/vtweb/%s", f.URL(), order.Token),
+	})
+}
+
+func (f *FakeMidtrans) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		writeFakeJSON(w, http.StatusUnauthorized, map[string]string{"status_code": "401", "status_message": "Unauthorized"})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[r.PathValue("orderID")]
+	if !ok {
+		writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "404", "status_message": "Transaction doesn't exist."})
+		return
+	}
+
+	writeFakeJSON(w, http.StatusOK, f.notification(order))
+}
+
+func (f *FakeMidtrans) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		writeFakeJSON(w, http.StatusUnauthorized, map[string]string{"status_code": "401", "status_message": "Unauthorized"})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[r.PathValue("orderID")]
+	if !ok {
+		writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "404", "status_message": "Transaction doesn't exist."})
+		return
+	}
+	if order.TransactionStatus != "pending" {
+		writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "412", "status_message": "Merchant cannot modify the status of the transaction"})
+		return
+	}
+
+	order.TransactionStatus = "cancel"
+	writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "200", "status_message": "Success, transaction is canceled"})
+}
+
+func (f *FakeMidtrans) handleRefund(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		writeFakeJSON(w, http.StatusUnauthorized, map[string]string{"status_code": "401", "status_message": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		RefundKey string `json:"refund_key"`
+		Amount    int    `json:"amount"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "400", "status_message": "invalid request body"})
+		return
+	}
+
+	f.mu.Lock()
+	order, ok := f.orders[r.PathValue("orderID")]
+	if !ok {
+		f.mu.Unlock()
+		writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "404", "status_message": "Transaction doesn't exist."})
+		return
+	}
+
+	// refund_key yang sama tidak diproses dua kali
+	if amount, done := order.RefundKeys[req.RefundKey]; done {
+		f.mu.Unlock()
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+			"status_code":    "200",
+			"status_message": "Success, refund request is approved",
+			"refund_key":     req.RefundKey,
+			"refund_amount":  fmt.Sprintf("%d.00", amount),
+		})
+		return
+	}
+
+	switch order.TransactionStatus {
+	case "settlement", "capture", "partial_refund":
+	default:
+		f.mu.Unlock()
+		writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "412", "status_message": "Merchant cannot modify the status of the transaction"})
+		return
+	}
+	if req.Amount <= 0 || order.RefundedAmount+req.Amount > order.GrossAmount {
+		f.mu.Unlock()
+		writeFakeJSON(w, http.StatusOK, map[string]string{"status_code": "413", "status_message": "The request could not be processed due to malformed syntax in the request body"})
+		return
+	}
+
+	order.RefundedAmount += req.Amount
+	order.RefundKeys[req.RefundKey] = req.Amount
+	order.Refunds = append(order.Refunds, model.MidtransRefundNotification{
+		RefundKey:    req.RefundKey,
+		RefundAmount: fmt.Sprintf("%d.00", req.Amount),
+		CreatedAt:    time.Now().Format("2006-01-02 15:04:05"),
+	})
+	if order.RefundedAmount == order.GrossAmount {
+		order.TransactionStatus = "refund"
+	} else {
+		order.TransactionStatus = "partial_refund"
+	}
+	notification := f.notification(order)
+	f.mu.Unlock()
+
+	writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+		"status_code":        "200",
+		"status_message":     "Success, refund request is approved",
+		"refund_key":         req.RefundKey,
+		"refund_amount":      fmt.Sprintf("%d.00", req.Amount),
+		"transaction_status": order.TransactionStatus,
+	})
+
+	// Seperti Midtrans, notifikasi refund dikirim terpisah dari response API
+	go func() {
+		if err := f.notify(notification); err != nil {
+			log.Printf("Fake midtrans refund notification failed: %v", err)
+		}
+	}()
+}
+
+// handleRedirect menggantikan halaman pembayaran Snap dengan keterangan singkat
+func (f *FakeMidtrans) handleRedirect(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "Fake Midtrans payment page for token %s.\nPOST /fake/{order_id}/settlement to complete the payment.\n", r.PathValue("token"))
+}
+
+func (f *FakeMidtrans) handleSetStatus(w http.ResponseWriter, r *http.Request) {
+	if err := f.SetStatus(r.PathValue("orderID"), r.PathValue("status")); err != nil {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]string{"status_message": err.Error()})
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, map[string]string{"status_message": "OK"})
+}
+
+// notification membuat payload notifikasi bertanda tangan, harus dipanggil dengan f.mu terkunci
+func (f *FakeMidtrans) notification(order *fakeMidtransOrder) model.MidtransCallbackRequest {
+	statusCode := fakeMidtransStatusCode(order.TransactionStatus)
+	grossAmount := fmt.Sprintf("%d.00", order.GrossAmount)
+
+	fraudStatus := ""
+	if order.TransactionStatus == "capture" || order.TransactionStatus == "settlement" {
+		fraudStatus = "accept"
+	}
+
+	notification := model.MidtransCallbackRequest{
+		TransactionTime:   order.CreatedAt.Format("2006-01-02 15:04:05"),
+		TransactionStatus: order.TransactionStatus,
+		TransactionID:     order.TransactionID,
+		StatusMessage:     "midtrans payment notification",
+		StatusCode:        statusCode,
+		SignatureKey:      MidtransSignature(order.OrderID, statusCode, grossAmount, f.ServerKey),
+		PaymentType:       "bank_transfer",
+		OrderID:           order.OrderID,
+		MerchantID:        "FAKE-MERCHANT",
+		GrossAmount:       grossAmount,
+		FraudStatus:       fraudStatus,
+		Currency:          "IDR",
+	}
+	if len(order.Refunds) > 0 {
+		notification.RefundAmount = fmt.Sprintf("%d.00", order.RefundedAmount)
+		notification.Refunds = append([]model.MidtransRefundNotification(nil), order.Refunds...)
+	}
+	return notification
+}
+
+func fakeMidtransStatusCode(transactionStatus string) string {
+	switch strings.ToLower(transactionStatus) {
+	case "pending":
+		return "201"
+	case "deny":
+		return "202"
+	case "expire":
+		return "407"
+	default:
+		return "200"
+	}
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Fake midtrans failed to write response: %v", err)
+	}
+}
@@ -1,12 +1,14 @@
 package service
 
 import (
+	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"os"
 	"pijar/model"
 	"strings"
 	"time"
@@ -14,22 +16,71 @@ import (
 	"github.com/go-resty/resty/v2"
 )
 
+// ErrInvalidSignature dikembalikan jika signature_key callback tidak cocok
+var ErrInvalidSignature = errors.New("invalid midtrans signature key")
+
+// ErrMidtransTransactionNotFound dikembalikan jika order belum pernah dibuat di Midtrans,
+// misalnya karena user tidak pernah membuka halaman pembayaran
+var ErrMidtransTransactionNotFound = errors.New("transaction not found in midtrans")
+
+// MidtransTransactionStatus adalah status transaksi hasil pengecekan ke Midtrans.
+// FraudStatus perlu ikut dibaca karena capture yang masih "challenge" belum lunas.
+type MidtransTransactionStatus struct {
+	TransactionStatus string
+	FraudStatus       string
+}
+
 // MidtransServiceInterface adalah interface untuk layanan Midtrans
 type MidtransServiceInterface interface {
 	Pay(req model.MidtransSnapReq) (model.MidtransSnapResp, error)
 	VerifyCallback(callback model.MidtransCallbackRequest) error
 	GenerateOrderID() string
-	CheckTransactionStatus(orderID string) (string, error)
+	CheckTransactionStatus(orderID string) (MidtransTransactionStatus, error)
 	CancelTransaction(orderID string) error
+	RefundTransaction(orderID string, refundKey string, amount int, reason string) error
+}
+
+// MidtransEndpoint berisi base URL Snap dan Core API Midtrans
+type MidtransEndpoint struct {
+	SnapBaseURL string
+	APIBaseURL  string
+}
+
+var (
+	MidtransSandbox = MidtransEndpoint{
+		SnapBaseURL: "https://app.sandbox.midtrans.com",
+		APIBaseURL:  "https://api.sandbox.midtrans.com",
+	}
+	MidtransProduction = MidtransEndpoint{
+		SnapBaseURL: "https://app.midtrans.com",
+		APIBaseURL:  "https://api.midtrans.com",
+	}
+)
+
+// MidtransEndpointFor mengembalikan endpoint untuk environment "sandbox" atau "production"
+func MidtransEndpointFor(env string) (MidtransEndpoint, error) {
+	switch env {
+	case "sandbox":
+		return MidtransSandbox, nil
+	case "production":
+		return MidtransProduction, nil
+	default:
+		return MidtransEndpoint{}, fmt.Errorf("unknown midtrans environment: %s", env)
+	}
 }
 
 // midtransService adalah implementasi dari MidtransServiceInterface
 type midtransService struct {
 	client    *resty.Client
-	url       string
+	endpoint  MidtransEndpoint
 	serverKey string
 }
 
+// authHeader membuat header Basic Auth, Midtrans memakai server key sebagai username dengan password kosong
+func (m *midtransService) authHeader() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(m.serverKey+":"))
+}
+
 // Pay membuat transaksi pembayaran baru di Midtrans
 func (m *midtransService) Pay(payload model.MidtransSnapReq) (model.MidtransSnapResp, error) {
 	// Validasi server key
@@ -37,26 +88,23 @@ func (m *midtransService) Pay(payload model.MidtransSnapReq) (model.MidtransSnap
 		return model.MidtransSnapResp{}, errors.New("SERVER_KEY tidak ditemukan")
 	}
 
-	// Encode server key untuk Basic Auth
-	encodedKey := base64.StdEncoding.EncodeToString([]byte(m.serverKey))
-
 	// Kirim request ke Midtrans
 	resp, err := m.client.R().
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Accept", "application/json").
-		SetHeader("Authorization", "Basic "+encodedKey).
+		SetHeader("Authorization", m.authHeader()).
 		SetBody(payload).
-		Post(m.url)
-
-	// Log response untuk debugging
-	log.Printf("Midtrans response status: %s", resp.Status())
-	log.Printf("Midtrans response body: %s", string(resp.Body()))
+		Post(m.endpoint.SnapBaseURL + "/snap/v1/transactions")
 
 	if err != nil {
 		log.Printf("Error sending request to Midtrans: %v", err)
 		return model.MidtransSnapResp{}, fmt.Errorf("error sending request to Midtrans: %w", err)
 	}
 
+	// Log response untuk debugging
+	log.Printf("Midtrans response status: %s", resp.Status())
+	log.Printf("Midtrans response body: %s", string(resp.Body()))
+
 	// Parse response
 	var snapResp model.MidtransSnapResp
 	err = json.Unmarshal(resp.Body(), &snapResp)
@@ -72,63 +120,41 @@ func (m *midtransService) Pay(payload model.MidtransSnapReq) (model.MidtransSnap
 		return model.MidtransSnapResp{}, errors.New(errorMsg)
 	}
 
-	// Generate redirect URL
-	redirectURL := fmt.Sprintf("https://app.sandbox.midtrans.com/snap/v2/vtweb/%s", snapResp.Token)
-	snapResp.RedirectUrl = redirectURL
+	// Generate redirect URL jika Midtrans tidak mengirimkannya
+	if snapResp.RedirectUrl == "" {
+		snapResp.RedirectUrl = fmt.Sprintf("%s/snap/v2/vtweb/%s", m.endpoint.SnapBaseURL, snapResp.Token)
+	}
 
 	return snapResp, nil
 }
 
-// VerifyCallback memverifikasi callback dari Midtrans
+// VerifyCallback memverifikasi signature_key callback dari Midtrans tanpa request ke jaringan.
+// signature_key = SHA512(order_id + status_code + gross_amount + server_key)
 func (m *midtransService) VerifyCallback(callback model.MidtransCallbackRequest) error {
 	// Validasi server key
 	if m.serverKey == "" {
 		return errors.New("SERVER_KEY tidak ditemukan")
 	}
 
-	// Encode server key untuk Basic Auth
-	encodedKey := base64.StdEncoding.EncodeToString([]byte(m.serverKey))
-
-	// URL untuk status transaksi
-	statusURL := fmt.Sprintf("https://api.sandbox.midtrans.com/v2/%s/status", callback.OrderID)
-
-	// Kirim request ke Midtrans untuk verifikasi status
-	resp, err := m.client.R().
-		SetHeader("Accept", "application/json").
-		SetHeader("Content-Type", "application/json").
-		SetHeader("Authorization", "Basic "+encodedKey).
-		Get(statusURL)
-
-	if err != nil {
-		log.Printf("Error verifying transaction status: %v", err)
-		return fmt.Errorf("error verifying transaction status: %w", err)
-	}
-
-	// Parse response
-	var statusResp map[string]interface{}
-	err = json.Unmarshal(resp.Body(), &statusResp)
-	if err != nil {
-		log.Printf("Error unmarshalling status response: %v", err)
-		return fmt.Errorf("error unmarshalling status response: %w", err)
-	}
-
-	// Verifikasi transaction_id
-	transactionID, ok := statusResp["transaction_id"].(string)
-	if !ok || transactionID != callback.TransactionID {
-		log.Printf("Transaction ID mismatch: %s vs %s", transactionID, callback.TransactionID)
-		return errors.New("transaction ID mismatch")
+	if callback.SignatureKey == "" {
+		return ErrInvalidSignature
 	}
 
-	// Verifikasi status transaksi
-	transactionStatus, ok := statusResp["transaction_status"].(string)
-	if !ok || transactionStatus != callback.TransactionStatus {
-		log.Printf("Transaction status mismatch: %s vs %s", transactionStatus, callback.TransactionStatus)
-		return errors.New("transaction status mismatch")
+	expected := MidtransSignature(callback.OrderID, callback.StatusCode, callback.GrossAmount, m.serverKey)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(callback.SignatureKey))) != 1 {
+		log.Printf("Invalid signature key for order %s", callback.OrderID)
+		return ErrInvalidSignature
 	}
 
 	return nil
 }
 
+// MidtransSignature menghitung signature_key notifikasi Midtrans dalam bentuk hex
+func MidtransSignature(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
 // GenerateOrderID menghasilkan ID order unik
 func (m *midtransService) GenerateOrderID() string {
 	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
@@ -136,33 +162,36 @@ func (m *midtransService) GenerateOrderID() string {
 }
 
 // CheckTransactionStatus memeriksa status transaksi di Midtrans
-func (m *midtransService) CheckTransactionStatus(orderID string) (string, error) {
+func (m *midtransService) CheckTransactionStatus(orderID string) (MidtransTransactionStatus, error) {
 	// Validasi server key
 	if m.serverKey == "" {
-		return "", errors.New("SERVER_KEY tidak ditemukan")
+		return MidtransTransactionStatus{}, errors.New("SERVER_KEY tidak ditemukan")
 	}
 
-	// Encode server key untuk Basic Auth
-	encodedKey := base64.StdEncoding.EncodeToString([]byte(m.serverKey))
-
 	// URL untuk status transaksi
-	statusURL := fmt.Sprintf("https://api.sandbox.midtrans.com/v2/%s/status", orderID)
+	statusURL := fmt.Sprintf("%s/v2/%s/status", m.endpoint.APIBaseURL, orderID)
 
 	// Kirim request ke Midtrans untuk mendapatkan status
 	resp, err := m.client.R().
 		SetHeader("Accept", "application/json").
 		SetHeader("Content-Type", "application/json").
-		SetHeader("Authorization", "Basic "+encodedKey).
+		SetHeader("Authorization", m.authHeader()).
 		Get(statusURL)
 
 	if err != nil {
 		log.Printf("Error checking transaction status: %v", err)
-		return "", fmt.Errorf("error checking transaction status: %w", err)
+		return MidtransTransactionStatus{}, fmt.Errorf("error checking transaction status: %w", err)
+	}
+
+	// Rate limit dan error server tidak boleh dianggap sebagai status pending
+	if resp.StatusCode() == 429 || resp.StatusCode() >= 500 {
+		return MidtransTransactionStatus{}, fmt.Errorf("error checking transaction status: midtrans returned HTTP %d", resp.StatusCode())
 	}
 
 	// Parse response
 	var statusResp struct {
 		TransactionStatus string `json:"transaction_status"`
+		FraudStatus       string `json:"fraud_status"`
 		StatusCode        string `json:"status_code"`
 		StatusMessage     string `json:"status_message"`
 	}
@@ -170,13 +199,20 @@ func (m *midtransService) CheckTransactionStatus(orderID string) (string, error)
 	err = json.Unmarshal(resp.Body(), &statusResp)
 	if err != nil {
 		log.Printf("Error unmarshalling status response: %v", err)
-		return "", fmt.Errorf("error unmarshalling status response: %w", err)
+		return MidtransTransactionStatus{}, fmt.Errorf("error unmarshalling status response: %w", err)
+	}
+
+	if statusResp.StatusCode == "404" {
+		return MidtransTransactionStatus{}, ErrMidtransTransactionNotFound
 	}
 
 	// Log status for debugging
-	log.Printf("Transaction status for order %s: %s", orderID, statusResp.TransactionStatus)
+	log.Printf("Transaction status for order %s: %s (fraud status: %s)", orderID, statusResp.TransactionStatus, statusResp.FraudStatus)
 
-	return statusResp.TransactionStatus, nil
+	return MidtransTransactionStatus{
+		TransactionStatus: statusResp.TransactionStatus,
+		FraudStatus:       statusResp.FraudStatus,
+	}, nil
 }
 
 // CancelTransaction membatalkan transaksi di Midtrans
@@ -186,17 +222,14 @@ func (m *midtransService) CancelTransaction(orderID string) error {
 		return errors.New("SERVER_KEY tidak ditemukan")
 	}
 
-	// Encode server key untuk Basic Auth
-	encodedKey := base64.StdEncoding.EncodeToString([]byte(m.serverKey))
-
 	// URL untuk cancel transaksi
-	cancelURL := fmt.Sprintf("https://api.sandbox.midtrans.com/v2/%s/cancel", orderID)
+	cancelURL := fmt.Sprintf("%s/v2/%s/cancel", m.endpoint.APIBaseURL, orderID)
 
 	// Kirim request ke Midtrans untuk cancel transaksi
 	resp, err := m.client.R().
 		SetHeader("Accept", "application/json").
 		SetHeader("Content-Type", "application/json").
-		SetHeader("Authorization", "Basic "+encodedKey).
+		SetHeader("Authorization", m.authHeader()).
 		Post(cancelURL)
 
 	if err != nil {
@@ -226,16 +259,63 @@ func (m *midtransService) CancelTransaction(orderID string) error {
 	return nil
 }
 
+// RefundTransaction mengembalikan dana (penuh atau sebagian) transaksi yang sudah settle.
+// refundKey harus unik per refund, Midtrans memakainya untuk mencegah refund ganda.
+func (m *midtransService) RefundTransaction(orderID string, refundKey string, amount int, reason string) error {
+	// Validasi server key
+	if m.serverKey == "" {
+		return errors.New("SERVER_KEY tidak ditemukan")
+	}
+
+	// URL untuk refund transaksi
+	refundURL := fmt.Sprintf("%s/v2/%s/refund", m.endpoint.APIBaseURL, orderID)
+
+	resp, err := m.client.R().
+		SetHeader("Accept", "application/json").
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", m.authHeader()).
+		SetBody(map[string]interface{}{
+			"refund_key": refundKey,
+			"amount":     amount,
+			"reason":     reason,
+		}).
+		Post(refundURL)
+
+	if err != nil {
+		log.Printf("Error refunding transaction: %v", err)
+		return fmt.Errorf("error refunding transaction: %w", err)
+	}
+
+	// Parse response
+	var refundResp struct {
+		StatusCode    string `json:"status_code"`
+		StatusMessage string `json:"status_message"`
+	}
+
+	err = json.Unmarshal(resp.Body(), &refundResp)
+	if err != nil {
+		log.Printf("Error unmarshalling refund response: %v", err)
+		return fmt.Errorf("error unmarshalling refund response: %w", err)
+	}
+
+	if refundResp.StatusCode != "200" {
+		log.Printf("Failed to refund transaction: %s", refundResp.StatusMessage)
+		return fmt.Errorf("failed to refund transaction: %s", refundResp.StatusMessage)
+	}
+
+	log.Printf("Transaction %s refunded %d (%s)", orderID, amount, refundKey)
+	return nil
+}
+
 // NewMidtransService membuat instance baru dari MidtransService
-func NewMidtransService(client *resty.Client) MidtransServiceInterface {
-	serverKey := os.Getenv("SERVER_KEY")
+func NewMidtransService(client *resty.Client, serverKey string, endpoint MidtransEndpoint) MidtransServiceInterface {
 	if serverKey == "" {
 		log.Println("WARNING: SERVER_KEY environment variable is not set")
 	}
 
 	return &midtransService{
 		client:    client,
-		url:       "https://app.sandbox.midtrans.com/snap/v1/transactions",
+		endpoint:  endpoint,
 		serverKey: serverKey,
 	}
 }
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pijar/model"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func TestMidtransSignature(t *testing.T) {
+	// sha512("ORDER-1" + "200" + "10000.00" + "SB-Mid-server-test")
+	const want = "6d210e4bd6a509be896f4d0421579cc94d548176050c39da7315790a739596eb1eefcca42fdbb52797dfe51e69af26356ced10b299c097e68ca25ba8880735bc"
+	if got := MidtransSignature("ORDER-1", "200", "10000.00", "SB-Mid-server-test"); got != want {
+		t.Errorf("MidtransSignature() = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyCallback(t *testing.T) {
+	const serverKey = "SB-Mid-server-test"
+	valid := model.MidtransCallbackRequest{
+		OrderID:      "ORDER-1",
+		StatusCode:   "200",
+		GrossAmount:  "10000.00",
+		SignatureKey: MidtransSignature("ORDER-1", "200", "10000.00", serverKey),
+	}
+
+	tests := []struct {
+		name      string
+		serverKey string
+		modify    func(c *model.MidtransCallbackRequest)
+		wantErr   error
+	}{
+		{
+			name:      "valid signature",
+			serverKey: serverKey,
+			modify:    func(c *model.MidtransCallbackRequest) {},
+		},
+		{
+			name:      "uppercase signature is accepted",
+			serverKey: serverKey,
+			modify:    func(c *model.MidtransCallbackRequest) { c.SignatureKey = strings.ToUpper(c.SignatureKey) },
+		},
+		{
+			name:      "missing signature",
+			serverKey: serverKey,
+			modify:    func(c *model.MidtransCallbackRequest) { c.SignatureKey = "" },
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "tampered gross amount",
+			serverKey: serverKey,
+			modify:    func(c *model.MidtransCallbackRequest) { c.GrossAmount = "1.00" },
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "tampered status code",
+			serverKey: serverKey,
+			modify:    func(c *model.MidtransCallbackRequest) { c.StatusCode = "201" },
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "signed with another server key",
+			serverKey: "SB-Mid-server-other",
+			modify:    func(c *model.MidtransCallbackRequest) {},
+			wantErr:   ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback := valid
+			tt.modify(&callback)
+			m := &midtransService{serverKey: tt.serverKey}
+
+			err := m.VerifyCallback(callback)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("VerifyCallback() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyCallback() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("missing server key", func(t *testing.T) {
+		m := &midtransService{}
+		if err := m.VerifyCallback(valid); err == nil {
+			t.Fatal("VerifyCallback() without a server key succeeded")
+		}
+	})
+}
+
+func TestCheckTransactionStatusReadsFraudStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status_code":"201","transaction_status":"capture","fraud_status":"challenge"}`))
+	}))
+	defer server.Close()
+
+	m := &midtransService{
+		client:    resty.New(),
+		endpoint:  MidtransEndpoint{APIBaseURL: server.URL},
+		serverKey: "SB-Mid-server-test",
+	}
+
+	got, err := m.CheckTransactionStatus("ORDER-1")
+	if err != nil {
+		t.Fatalf("CheckTransactionStatus() error = %v", err)
+	}
+	want := MidtransTransactionStatus{TransactionStatus: "capture", FraudStatus: "challenge"}
+	if got != want {
+		t.Errorf("CheckTransactionStatus() = %+v, want %+v", got, want)
+	}
+}
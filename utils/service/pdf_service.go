@@ -182,4 +182,218 @@ func GenerateJournalsPDF(journals []JournalPDF) (*gofpdf.Fpdf, error) {
 	pdf.AliasNbPages("")
 	
 	return pdf, nil
-}
\ No newline at end of file
+}
+
+// GenerateReceiptPDF generates a payment receipt for a paid transaction
+func GenerateReceiptPDF(receipt model.Receipt) (*gofpdf.Fpdf, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	// Warna disamakan dengan ekspor jurnal
+	primaryColor := []int{41, 128, 185}    // Biru
+	secondaryColor := []int{236, 240, 241} // Abu-abu muda
+	accentColor := []int{52, 152, 219}     // Biru muda
+
+	transaction := receipt.Transaction
+
+	// ----- Header Dokumen -----
+	pdf.SetFont("Arial", "B", 22)
+	pdf.SetTextColor(primaryColor[0], primaryColor[1], primaryColor[2])
+	pdf.Cell(0, 10, "Bukti Pembayaran")
+	pdf.Ln(15)
+
+	pdf.SetFont("Arial", "I", 10)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.Cell(0, 6, "Dicetak pada: "+time.Now().Format("02 January 2006 15:04:05"))
+	pdf.Ln(15)
+
+	pdf.SetDrawColor(accentColor[0], accentColor[1], accentColor[2])
+	pdf.SetLineWidth(0.5)
+	pdf.Line(15, pdf.GetY(), 195, pdf.GetY())
+	pdf.Ln(10)
+
+	// ----- Detail transaksi -----
+	rows := [][2]string{
+		{"Nomor Pesanan", transaction.OrderID},
+		{"Tanggal Transaksi", transaction.CreatedAt.Format("02 January 2006 15:04")},
+		{"Status", strings.ToUpper(transaction.Status)},
+		{"Nama Pelanggan", receipt.Customer.Name},
+		{"Email", receipt.Customer.Email},
+	}
+	if transaction.MidtransID != "" {
+		rows = append(rows, [2]string{"ID Pembayaran", transaction.MidtransID})
+	}
+	for _, row := range rows {
+		pdf.SetFont("Arial", "B", 11)
+		pdf.SetTextColor(primaryColor[0], primaryColor[1], primaryColor[2])
+		pdf.CellFormat(50, 8, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 11)
+		pdf.SetTextColor(50, 50, 50)
+		pdf.CellFormat(0, 8, row[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(8)
+
+	// ----- Rincian pembelian -----
+	pdf.SetFillColor(primaryColor[0], primaryColor[1], primaryColor[2])
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(130, 10, "Produk", "", 0, "L", true, 0, "")
+	pdf.CellFormat(50, 10, "Jumlah", "", 1, "R", true, 0, "")
+
+	pdf.SetFillColor(secondaryColor[0], secondaryColor[1], secondaryColor[2])
+	pdf.SetTextColor(50, 50, 50)
+	pdf.SetFont("Arial", "", 11)
+	pdf.CellFormat(130, 10, receipt.Product.Name, "", 0, "L", true, 0, "")
+	pdf.CellFormat(50, 10, formatRupiah(transaction.Amount), "", 1, "R", true, 0, "")
+
+	for _, refund := range receipt.Refunds {
+		if refund.Status != model.RefundStatusSucceeded {
+			continue
+		}
+		label := "Refund " + refund.CreatedAt.Format("02/01/2006")
+		if refund.Reason != "" {
+			label += " - " + refund.Reason
+		}
+		pdf.CellFormat(130, 10, label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 10, "-"+formatRupiah(refund.Amount), "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetDrawColor(accentColor[0], accentColor[1], accentColor[2])
+	pdf.SetLineWidth(0.2)
+	pdf.Line(15, pdf.GetY(), 195, pdf.GetY())
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.SetTextColor(primaryColor[0], primaryColor[1], primaryColor[2])
+	pdf.CellFormat(130, 10, "Total Dibayar", "", 0, "L", false, 0, "")
+	pdf.CellFormat(50, 10, formatRupiah(transaction.Amount-receipt.RefundedAmount), "", 1, "R", false, 0, "")
+
+	// Footer
+	pdf.SetY(-15)
+	pdf.SetFont("Arial", "I", 8)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.CellFormat(0, 10, "Halaman "+strconv.Itoa(pdf.PageNo())+"/{nb}", "", 0, "C", false, 0, "")
+	pdf.AliasNbPages("")
+
+	return pdf, pdf.Error()
+}
+
+// formatRupiah memformat nominal menjadi "Rp 150.000"
+func formatRupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + "Rp " + b.String()
+}
+
+// GenerateCoachTranscriptPDF generates a PDF transcript of a coach session
+func GenerateCoachTranscriptPDF(transcript model.CoachTranscript) (*gofpdf.Fpdf, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+
+	// Font bawaan hanya mendukung cp1252, teks UTF-8 diterjemahkan dulu
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// Warna disamakan dengan ekspor jurnal
+	primaryColor := []int{41, 128, 185}    // Biru
+	secondaryColor := []int{236, 240, 241} // Abu-abu muda
+	accentColor := []int{52, 152, 219}     // Biru muda
+
+	// Transkrip bisa panjang, jadi nomor halaman dicetak di setiap halaman
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.CellFormat(0, 10, "Halaman "+strconv.Itoa(pdf.PageNo())+"/{nb}", "", 0, "C", false, 0, "")
+	})
+	pdf.AliasNbPages("")
+	pdf.AddPage()
+
+	// ----- Header Dokumen -----
+	pdf.SetFont("Arial", "B", 22)
+	pdf.SetTextColor(primaryColor[0], primaryColor[1], primaryColor[2])
+	pdf.Cell(0, 10, "Transkrip Sesi AI Coach")
+	pdf.Ln(15)
+
+	pdf.SetFont("Arial", "I", 10)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.Cell(0, 6, "Dicetak pada: "+transcript.ExportedAt.Format("02 January 2006 15:04:05"))
+	pdf.Ln(12)
+
+	// ----- Detail sesi -----
+	rows := [][2]string{}
+	if transcript.Title != "" {
+		rows = append(rows, [2]string{"Judul", transcript.Title})
+	}
+	if transcript.Persona != "" {
+		rows = append(rows, [2]string{"Persona", transcript.Persona})
+	}
+	if !transcript.StartedAt.IsZero() {
+		rows = append(rows, [2]string{"Dimulai", transcript.StartedAt.Format("02 January 2006 15:04")})
+	}
+	rows = append(rows, [2]string{"ID Sesi", transcript.SessionID})
+	for _, row := range rows {
+		pdf.SetFont("Arial", "B", 11)
+		pdf.SetTextColor(primaryColor[0], primaryColor[1], primaryColor[2])
+		pdf.CellFormat(35, 7, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 11)
+		pdf.SetTextColor(50, 50, 50)
+		pdf.MultiCell(145, 7, tr(row[1]), "", "L", false)
+	}
+	pdf.Ln(5)
+
+	// ----- Garis pemisah -----
+	pdf.SetDrawColor(accentColor[0], accentColor[1], accentColor[2])
+	pdf.SetLineWidth(0.5)
+	pdf.Line(15, pdf.GetY(), 195, pdf.GetY())
+	pdf.Ln(8)
+
+	if len(transcript.Messages) == 0 {
+		pdf.SetFont("Arial", "I", 11)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.Cell(0, 6, "Belum ada pesan dalam sesi ini.")
+	}
+
+	for _, msg := range transcript.Messages {
+		// Jangan biarkan label pembicara tertinggal sendirian di bawah halaman
+		if pdf.GetY() > 255 {
+			pdf.AddPage()
+		}
+
+		// ----- Label pembicara dan waktu -----
+		pdf.SetFont("Arial", "B", 11)
+		pdf.SetTextColor(primaryColor[0], primaryColor[1], primaryColor[2])
+		pdf.CellFormat(90, 6, msg.Speaker, "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 9)
+		pdf.SetTextColor(100, 100, 100)
+		timestamp := ""
+		if msg.Timestamp != nil {
+			timestamp = msg.Timestamp.Format("02 Jan 2006 15:04")
+		}
+		pdf.CellFormat(90, 6, timestamp, "", 1, "R", false, 0, "")
+		pdf.Ln(1)
+
+		// ----- Isi pesan -----
+		// Pesan user diberi latar abu-abu agar mudah dibedakan dari jawaban coach
+		content := strings.TrimSpace(strings.ReplaceAll(msg.Content, "\r\n", "\n"))
+		pdf.SetFont("Arial", "", 11)
+		pdf.SetTextColor(50, 50, 50)
+		pdf.SetFillColor(secondaryColor[0], secondaryColor[1], secondaryColor[2])
+		pdf.MultiCell(180, 5.5, tr(content), "", "L", msg.Role == "user")
+		pdf.Ln(6)
+	}
+
+	return pdf, pdf.Error()
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"pijar/model"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultCrisisKeywords adalah frasa yang langsung dianggap berisiko tinggi.
+// Daftar ini bisa ditambah lewat SAFETY_KEYWORDS.
+var DefaultCrisisKeywords = []string{
+	"bunuh diri",
+	"ingin mati",
+	"pengen mati",
+	"pingin mati",
+	"mau mati saja",
+	"mengakhiri hidup",
+	"akhiri hidupku",
+	"menyakiti diri",
+	"melukai diri",
+	"self harm",
+	"self-harm",
+	"suicide",
+	"kill myself",
+	"end my life",
+	"want to die",
+	"hurt myself",
+}
+
+// DefaultCrisisHotlines adalah layanan bantuan yang ditampilkan jika SAFETY_HOTLINES kosong
+var DefaultCrisisHotlines = []model.Hotline{
+	{Name: "Layanan Kesehatan Jiwa Kemenkes (SEJIWA)", Contact: "119 ext. 8"},
+	{Name: "Layanan Darurat", Contact: "112"},
+	{Name: "Into The Light Indonesia", Contact: "https://www.intothelightid.org"},
+}
+
+// SafetyClassifier memeriksa risiko krisis atau menyakiti diri pada sebuah teks
+type SafetyClassifier interface {
+	// AssessInput memeriksa tulisan user (pesan coach atau jurnal)
+	AssessInput(ctx context.Context, text string) model.SafetyAssessment
+	// AssessOutput memeriksa jawaban AI sebelum dikirim ke user
+	AssessOutput(ctx context.Context, text string) model.SafetyAssessment
+	// MatchKeywords mengembalikan kata kunci krisis yang ada di teks, tanpa memanggil LLM
+	MatchKeywords(text string) []string
+}
+
+type safetyClassifier struct {
+	keywords [][]string  // setiap kata kunci disimpan sebagai urutan kata
+	llm      LLMProvider // nil berarti hanya pemeriksaan kata kunci
+}
+
+// NewSafetyClassifier membuat classifier kata kunci, ditambah pemeriksaan LLM jika llm tidak nil
+func NewSafetyClassifier(keywords []string, llm LLMProvider) SafetyClassifier {
+	normalized := make([][]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if words := safetyWords(keyword); len(words) > 0 {
+			normalized = append(normalized, words)
+		}
+	}
+	return &safetyClassifier{
+		keywords: normalized,
+		llm:      llm,
+	}
+}
+
+const safetyInputPrompt = `You are a safety classifier for a mental health journaling and coaching app.
+Classify the risk that the author of the following text intends to harm themselves or others, or is in acute crisis.
+Use "high" for explicit or implied intent, plans or means of self-harm or suicide; "elevated" for hopelessness, passive wishes to disappear, or severe distress without intent; otherwise "none".
+Reply with JSON only: {"risk_level": "none|elevated|high", "categories": ["..."]}`
+
+const safetyOutputPrompt = `You are a safety reviewer for replies written by an AI mental health coach.
+Classify whether the following reply is unsafe: it encourages or normalizes self-harm or suicide, gives methods or means, dismisses a user in crisis, or gives dangerous medical advice.
+Use "high" for any of those, "elevated" for borderline replies, otherwise "none".
+Reply with JSON only: {"risk_level": "none|elevated|high", "categories": ["..."]}`
+
+func (s *safetyClassifier) AssessInput(ctx context.Context, text string) model.SafetyAssessment {
+	if matched := s.MatchKeywords(text); len(matched) > 0 {
+		return model.SafetyAssessment{
+			RiskLevel:       model.SafetyRiskHigh,
+			Categories:      []string{"self_harm"},
+			MatchedKeywords: matched,
+			Method:          "keyword",
+		}
+	}
+
+	return s.assessWithLLM(ctx, safetyInputPrompt, text)
+}
+
+// MatchKeywords mencocokkan kata kunci sebagai kata utuh, jadi "ingin matikan lampu"
+// tidak dianggap berisi "ingin mati"
+func (s *safetyClassifier) MatchKeywords(text string) []string {
+	words := safetyWords(text)
+	var matched []string
+	for _, keyword := range s.keywords {
+		if containsWords(words, keyword) {
+			matched = append(matched, strings.Join(keyword, " "))
+		}
+	}
+	return matched
+}
+
+// containsWords melaporkan apakah sequence muncul berurutan di dalam words
+func containsWords(words, sequence []string) bool {
+	for i := 0; i+len(sequence) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(sequence)], sequence) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *safetyClassifier) AssessOutput(ctx context.Context, text string) model.SafetyAssessment {
+	// Jawaban coach yang suportif sering menyebut kata kunci krisis, jadi hanya diperiksa LLM
+	return s.assessWithLLM(ctx, safetyOutputPrompt, text)
+}
+
+// assessWithLLM meminta LLM mengklasifikasi teks. Jika LLM gagal, teks dianggap aman
+// karena pemeriksaan kata kunci sudah dijalankan lebih dulu.
+func (s *safetyClassifier) assessWithLLM(ctx context.Context, instruction string, text string) model.SafetyAssessment {
+	none := model.SafetyAssessment{RiskLevel: model.SafetyRiskNone, Method: "keyword"}
+	if s.llm == nil || strings.TrimSpace(text) == "" {
+		return none
+	}
+
+	resp, err := AskLLM(ctx, s.llm, "Text:\n"+text, LLMOptions{
+		SystemPrompt: instruction,
+		Temperature:  0.1,
+		MaxTokens:    100,
+	})
+	if err != nil {
+		log.Printf("Safety LLM check failed: %v", err)
+		return none
+	}
+
+	assessment, err := parseSafetyResponse(resp)
+	if err != nil {
+		log.Printf("Safety LLM check returned invalid response: %v", err)
+		return none
+	}
+	return assessment
+}
+
+func parseSafetyResponse(resp string) (model.SafetyAssessment, error) {
+	start := strings.Index(resp, "{")
+	end := strings.LastIndex(resp, "}")
+	if start == -1 || end < start {
+		return model.SafetyAssessment{}, fmt.Errorf("no JSON object in %q", resp)
+	}
+
+	var result struct {
+		RiskLevel  string   `json:"risk_level"`
+		Categories []string `json:"categories"`
+	}
+	if err := json.Unmarshal([]byte(resp[start:end+1]), &result); err != nil {
+		return model.SafetyAssessment{}, err
+	}
+
+	switch result.RiskLevel {
+	case model.SafetyRiskNone, model.SafetyRiskElevated, model.SafetyRiskHigh:
+	default:
+		return model.SafetyAssessment{}, fmt.Errorf("unknown risk level %q", result.RiskLevel)
+	}
+
+	return model.SafetyAssessment{
+		RiskLevel:  result.RiskLevel,
+		Categories: result.Categories,
+		Method:     "llm",
+	}, nil
+}
+
+// safetyWords memecah teks menjadi kata huruf kecil. Tanda baca memisahkan kata,
+// kecuali tanda hubung dan apostrof yang menjadi bagian kata seperti "self-harm".
+func safetyWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	})
+}
+
+// ParseHotlines membaca daftar hotline dengan format "Nama|Kontak;Nama|Kontak"
+func ParseHotlines(s string) ([]model.Hotline, error) {
+	var hotlines []model.Hotline
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, contact, ok := strings.Cut(entry, "|")
+		name, contact = strings.TrimSpace(name), strings.TrimSpace(contact)
+		if !ok || name == "" || contact == "" {
+			return nil, fmt.Errorf("invalid hotline %q, expected Name|Contact", entry)
+		}
+		hotlines = append(hotlines, model.Hotline{Name: name, Contact: contact})
+	}
+	return hotlines, nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatchKeywords(t *testing.T) {
+	classifier := NewSafetyClassifier(DefaultCrisisKeywords, nil)
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "phrase as whole words", text: "Rasanya aku ingin mati saja", want: []string{"ingin mati"}},
+		{name: "punctuation around the phrase", text: "Aku pengen mati. Capek.", want: []string{"pengen mati"}},
+		{name: "case and extra spaces", text: "kadang  INGIN   Mati", want: []string{"ingin mati"}},
+		{name: "hyphenated keyword", text: "thinking about self-harm again", want: []string{"self-harm"}},
+		{name: "turning off the lamp", text: "ingin matikan lampu dulu sebelum tidur", want: nil},
+		{name: "turning off the phone", text: "pengen matiin hp biar fokus", want: nil},
+		{name: "word that only starts like a keyword", text: "aku ingin matikan notifikasi", want: nil},
+		{name: "ordinary text", text: "Hari ini menyenangkan", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.MatchKeywords(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("MatchKeywords(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}